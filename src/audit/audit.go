@@ -0,0 +1,164 @@
+package audit
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+
+	"github.com/haseakito/ec_api/auth"
+	"github.com/haseakito/ec_api/models"
+)
+
+// Fields which change on every write and are left out of the diff
+var ignoredFields = map[string]bool{
+	"created_at": true,
+	"updated_at": true,
+}
+
+/*
+Description:
+
+	Change holds the value of a field before and after a mutation.
+*/
+type Change struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+/*
+Description:
+
+	Record an audit event for a mutation made by the actor of the request.
+	It should be called with the transaction performing the mutation so that both are committed together.
+
+Parameters:
+
+	tx (*gorm.DB): The transaction performing the mutation.
+	c (echo.Context): Context object containing the HTTP request information.
+	storeID (string): The ID of the store the entity belongs to.
+	entityType (string): The type of the entity, e.g. product.
+	entityID (string): The ID of the entity.
+	action (string): The mutation, one of models.AuditCreate, models.AuditUpdate, models.AuditDelete or models.AuditRestore.
+	before (interface{}): The entity before the mutation. nil for creations.
+	after (interface{}): The entity after the mutation. nil for deletions.
+
+Returns:
+
+	error: Any error encountered while computing the diff or creating the event.
+*/
+func Record(tx *gorm.DB, c echo.Context, storeID, entityType, entityID, action string, before, after interface{}) error {
+	// Get the actor of the request
+	actorType, actorID := auth.CurrentActor(c)
+
+	return RecordActor(tx, actorType, actorID, c.Response().Header().Get(echo.HeaderXRequestID), storeID, entityType, entityID, action, before, after)
+}
+
+/*
+Description:
+
+	Record an audit event for a mutation made on behalf of an actor outside of a request, such as by a background job
+	started by the actor. It should be called with the transaction performing the mutation so that both are committed together.
+
+Parameters:
+
+	tx (*gorm.DB): The transaction performing the mutation.
+	actorType (string): The type of the actor, either user or api_key.
+	actorID (string): The ID of the user or the API key.
+	requestID (string): The ID of the request which initiated the mutation.
+	storeID (string): The ID of the store the entity belongs to.
+	entityType (string): The type of the entity, e.g. product.
+	entityID (string): The ID of the entity.
+	action (string): The mutation, one of models.AuditCreate, models.AuditUpdate, models.AuditDelete or models.AuditRestore.
+	before (interface{}): The entity before the mutation. nil for creations.
+	after (interface{}): The entity after the mutation. nil for deletions.
+
+Returns:
+
+	error: Any error encountered while computing the diff or creating the event.
+*/
+func RecordActor(tx *gorm.DB, actorType, actorID, requestID, storeID, entityType, entityID, action string, before, after interface{}) error {
+	// Compute the changed fields
+	changes, err := Diff(before, after)
+	if err != nil {
+		return err
+	}
+
+	raw, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+
+	return tx.Create(&models.AuditEvent{
+		StoreID:    storeID,
+		ActorType:  actorType,
+		ActorID:    actorID,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Action:     action,
+		Changes:    models.JSON(raw),
+		RequestID:  requestID,
+	}).Error
+}
+
+/*
+Description:
+
+	Compute the fields which differ between the JSON representations of two entities.
+
+Parameters:
+
+	before (interface{}): The entity before the mutation. Nullable.
+	after (interface{}): The entity after the mutation. Nullable.
+
+Returns:
+
+	(map[string]Change, error): The changed fields keyed by their JSON name. Otherwise, any error encountered while encoding the entities.
+*/
+func Diff(before, after interface{}) (map[string]Change, error) {
+	beforeFields, err := fields(before)
+	if err != nil {
+		return nil, err
+	}
+
+	afterFields, err := fields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := map[string]Change{}
+	for key, value := range beforeFields {
+		if ignoredFields[key] || reflect.DeepEqual(value, afterFields[key]) {
+			continue
+		}
+		changes[key] = Change{Before: value, After: afterFields[key]}
+	}
+	for key, value := range afterFields {
+		if _, ok := beforeFields[key]; ok || ignoredFields[key] || value == nil {
+			continue
+		}
+		changes[key] = Change{Before: nil, After: value}
+	}
+
+	return changes, nil
+}
+
+// Decode the JSON representation of the entity into its fields
+func fields(entity interface{}) (map[string]interface{}, error) {
+	res := map[string]interface{}{}
+	if entity == nil || (reflect.ValueOf(entity).Kind() == reflect.Ptr && reflect.ValueOf(entity).IsNil()) {
+		return res, nil
+	}
+
+	raw, err := json.Marshal(entity)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(raw, &res); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
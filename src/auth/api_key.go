@@ -0,0 +1,127 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+
+	"github.com/haseakito/ec_api/models"
+	"github.com/haseakito/ec_api/utils"
+)
+
+// APIKeyPrefix is the prefix of every API key, used to tell them apart from session tokens
+const APIKeyPrefix = "eck_"
+
+// ErrNoAPIKey is returned when no API key is set in the context.
+var ErrNoAPIKey = errors.New("no API key in context")
+
+// Scope required from API keys for each permission. Permissions missing here are never granted to API keys.
+var permissionScopes = map[Permission]string{
+	PermProductsRead:   models.ScopeCatalogRead,
+	PermProductsWrite:  models.ScopeCatalogWrite,
+	PermProductsDelete: models.ScopeCatalogWrite,
+	PermOrdersRead:     models.ScopeOrdersRead,
+	PermOrdersWrite:    models.ScopeOrdersWrite,
+	PermRevenueRead:    models.ScopeOrdersRead,
+}
+
+/*
+Description:
+
+	APIKeyAuthMiddleware is used to authenticate incoming requests with either a store API key or a session token.
+	API keys are read from the X-API-Key header, or from the bearer token when it starts with APIKeyPrefix.
+	If the API key is valid and not revoked, the key is set in the context. Otherwise, the request is authenticated
+	with the provided Authenticator as AuthMiddleware does.
+
+Parameters:
+
+	authenticator (Authenticator): The Authenticator used to verify session tokens.
+	db (*gorm.DB): A pointer to the GORM database connection.
+
+Returns:
+
+	echo.MiddlewareFunc: An Echo middleware function that performs authentication for incoming requests.
+*/
+func APIKeyAuthMiddleware(authenticator Authenticator, db *gorm.DB) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			// Obtain the API key from the request header
+			token := c.Request().Header.Get("X-API-Key")
+			if bearer := strings.TrimPrefix(c.Request().Header.Get("Authorization"), "Bearer "); token == "" && strings.HasPrefix(bearer, APIKeyPrefix) {
+				token = bearer
+			}
+
+			// If there is no API key, then authenticate the session token
+			if token == "" {
+				user, err := authenticate(authenticator, c)
+				if err != nil {
+					return echo.ErrUnauthorized
+				}
+
+				c.Set("user", user)
+				return next(c)
+			}
+
+			// Get an active API key with the hash of the key
+			// If there is no record, then throw an unauthorized error
+			var key models.APIKey
+			if err := db.Take(&key, "key_hash = ? AND revoked_at IS NULL", utils.HashToken(token)).Error; err != nil {
+				return echo.ErrUnauthorized
+			}
+
+			// Record the usage of the key
+			now := time.Now()
+			db.Model(&key).UpdateColumn("last_used_at", now)
+
+			// Set API key information in the context
+			c.Set("api_key", &key)
+
+			// Call the next handler in the middleware chain
+			return next(c)
+		}
+	}
+}
+
+/*
+Description:
+
+	Get the API key set in the context by APIKeyAuthMiddleware.
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	(*models.APIKey, error): The API key. Otherwise, ErrNoAPIKey if the request was not authenticated with an API key.
+*/
+func CurrentAPIKey(c echo.Context) (*models.APIKey, error) {
+	key, ok := c.Get("api_key").(*models.APIKey)
+	if !ok || key == nil {
+		return nil, ErrNoAPIKey
+	}
+
+	return key, nil
+}
+
+/*
+Description:
+
+	Check whether the API key is granted the scope required by the permission.
+
+Parameters:
+
+	key (*models.APIKey): The API key to check.
+	permission (Permission): The permission to check.
+
+Returns:
+
+	bool: true if the key is granted the permission, false otherwise.
+*/
+func APIKeyHasPermission(key *models.APIKey, permission Permission) bool {
+	scope, ok := permissionScopes[permission]
+	return ok && key.HasScope(scope)
+}
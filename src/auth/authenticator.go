@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"errors"
+	"os"
+
+	"github.com/clerkinc/clerk-sdk-go/clerk"
+)
+
+/*
+Description:
+
+	Authenticator verifies the bearer token of a request and resolves the user it belongs to.
+	AuthMiddleware and OptionalAuthMiddleware depend on this interface so that the identity provider can be swapped.
+*/
+type Authenticator interface {
+	Authenticate(token string) (*clerk.User, error)
+}
+
+/*
+Description:
+
+	Instantiates a new Authenticator based on the AUTH_PROVIDER environment variable.
+	"clerk" (default) uses the Clerk API with CLERK_SECRET_KEY.
+	"jwt" verifies tokens locally with JWT_HS256_SECRET or JWT_RS256_PUBLIC_KEY_FILE,
+	optionally checking JWT_ISSUER and JWT_AUDIENCE.
+
+Returns:
+
+	(Authenticator, error): The configured Authenticator. Otherwise, any error encountered during the setup.
+*/
+func NewAuthenticatorFromEnv() (Authenticator, error) {
+	switch os.Getenv("AUTH_PROVIDER") {
+	case "", "clerk":
+		// Initialize clerk client
+		client, err := clerk.NewClient(os.Getenv("CLERK_SECRET_KEY"))
+		if err != nil {
+			return nil, err
+		}
+
+		return NewClerkAuthenticator(client), nil
+
+	case "jwt":
+		opts := JWTOptions{
+			Issuer:   os.Getenv("JWT_ISSUER"),
+			Audience: os.Getenv("JWT_AUDIENCE"),
+		}
+
+		// Prefer the RS256 public key when both are configured
+		if path := os.Getenv("JWT_RS256_PUBLIC_KEY_FILE"); path != "" {
+			pem, err := os.ReadFile(path)
+			if err != nil {
+				return nil, err
+			}
+
+			return NewRS256Authenticator(pem, opts)
+		}
+
+		if secret := os.Getenv("JWT_HS256_SECRET"); secret != "" {
+			return NewHS256Authenticator([]byte(secret), opts)
+		}
+
+		return nil, errors.New("auth: JWT_HS256_SECRET or JWT_RS256_PUBLIC_KEY_FILE is required for the jwt provider")
+
+	default:
+		return nil, errors.New("auth: unknown AUTH_PROVIDER " + os.Getenv("AUTH_PROVIDER"))
+	}
+}
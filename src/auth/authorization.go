@@ -0,0 +1,252 @@
+package auth
+
+import (
+	"errors"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+
+	"github.com/haseakito/ec_api/models"
+)
+
+/*
+Description:
+
+	StoreResolver resolves the store that owns the resource targeted by the incoming request.
+*/
+type StoreResolver func(db *gorm.DB, c echo.Context) (*models.Store, error)
+
+/*
+Description:
+
+	Resolve the store directly from the `:id` path parameter.
+
+Parameters:
+
+	db (*gorm.DB): A pointer to the GORM database connection.
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	(*models.Store, error): The store with the store id. Otherwise, any error encountered during the query.
+*/
+func StoreFromParam(db *gorm.DB, c echo.Context) (*models.Store, error) {
+	var store models.Store
+	if err := db.Take(&store, "id = ?", c.Param("id")).Error; err != nil {
+		return nil, err
+	}
+
+	return &store, nil
+}
+
+/*
+Description:
+
+	Resolve the store through the product referenced by the `:id` path parameter.
+
+Parameters:
+
+	db (*gorm.DB): A pointer to the GORM database connection.
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	(*models.Store, error): The store the product belongs to. Otherwise, any error encountered during the query.
+*/
+func StoreFromProductParam(db *gorm.DB, c echo.Context) (*models.Store, error) {
+	var product models.Product
+	if err := db.Select("id", "store_id").Take(&product, "id = ?", c.Param("id")).Error; err != nil {
+		return nil, err
+	}
+
+	var store models.Store
+	if err := db.Take(&store, "id = ?", product.StoreID).Error; err != nil {
+		return nil, err
+	}
+
+	return &store, nil
+}
+
+/*
+Description:
+
+	Resolve the trashed store directly from the `:id` path parameter.
+
+Parameters:
+
+	db (*gorm.DB): A pointer to the GORM database connection.
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	(*models.Store, error): The trashed store with the store id. Otherwise, any error encountered during the query.
+*/
+func TrashedStoreFromParam(db *gorm.DB, c echo.Context) (*models.Store, error) {
+	var store models.Store
+	if err := db.Unscoped().Take(&store, "id = ? AND deleted_at IS NOT NULL", c.Param("id")).Error; err != nil {
+		return nil, err
+	}
+
+	return &store, nil
+}
+
+/*
+Description:
+
+	Resolve the store through the trashed product referenced by the `:id` path parameter. The store itself must not be trashed.
+
+Parameters:
+
+	db (*gorm.DB): A pointer to the GORM database connection.
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	(*models.Store, error): The store the product belongs to. Otherwise, any error encountered during the query.
+*/
+func StoreFromTrashedProductParam(db *gorm.DB, c echo.Context) (*models.Store, error) {
+	var product models.Product
+	if err := db.Unscoped().Select("id", "store_id").Take(&product, "id = ? AND deleted_at IS NOT NULL", c.Param("id")).Error; err != nil {
+		return nil, err
+	}
+
+	var store models.Store
+	if err := db.Take(&store, "id = ?", product.StoreID).Error; err != nil {
+		return nil, err
+	}
+
+	return &store, nil
+}
+
+/*
+Description:
+
+	Resolve the role of the user in the store. The user referenced by Store.UserID is always the owner,
+	other users hold the role of their accepted membership.
+
+Parameters:
+
+	db (*gorm.DB): A pointer to the GORM database connection.
+	store (*models.Store): The store to resolve the role in.
+	userID (string): The ID of the user.
+
+Returns:
+
+	(string, bool): The role of the user, and false if the user is not a member of the store.
+*/
+func StoreRole(db *gorm.DB, store *models.Store, userID string) (string, bool) {
+	if store.UserID == userID {
+		return models.RoleOwner, true
+	}
+
+	var member models.StoreMember
+	if err := db.Take(&member, "store_id = ? AND user_id = ? AND accepted_at IS NOT NULL", store.ID, userID).Error; err != nil {
+		return "", false
+	}
+
+	return member.Role, true
+}
+
+/*
+Description:
+
+	StoreMemberMiddleware is used to authorize incoming requests against the store behind the requested resource.
+	It must be installed after AuthMiddleware or APIKeyAuthMiddleware. It resolves the store with the provided resolver,
+	and rejects the request with a Forbidden error if the authenticated user is neither the owner nor a member of the store,
+	or if the API key was issued by another store.
+	If the user has a role in the store, the store and the role are set in the context,
+	and the request is passed to the next handler in the middleware chain.
+
+Parameters:
+
+	db (*gorm.DB): A pointer to the GORM database connection.
+	resolve (StoreResolver): The function used to resolve the store from the request.
+
+Returns:
+
+	echo.MiddlewareFunc: An Echo middleware function that performs authorization for incoming requests.
+*/
+func StoreMemberMiddleware(db *gorm.DB, resolve StoreResolver) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			// Resolve the store behind the requested resource
+			// If there is no record, then throw a NotFound error
+			store, err := resolve(db, c)
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return echo.ErrNotFound
+			}
+			if err != nil {
+				return echo.ErrInternalServerError
+			}
+
+			// API keys are restricted to the resources of the issuing store
+			if key, err := CurrentAPIKey(c); err == nil {
+				if key.StoreID != store.ID {
+					return echo.ErrForbidden
+				}
+
+				c.Set("store", store)
+				return next(c)
+			}
+
+			// Get the authenticated user from the context
+			// If there is no user, then throw an unauthorized error
+			user, err := CurrentUser(c)
+			if err != nil {
+				return echo.ErrUnauthorized
+			}
+
+			// If the user has no role in the store, then throw a forbidden error
+			role, ok := StoreRole(db, store, user.ID)
+			if !ok {
+				return echo.ErrForbidden
+			}
+
+			// Set store information and the role in the context
+			c.Set("store", store)
+			c.Set("store_role", role)
+
+			// Call the next handler in the middleware chain
+			return next(c)
+		}
+	}
+}
+
+/*
+Description:
+
+	RequirePermission is used to check the permission of the store role set in the context by StoreMemberMiddleware,
+	or the scopes of the API key the request was authenticated with.
+	If the role or the key is not granted the permission, the request is rejected with a Forbidden error.
+
+Parameters:
+
+	permission (Permission): The permission required by the route.
+
+Returns:
+
+	echo.MiddlewareFunc: An Echo middleware function that checks the permission for incoming requests.
+*/
+func RequirePermission(permission Permission) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			// If the API key is not granted the scope of the permission, then throw a forbidden error
+			if key, err := CurrentAPIKey(c); err == nil {
+				if !APIKeyHasPermission(key, permission) {
+					return echo.ErrForbidden
+				}
+
+				return next(c)
+			}
+
+			// If the role is not granted the permission, then throw a forbidden error
+			role, _ := c.Get("store_role").(string)
+			if !HasPermission(role, permission) {
+				return echo.ErrForbidden
+			}
+
+			// Call the next handler in the middleware chain
+			return next(c)
+		}
+	}
+}
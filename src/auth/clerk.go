@@ -8,38 +8,80 @@ import (
 	"github.com/labstack/echo/v4"
 )
 
+/*
+Description:
+
+	ClerkAuthenticator verifies Clerk session tokens and retrieves the user information from Clerk.
+*/
+type ClerkAuthenticator struct {
+	client clerk.Client
+}
+
+/*
+Description:
+
+	Instantiates a new ClerkAuthenticator with the provided Clerk client.
+
+Parameters:
+
+	client (clerk.Client): The Clerk client used to interact with the Clerk authentication service.
+
+Returns:
+
+	*ClerkAuthenticator: A pointer to the newly created ClerkAuthenticator instance.
+*/
+func NewClerkAuthenticator(client clerk.Client) *ClerkAuthenticator {
+	return &ClerkAuthenticator{
+		client: client,
+	}
+}
+
+/*
+Description:
+
+	Verify the Clerk session token and retrieve the user information from Clerk.
+
+Parameters:
+
+	token (string): The bearer token.
+
+Returns:
+
+	(*clerk.User, error): The authenticated user. Otherwise, any error encountered during the verification.
+*/
+func (a *ClerkAuthenticator) Authenticate(token string) (*clerk.User, error) {
+	// Verify the bearer token
+	sessClaims, err := a.client.VerifyToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	// Retrieve user information from Clerk
+	return a.client.Users().Read(sessClaims.Claims.Subject)
+}
+
 /*
 Description:
 
 	AuthMiddleware is used to authenticate incoming requests by verifying the session claims from the context.
-	It retrieves the session claims from the context, validates them, and retrieves the user information from Clerk.
-	If the session claims are valid and the user information is successfully retrieved, the user information is set in the context,
+	It retrieves the bearer token from the request header and resolves the user with the provided Authenticator.
+	If the token is valid and the user information is successfully retrieved, the user information is set in the context,
 	and the request is passed to the next handler in the middleware chain.
 
 Parameters:
 
-	client (clerk.Client): The Clerk client used to interact with the Clerk authentication service.
+	authenticator (Authenticator): The Authenticator used to verify the bearer token.
 
 Returns:
 
-	echo.,MiddlewareFunc: An Echo middleware function that performs authentication for incoming requests.
+	echo.MiddlewareFunc: An Echo middleware function that performs authentication for incoming requests.
 */
-func AuthMiddleware(client clerk.Client) echo.MiddlewareFunc {
+func AuthMiddleware(authenticator Authenticator) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
-			// Obtain bearer token from request header
-			sessToken := c.Request().Header.Get("Authorization")
-			sessToken = strings.TrimPrefix(sessToken, "Bearer ")
-
-			// Verify the bearer token
+			// Verify the bearer token and retrieve user information
 			// If the verication is unsuccessful, then throw an unauthroized error
-			sessClaims, err := client.VerifyToken(sessToken)
-			if err != nil {
-				return echo.ErrUnauthorized
-			}
-
-			// Retrieve user information from Clerk
-			user, err := client.Users().Read(sessClaims.Claims.Subject)
+			user, err := authenticate(authenticator, c)
 			if err != nil {
 				return echo.ErrUnauthorized
 			}
@@ -52,3 +94,60 @@ func AuthMiddleware(client clerk.Client) echo.MiddlewareFunc {
 		}
 	}
 }
+
+/*
+Description:
+
+	OptionalAuthMiddleware is used on routes that are accessible anonymously.
+	If the request carries a bearer token and the token is valid, the user information is set in the context.
+	Otherwise, the request is passed to the next handler in the middleware chain without a user.
+
+Parameters:
+
+	authenticator (Authenticator): The Authenticator used to verify the bearer token.
+
+Returns:
+
+	echo.MiddlewareFunc: An Echo middleware function that resolves the user for incoming requests when possible.
+*/
+func OptionalAuthMiddleware(authenticator Authenticator) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			// If there is no bearer token, then proceed anonymously
+			if c.Request().Header.Get("Authorization") == "" {
+				return next(c)
+			}
+
+			// Verify the bearer token and retrieve user information
+			// If the verification is successful, then set user information in the context
+			if user, err := authenticate(authenticator, c); err == nil {
+				c.Set("user", user)
+			}
+
+			// Call the next handler in the middleware chain
+			return next(c)
+		}
+	}
+}
+
+/*
+Description:
+
+	Obtain the bearer token of the request and resolve the user with the provided Authenticator.
+
+Parameters:
+
+	authenticator (Authenticator): The Authenticator used to verify the bearer token.
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	(*clerk.User, error): The authenticated user. Otherwise, any error encountered during the verification.
+*/
+func authenticate(authenticator Authenticator, c echo.Context) (*clerk.User, error) {
+	// Obtain bearer token from request header
+	sessToken := c.Request().Header.Get("Authorization")
+	sessToken = strings.TrimPrefix(sessToken, "Bearer ")
+
+	return authenticator.Authenticate(sessToken)
+}
@@ -0,0 +1,156 @@
+package auth
+
+import (
+	"errors"
+
+	"github.com/clerkinc/clerk-sdk-go/clerk"
+	"github.com/golang-jwt/jwt"
+)
+
+// ErrInvalidToken is returned when a bearer token cannot be verified.
+var ErrInvalidToken = errors.New("invalid token")
+
+/*
+Description:
+
+	JWTOptions holds the optional claim checks performed by JWTAuthenticator.
+
+Fields:
+
+	Issuer (string): The expected `iss` claim. Not checked when empty.
+	Audience (string): The expected `aud` claim. Not checked when empty.
+*/
+type JWTOptions struct {
+	Issuer   string
+	Audience string
+}
+
+/*
+Description:
+
+	JWTAuthenticator verifies locally signed JWTs without any network call.
+	It is intended for integration tests and self-hosted deployments running without a Clerk account.
+	The user is built from the `sub`, `email`, `first_name`, `last_name` and `public_metadata` claims.
+*/
+type JWTAuthenticator struct {
+	method jwt.SigningMethod
+	key    interface{}
+	opts   JWTOptions
+}
+
+/*
+Description:
+
+	Instantiates a new JWTAuthenticator verifying HS256 signed tokens with the shared secret.
+
+Parameters:
+
+	secret ([]byte): The shared secret used to sign the tokens.
+	opts (JWTOptions): The optional claim checks.
+
+Returns:
+
+	(*JWTAuthenticator, error): A pointer to the newly created JWTAuthenticator. Otherwise, an error if the secret is empty.
+*/
+func NewHS256Authenticator(secret []byte, opts JWTOptions) (*JWTAuthenticator, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("auth: empty HS256 secret")
+	}
+
+	return &JWTAuthenticator{
+		method: jwt.SigningMethodHS256,
+		key:    secret,
+		opts:   opts,
+	}, nil
+}
+
+/*
+Description:
+
+	Instantiates a new JWTAuthenticator verifying RS256 signed tokens with the PEM encoded public key.
+
+Parameters:
+
+	publicKeyPEM ([]byte): The PEM encoded RSA public key.
+	opts (JWTOptions): The optional claim checks.
+
+Returns:
+
+	(*JWTAuthenticator, error): A pointer to the newly created JWTAuthenticator. Otherwise, any error encountered while parsing the key.
+*/
+func NewRS256Authenticator(publicKeyPEM []byte, opts JWTOptions) (*JWTAuthenticator, error) {
+	key, err := jwt.ParseRSAPublicKeyFromPEM(publicKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	return &JWTAuthenticator{
+		method: jwt.SigningMethodRS256,
+		key:    key,
+		opts:   opts,
+	}, nil
+}
+
+/*
+Description:
+
+	Verify the signature and the claims of the token and build the user from the claims.
+
+Parameters:
+
+	token (string): The bearer token.
+
+Returns:
+
+	(*clerk.User, error): The authenticated user. Otherwise, ErrInvalidToken if the verification fails.
+*/
+func (a *JWTAuthenticator) Authenticate(token string) (*clerk.User, error) {
+	// Only accept the configured signing method to prevent algorithm confusion
+	parser := jwt.Parser{ValidMethods: []string{a.method.Alg()}}
+
+	// Verify the signature and the registered time based claims
+	var claims jwt.MapClaims
+	if _, err := parser.ParseWithClaims(token, &claims, func(*jwt.Token) (interface{}, error) {
+		return a.key, nil
+	}); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	// Verify the issuer and the audience when configured
+	if a.opts.Issuer != "" && !claims.VerifyIssuer(a.opts.Issuer, true) {
+		return nil, ErrInvalidToken
+	}
+	if a.opts.Audience != "" && !claims.VerifyAudience(a.opts.Audience, true) {
+		return nil, ErrInvalidToken
+	}
+
+	// The subject is the user id and is always required
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return nil, ErrInvalidToken
+	}
+
+	// Build the user from the claims
+	user := &clerk.User{
+		ID:             subject,
+		PublicMetadata: claims["public_metadata"],
+	}
+	if email, ok := claims["email"].(string); ok && email != "" {
+		emailID := "primary"
+		user.PrimaryEmailAddressID = &emailID
+		user.EmailAddresses = []clerk.EmailAddress{{ID: emailID, EmailAddress: email}}
+
+		// The email address is only verified when the issuer says so
+		if verified, _ := claims["email_verified"].(bool); verified {
+			user.EmailAddresses[0].Verification = &clerk.Verification{Status: EmailVerified}
+		}
+	}
+	if firstName, ok := claims["first_name"].(string); ok {
+		user.FirstName = &firstName
+	}
+	if lastName, ok := claims["last_name"].(string); ok {
+		user.LastName = &lastName
+	}
+
+	return user, nil
+}
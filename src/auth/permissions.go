@@ -0,0 +1,68 @@
+package auth
+
+import "github.com/haseakito/ec_api/models"
+
+// Permission is an action a store role may perform on the store's resources
+type Permission string
+
+const (
+	PermStoreUpdate    Permission = "store:update"
+	PermStoreDelete    Permission = "store:delete"
+	PermProductsRead   Permission = "products:read"
+	PermProductsWrite  Permission = "products:write"
+	PermProductsDelete Permission = "products:delete"
+	PermOrdersRead     Permission = "orders:read"
+	PermOrdersWrite    Permission = "orders:write"
+	PermRevenueRead    Permission = "revenue:read"
+	PermMembersManage  Permission = "members:manage"
+	PermAPIKeysManage  Permission = "api_keys:manage"
+	PermAuditRead      Permission = "audit:read"
+)
+
+// Permissions granted to each store role
+var rolePermissions = map[string][]Permission{
+	models.RoleOwner: {
+		PermStoreUpdate, PermStoreDelete,
+		PermProductsRead, PermProductsWrite, PermProductsDelete,
+		PermOrdersRead, PermOrdersWrite, PermRevenueRead,
+		PermMembersManage, PermAPIKeysManage, PermAuditRead,
+	},
+	models.RoleManager: {
+		PermStoreUpdate,
+		PermProductsRead, PermProductsWrite, PermProductsDelete,
+		PermOrdersRead, PermOrdersWrite, PermRevenueRead,
+		PermAuditRead,
+	},
+	models.RoleFulfillment: {
+		PermProductsRead,
+		PermOrdersRead, PermOrdersWrite,
+	},
+	models.RoleAnalyst: {
+		PermProductsRead,
+		PermOrdersRead, PermRevenueRead,
+	},
+}
+
+/*
+Description:
+
+	Check whether the store role is granted the permission.
+
+Parameters:
+
+	role (string): The role of the user in the store.
+	permission (Permission): The permission to check.
+
+Returns:
+
+	bool: true if the role is granted the permission, false otherwise.
+*/
+func HasPermission(role string, permission Permission) bool {
+	for _, p := range rolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+
+	return false
+}
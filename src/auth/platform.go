@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"github.com/clerkinc/clerk-sdk-go/clerk"
+	"github.com/labstack/echo/v4"
+)
+
+// PlatformAdminRole is the value of the `role` public metadata granting access to the platform APIs
+const PlatformAdminRole = "platform_admin"
+
+/*
+Description:
+
+	UserDirectory looks up users of the identity provider by their IDs.
+	It is implemented by Authenticators backed by a user database such as ClerkAuthenticator.
+*/
+type UserDirectory interface {
+	GetUsers(userIDs []string) ([]clerk.User, error)
+}
+
+/*
+Description:
+
+	Retrieve the user information from Clerk with the user ids, in a single request.
+
+Parameters:
+
+	userIDs ([]string): The IDs of the users.
+
+Returns:
+
+	([]clerk.User, error): The users found. Otherwise, any error encountered during the retrieval.
+*/
+func (a *ClerkAuthenticator) GetUsers(userIDs []string) ([]clerk.User, error) {
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+
+	// Clerk returns 10 users by default, so that the limit must cover all the ids
+	limit := len(userIDs)
+	return a.client.Users().ListAll(clerk.ListAllUsersParams{UserIDs: userIDs, Limit: &limit})
+}
+
+/*
+Description:
+
+	Check whether the user is a platform operator, i.e. the `role` of its public metadata is platform_admin.
+
+Parameters:
+
+	user (*clerk.User): The user to check.
+
+Returns:
+
+	bool: true if the user is a platform operator, false otherwise.
+*/
+func IsPlatformAdmin(user *clerk.User) bool {
+	metadata, ok := user.PublicMetadata.(map[string]interface{})
+	if !ok {
+		return false
+	}
+
+	role, _ := metadata["role"].(string)
+	return role == PlatformAdminRole
+}
+
+/*
+Description:
+
+	PlatformAdminMiddleware is used to restrict routes to platform operators.
+	It must be installed after AuthMiddleware. If the authenticated user is not a platform operator,
+	the request is rejected with a Forbidden error.
+
+Returns:
+
+	echo.MiddlewareFunc: An Echo middleware function that performs authorization for incoming requests.
+*/
+func PlatformAdminMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			// Get the authenticated user from the context
+			// If there is no user, then throw an unauthorized error
+			user, err := CurrentUser(c)
+			if err != nil {
+				return echo.ErrUnauthorized
+			}
+
+			// If the user is not a platform operator, then throw a forbidden error
+			if !IsPlatformAdmin(user) {
+				return echo.ErrForbidden
+			}
+
+			// Call the next handler in the middleware chain
+			return next(c)
+		}
+	}
+}
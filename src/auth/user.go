@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/clerkinc/clerk-sdk-go/clerk"
+	"github.com/labstack/echo/v4"
+)
+
+// ErrNoUser is returned when no authenticated user is set in the context.
+var ErrNoUser = errors.New("no authenticated user in context")
+
+// EmailVerified is the verification status of the email addresses the user proved to own
+const EmailVerified = "verified"
+
+/*
+Description:
+
+	Get the authenticated Clerk user set in the context by AuthMiddleware.
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	(*clerk.User, error): The authenticated user. Otherwise, ErrNoUser if the request is not authenticated.
+*/
+func CurrentUser(c echo.Context) (*clerk.User, error) {
+	user, ok := c.Get("user").(*clerk.User)
+	if !ok || user == nil {
+		return nil, ErrNoUser
+	}
+
+	return user, nil
+}
+
+/*
+Description:
+
+	Get the actor of the request, i.e. the API key or the authenticated user.
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	(string, string): The type of the actor (api_key or user) and its ID. Empty strings if the request is not authenticated.
+*/
+func CurrentActor(c echo.Context) (string, string) {
+	if key, err := CurrentAPIKey(c); err == nil {
+		return "api_key", key.ID
+	}
+
+	if user, err := CurrentUser(c); err == nil {
+		return "user", user.ID
+	}
+
+	return "", ""
+}
+
+/*
+Description:
+
+	Report whether the email address is one of the verified email addresses of the user, ignoring the case.
+
+Parameters:
+
+	user (*clerk.User): The user.
+	email (string): The email address.
+
+Returns:
+
+	bool: Whether the user proved to own the email address.
+*/
+func HasVerifiedEmail(user *clerk.User, email string) bool {
+	for _, address := range user.EmailAddresses {
+		if strings.EqualFold(address.EmailAddress, email) && address.Verification != nil && address.Verification.Status == EmailVerified {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,398 @@
+package bulk
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/haseakito/ec_api/audit"
+	"github.com/haseakito/ec_api/jobs"
+	"github.com/haseakito/ec_api/models"
+	"github.com/haseakito/ec_api/money"
+	"github.com/haseakito/ec_api/requests"
+	"github.com/haseakito/ec_api/utils"
+)
+
+// JobTypeProductImport is the type of the jobs importing product files
+const JobTypeProductImport = "product_import"
+
+const (
+	// MaxImportRows is the number of rows a product file may contain
+	MaxImportRows = 10000
+
+	// maxRowErrors is the number of row errors kept in the result of an import, the other failed rows are only counted
+	maxRowErrors = 1000
+
+	// maxRowImages is the number of image URLs a row may contain
+	maxRowImages = 10
+)
+
+/*
+Description:
+
+	ImportParams holds the parameters of a product import job.
+
+Fields:
+
+	Format (string): The format of the file, csv or ndjson.
+	DryRun (bool): Validate the rows and report what would be created or updated without writing anything.
+*/
+type ImportParams struct {
+	Format string `json:"format"`
+	DryRun bool   `json:"dry_run"`
+}
+
+/*
+Description:
+
+	ImportResult holds the result of a product import job, updated as the rows are processed.
+
+Fields:
+
+	DryRun (bool): Indicates whether nothing was written.
+	Total (int): The number of rows of the file.
+	Created (int): The number of products created, or which would be created in a dry run.
+	Updated (int): The number of products updated by SKU, or which would be updated in a dry run.
+	Failed (int): The number of invalid rows, which were skipped.
+	Errors ([]RowError): The errors of the invalid rows. Only the first 1000 errors are kept.
+*/
+type ImportResult struct {
+	DryRun  bool       `json:"dry_run"`
+	Total   int        `json:"total"`
+	Created int        `json:"created"`
+	Updated int        `json:"updated"`
+	Failed  int        `json:"failed"`
+	Errors  []RowError `json:"errors"`
+}
+
+/*
+Description:
+
+	RowError is the reason a row of a product file was skipped.
+
+Fields:
+
+	Line (int): The line number of the row in the file.
+	SKU (string): The SKU of the row, if any.
+	Message (string): The validation error.
+*/
+type RowError struct {
+	Line    int    `json:"line"`
+	SKU     string `json:"sku,omitempty"`
+	Message string `json:"message"`
+}
+
+/*
+Description:
+
+	Run a product import job, resuming after the rows already processed. Every row is validated like
+	a product created with AdminStoreHandler.CreateProduct. Rows with the SKU of a product of the store update the product,
+	other rows create a product. Invalid rows are skipped and reported in the result, and never fail the job.
+
+Parameters:
+
+	db (*gorm.DB): A pointer to the GORM database connection.
+	job (*models.Job): The job, with the file as input and ImportParams as parameters.
+
+Returns:
+
+	error: Any error failing the job, such as an unreadable file or a database error.
+*/
+func ImportProducts(db *gorm.DB, job *models.Job) error {
+	var params ImportParams
+	if err := json.Unmarshal(job.Params, &params); err != nil {
+		return err
+	}
+
+	var store models.Store
+	if err := db.Take(&store, "id = ?", job.StoreID).Error; err != nil {
+		return err
+	}
+
+	lines, err := ReadRows(job.Input, params.Format)
+	if err != nil {
+		return err
+	}
+
+	// Resume from the result saved with the progress
+	result := ImportResult{}
+	if len(job.Result) > 0 {
+		if err := json.Unmarshal(job.Result, &result); err != nil {
+			return err
+		}
+	}
+	result.DryRun = params.DryRun
+	result.Total = len(lines)
+
+	imp := importer{db: db, job: job, store: store, dryRun: params.DryRun, created: map[string]bool{}}
+
+	// A dry run writes nothing, so the SKUs of the rows before are remembered to report their later rows as updates
+	if params.DryRun {
+		for _, line := range lines[:job.Progress] {
+			if line.Err == nil && line.Row.SKU != "" {
+				imp.created[line.Row.SKU] = true
+			}
+		}
+	}
+
+	for i := job.Progress; i < len(lines); i++ {
+		if err := imp.importLine(lines[i], i+1, &result); err != nil {
+			return err
+		}
+	}
+
+	// Save the final result, also for files without rows
+	return jobs.Checkpoint(db, job, len(lines), result)
+}
+
+// importer imports the rows of a product file into a store
+type importer struct {
+	db     *gorm.DB
+	job    *models.Job
+	store  models.Store
+	dryRun bool
+
+	// created holds the SKUs created by the previous rows of a dry run
+	created map[string]bool
+}
+
+// importPlan is the product to create or update for a valid row
+type importPlan struct {
+	product  models.Product
+	before   *models.Product
+	category *models.Category
+	images   []string
+}
+
+// importLine imports a row and saves the progress in the same transaction
+func (imp *importer) importLine(line Line, progress int, result *ImportResult) error {
+	plan, message, err := imp.prepare(line)
+	if err != nil {
+		return err
+	}
+
+	// Skip and report the invalid rows
+	if message != "" {
+		return imp.skip(line, message, progress, result)
+	}
+
+	// Upload the new images before the transaction, so that the transaction is not held during the downloads
+	// The lease of the job is renewed before each download, so that no other worker claims the job while the images are uploaded
+	// If any image cannot be uploaded, then the row is skipped
+	var images []models.ProductImage
+	if !imp.dryRun {
+		for _, imageURL := range plan.images {
+			if err := jobs.Renew(imp.db, imp.job); err != nil {
+				return err
+			}
+			uploaded, err := utils.UploadFromURL(imageURL, "products/")
+			if err != nil {
+				return imp.skip(line, fmt.Sprintf("image_urls: %s: %s", imageURL, imageError(err)), progress, result)
+			}
+			images = append(images, models.ProductImage{Url: uploaded})
+		}
+	}
+
+	if plan.before == nil {
+		result.Created++
+	} else {
+		result.Updated++
+	}
+
+	if imp.dryRun {
+		if line.Row.SKU != "" {
+			imp.created[line.Row.SKU] = true
+		}
+		return jobs.Checkpoint(imp.db, imp.job, progress, result)
+	}
+
+	record := func(tx *gorm.DB, entityType, entityID, action string, before, after interface{}) error {
+		return audit.RecordActor(tx, imp.job.ActorType, imp.job.ActorID, imp.job.RequestID, imp.store.ID, entityType, entityID, action, before, after)
+	}
+
+	// Create or update the product, assign it to the category, add the images and record the audit events in a transaction
+	return imp.db.Transaction(func(tx *gorm.DB) error {
+		product := plan.product
+		if plan.before == nil {
+			if err := tx.Omit(clause.Associations).Create(&product).Error; err != nil {
+				return err
+			}
+			if err := record(tx, "product", product.ID, models.AuditCreate, nil, product); err != nil {
+				return err
+			}
+		} else {
+			// Only the imported columns are written, so that the units reserved by concurrent checkouts are kept
+			// The publication is only written when the row gives it, so that files without it keep the products as they are
+			columns := []string{"name", "description", "price"}
+			if line.Row.Published != nil {
+				columns = append(columns, "published")
+			}
+			if err := tx.Model(&product).Select(columns).Updates(&product).Error; err != nil {
+				return err
+			}
+			if err := record(tx, "product", product.ID, models.AuditUpdate, *plan.before, product); err != nil {
+				return err
+			}
+		}
+
+		if plan.category != nil {
+			if err := tx.Model(&product).Omit("Categories.*").Association("Categories").Append(plan.category); err != nil {
+				return err
+			}
+		}
+
+		for _, image := range images {
+			image.ProductID = product.ID
+			if err := tx.Create(&image).Error; err != nil {
+				return err
+			}
+			if err := record(tx, "product_image", image.ID, models.AuditCreate, nil, image); err != nil {
+				return err
+			}
+		}
+
+		return jobs.Checkpoint(tx, imp.job, progress, result)
+	})
+}
+
+// prepare validates a row and plans the product to create or update.
+// The message describes why the row is invalid, and the error is set on database errors.
+func (imp *importer) prepare(line Line) (*importPlan, string, error) {
+	if line.Err != nil {
+		return nil, line.Err.Error(), nil
+	}
+	row := line.Row
+
+	// Convert the decimal price into minor units of the currency of the store
+	var price *int64
+	if row.Price != "" {
+		amount, err := money.Parse(row.Price.String(), imp.store.Currency)
+		if err != nil {
+			return nil, fmt.Sprintf("price: %q is not a valid amount in %s", row.Price, strings.ToUpper(imp.store.Currency)), nil
+		}
+		price = &amount
+	}
+
+	var sku *string
+	if row.SKU != "" {
+		sku = &row.SKU
+	}
+
+	// Validate the row like a product created from the API
+	req := requests.ProductCreateRequest{
+		SKU:         sku,
+		Name:        row.Name,
+		Description: row.Description,
+		Price:       price,
+	}
+	if err := req.Validate(); err != nil {
+		return nil, err.Error(), nil
+	}
+
+	// Resolve the category by slug
+	var category *models.Category
+	if row.Category != "" {
+		var c models.Category
+		err := imp.db.Take(&c, "store_id = ? AND slug = ?", imp.store.ID, row.Category).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Sprintf("category: unknown category %q", row.Category), nil
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		category = &c
+	}
+
+	if len(row.ImageURLs) > maxRowImages {
+		return nil, fmt.Sprintf("image_urls: at most %d images are allowed", maxRowImages), nil
+	}
+	for _, imageURL := range row.ImageURLs {
+		if u, err := url.Parse(imageURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Sprintf("image_urls: %q is not an http or https URL", imageURL), nil
+		}
+	}
+
+	plan := &importPlan{category: category}
+
+	// Get the product of the store with the SKU, if any
+	var existing models.Product
+	found := false
+	if sku != nil {
+		err := imp.db.Preload("ProductImages").Take(&existing, "store_id = ? AND sku = ?", imp.store.ID, *sku).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", err
+		}
+		found = err == nil
+	}
+
+	if !found {
+		plan.product = models.Product{StoreID: imp.store.ID, SKU: sku, Tags: models.StringArray{}}
+
+		// In a dry run, the product created by a previous row is updated instead
+		if imp.dryRun && sku != nil && imp.created[*sku] {
+			plan.before = &models.Product{}
+		}
+	} else {
+		// If the product was force-unpublished by the platform, then it cannot be published
+		if row.Published != nil && *row.Published && existing.LockedAt != nil {
+			return nil, "published: the product was unpublished by the platform and cannot be published", nil
+		}
+
+		before := existing
+		plan.before = &before
+		plan.product = existing
+	}
+
+	plan.product.Name = row.Name
+	if row.Description != nil {
+		plan.product.Description = row.Description
+	}
+	if price != nil {
+		plan.product.Price = price
+	}
+	if row.Published != nil {
+		plan.product.Published = *row.Published
+	}
+
+	// Only the images which the product does not have yet are added
+	existingURLs := map[string]bool{}
+	for _, image := range existing.ProductImages {
+		existingURLs[image.Url] = true
+	}
+	for _, imageURL := range row.ImageURLs {
+		if !existingURLs[imageURL] {
+			existingURLs[imageURL] = true
+			plan.images = append(plan.images, imageURL)
+		}
+	}
+
+	return plan, "", nil
+}
+
+// imageError describes the failure of the upload of an image to the merchant, without the details of the other failures
+func imageError(err error) string {
+	for _, known := range []error{utils.ErrImageURL, utils.ErrImageDownload, utils.ErrImageType, utils.ErrImageSize} {
+		if errors.Is(err, known) {
+			return err.Error()
+		}
+	}
+
+	log.Println("bulk: failed to upload image:", err)
+	return "failed to upload image"
+}
+
+// skip reports an invalid row and saves the progress
+func (imp *importer) skip(line Line, message string, progress int, result *ImportResult) error {
+	result.Failed++
+	if len(result.Errors) < maxRowErrors {
+		result.Errors = append(result.Errors, RowError{Line: line.Number, SKU: line.Row.SKU, Message: message})
+	}
+
+	return jobs.Checkpoint(imp.db, imp.job, progress, result)
+}
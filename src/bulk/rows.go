@@ -0,0 +1,350 @@
+package bulk
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/haseakito/ec_api/models"
+	"github.com/haseakito/ec_api/money"
+)
+
+// Formats of product files
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+// ErrUnsupportedFormat is returned when the format of a product file is neither CSV nor NDJSON.
+var ErrUnsupportedFormat = errors.New("bulk: unsupported format")
+
+// Columns are the columns of product files, in the order of exports. Only the name is required in imports.
+var Columns = []string{"sku", "name", "description", "price", "published", "category", "image_urls"}
+
+// imageURLSeparator separates the image URLs in a CSV cell
+const imageURLSeparator = "|"
+
+/*
+Description:
+
+	Row is a product in a product file. In CSV files, the image URLs are separated by "|".
+
+Fields:
+
+	SKU (string): The stock keeping unit of the product. Products are upserted by SKU when set.
+	Name (string): The name of the product.
+	Description (*string): The description of the product. Nullable.
+	Price (json.Number): The decimal price in the major unit of the currency of the store, e.g. 19.99.
+	Published (*bool): Indicates whether the product is published or not. Nullable. The publication of existing products is kept when null.
+	Category (string): The slug of a category of the store to assign the product to.
+	ImageURLs ([]string): The URLs of the images of the product.
+*/
+type Row struct {
+	SKU         string      `json:"sku,omitempty"`
+	Name        string      `json:"name"`
+	Description *string     `json:"description,omitempty"`
+	Price       json.Number `json:"price,omitempty"`
+	Published   *bool       `json:"published,omitempty"`
+	Category    string      `json:"category,omitempty"`
+	ImageURLs   []string    `json:"image_urls,omitempty"`
+}
+
+/*
+Description:
+
+	Line is a row read from a product file along with its line number, or the error which prevented reading it.
+
+Fields:
+
+	Number (int): The line number of the row in the file, starting at 1.
+	Row (Row): The row.
+	Err (error): The error reading the row. Nullable.
+*/
+type Line struct {
+	Number int
+	Row    Row
+	Err    error
+}
+
+/*
+Description:
+
+	Read the rows of a product file. Malformed rows are returned with their error, so that they are reported with the other invalid rows.
+
+Parameters:
+
+	input ([]byte): The content of the file.
+	format (string): The format of the file, csv or ndjson.
+
+Returns:
+
+	([]Line, error): The rows of the file. Otherwise, ErrUnsupportedFormat or an error in the header of a CSV file.
+*/
+func ReadRows(input []byte, format string) ([]Line, error) {
+	switch format {
+	case FormatCSV:
+		return readCSV(input)
+	case FormatNDJSON:
+		return readNDJSON(input)
+	}
+
+	return nil, ErrUnsupportedFormat
+}
+
+/*
+Description:
+
+	Stream the products of a store as a product file which can be imported back.
+	Products are read in batches so that the whole catalog is never held in memory.
+
+Parameters:
+
+	w (io.Writer): The writer the file is streamed to.
+	db (*gorm.DB): A pointer to the GORM database connection.
+	store (models.Store): The store of the products.
+	format (string): The format of the file, csv or ndjson.
+	flush (func()): Called after each batch to send the rows written so far.
+
+Returns:
+
+	error: ErrUnsupportedFormat, or any error encountered while reading the products or writing the file.
+*/
+func Export(w io.Writer, db *gorm.DB, store models.Store, format string, flush func()) error {
+	var write func(row Row) error
+	var end func() error
+	switch format {
+	case FormatCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(Columns); err != nil {
+			return err
+		}
+		write = func(row Row) error { return writer.Write(row.record()) }
+		end = func() error {
+			writer.Flush()
+			return writer.Error()
+		}
+	case FormatNDJSON:
+		encoder := json.NewEncoder(w)
+		write = func(row Row) error { return encoder.Encode(row) }
+		end = func() error { return nil }
+	default:
+		return ErrUnsupportedFormat
+	}
+
+	var products []models.Product
+	res := db.Preload("Categories", func(db *gorm.DB) *gorm.DB { return db.Order("slug asc") }).
+		Preload("ProductImages", "variant_id IS NULL").
+		Where("store_id = ?", store.ID).
+		FindInBatches(&products, 200, func(tx *gorm.DB, batch int) error {
+			for _, product := range products {
+				if err := write(NewRow(product, store.Currency)); err != nil {
+					return err
+				}
+			}
+			if err := end(); err != nil {
+				return err
+			}
+			flush()
+			return nil
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+
+	if err := end(); err != nil {
+		return err
+	}
+	flush()
+	return nil
+}
+
+/*
+Description:
+
+	Build the row of a product. Only the first category of the product, by slug, is exported.
+
+Parameters:
+
+	product (models.Product): The product with its categories and images.
+	currency (string): The currency of the store.
+
+Returns:
+
+	Row: The row of the product.
+*/
+func NewRow(product models.Product, currency string) Row {
+	published := product.Published
+	row := Row{
+		Name:        product.Name,
+		Description: product.Description,
+		Published:   &published,
+	}
+	if product.SKU != nil {
+		row.SKU = *product.SKU
+	}
+	if product.Price != nil {
+		row.Price = json.Number(money.Format(*product.Price, currency))
+	}
+	if len(product.Categories) > 0 {
+		row.Category = product.Categories[0].Slug
+	}
+	for _, image := range product.ProductImages {
+		row.ImageURLs = append(row.ImageURLs, image.Url)
+	}
+
+	return row
+}
+
+// record converts the row into a CSV record in the order of Columns
+func (r Row) record() []string {
+	description := ""
+	if r.Description != nil {
+		description = *r.Description
+	}
+	published := ""
+	if r.Published != nil {
+		published = strconv.FormatBool(*r.Published)
+	}
+
+	return []string{
+		r.SKU,
+		r.Name,
+		description,
+		r.Price.String(),
+		published,
+		r.Category,
+		strings.Join(r.ImageURLs, imageURLSeparator),
+	}
+}
+
+// readCSV reads the rows of a CSV file whose first record is the header
+func readCSV(input []byte) ([]Line, error) {
+	reader := csv.NewReader(bytes.NewReader(input))
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("bulk: the file is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("bulk: invalid header: %w", err)
+	}
+
+	// Map the columns of the header
+	known := make(map[string]bool, len(Columns))
+	for _, column := range Columns {
+		known[column] = true
+	}
+	indexes := make(map[string]int, len(header))
+	for i, column := range header {
+		column = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(column, "\ufeff")))
+		if !known[column] {
+			return nil, fmt.Errorf("bulk: unknown column %q", column)
+		}
+		if _, ok := indexes[column]; ok {
+			return nil, fmt.Errorf("bulk: duplicate column %q", column)
+		}
+		indexes[column] = i
+	}
+	if _, ok := indexes["name"]; !ok {
+		return nil, errors.New(`bulk: missing column "name"`)
+	}
+
+	var lines []Line
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			lines = append(lines, Line{Number: parseErr.StartLine, Err: parseErr.Err})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		number, _ := reader.FieldPos(0)
+		if len(record) != len(header) {
+			lines = append(lines, Line{Number: number, Err: fmt.Errorf("expected %d fields but found %d", len(header), len(record))})
+			continue
+		}
+
+		row, err := csvRow(record, indexes)
+		lines = append(lines, Line{Number: number, Row: row, Err: err})
+	}
+
+	return lines, nil
+}
+
+// csvRow converts a CSV record into a row
+func csvRow(record []string, indexes map[string]int) (Row, error) {
+	cell := func(column string) string {
+		if i, ok := indexes[column]; ok {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	row := Row{
+		SKU:      cell("sku"),
+		Name:     cell("name"),
+		Price:    json.Number(cell("price")),
+		Category: cell("category"),
+	}
+	if description := cell("description"); description != "" {
+		row.Description = &description
+	}
+	if published := cell("published"); published != "" {
+		value, err := strconv.ParseBool(published)
+		if err != nil {
+			return row, fmt.Errorf("published: expected true or false but found %q", published)
+		}
+		row.Published = &value
+	}
+	for _, url := range strings.Split(cell("image_urls"), imageURLSeparator) {
+		if url = strings.TrimSpace(url); url != "" {
+			row.ImageURLs = append(row.ImageURLs, url)
+		}
+	}
+
+	return row, nil
+}
+
+// readNDJSON reads the rows of a file with one JSON object per line, skipping the blank lines
+func readNDJSON(input []byte) ([]Line, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(input))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(input)+1)
+
+	var lines []Line
+	for number := 1; scanner.Scan(); number++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+
+		var row Row
+		decoder := json.NewDecoder(bytes.NewReader(text))
+		decoder.DisallowUnknownFields()
+		err := decoder.Decode(&row)
+		if err == nil && decoder.More() {
+			err = errors.New("expected a single JSON object")
+		}
+		lines = append(lines, Line{Number: number, Row: row, Err: err})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return lines, nil
+}
@@ -0,0 +1,132 @@
+package catalog
+
+import "gorm.io/gorm"
+
+/*
+Description:
+
+	Facets holds the counts of products per filter value, used to render the filters of a listing.
+	Each facet is counted with all the other filters applied, so that picking a value never hides the alternatives.
+
+Fields:
+
+	Categories ([]CategoryCount): The number of products assigned to each category.
+	Tags ([]TagCount): The number of products with each tag, most frequent first.
+	Price (PriceRange): The range of the prices of the products.
+	Ratings ([]RatingCount): The number of products rated at least 4, 3, 2 and 1 on average.
+	InStock (int64): The number of products with units available.
+*/
+type Facets struct {
+	Categories []CategoryCount `json:"categories"`
+	Tags       []TagCount      `json:"tags"`
+	Price      PriceRange      `json:"price"`
+	Ratings    []RatingCount   `json:"ratings"`
+	InStock    int64           `json:"in_stock"`
+}
+
+type CategoryCount struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Slug  string `json:"slug"`
+	Count int64  `json:"count"`
+}
+
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int64  `json:"count"`
+}
+
+type PriceRange struct {
+	Min *int64 `json:"min"`
+	Max *int64 `json:"max"`
+}
+
+type RatingCount struct {
+	MinRating int   `json:"min_rating"`
+	Count     int64 `json:"count"`
+}
+
+// maxTags is the number of tags returned in the facets
+const maxTags = 50
+
+/*
+Description:
+
+	Count the facets of a product listing.
+
+Parameters:
+
+	base (func() *gorm.DB): Returns a new query on the products of the listing before the filters,
+		e.g. the published products of a store. The query must not be ordered or limited.
+	f (Filter): The filters of the listing.
+
+Returns:
+
+	(Facets, error): The facets. Otherwise, any error encountered during the queries.
+*/
+func ComputeFacets(base func() *gorm.DB, f Filter) (Facets, error) {
+	facets := Facets{
+		Categories: []CategoryCount{},
+		Tags:       []TagCount{},
+		Ratings:    []RatingCount{},
+	}
+
+	// Count the products per category
+	if err := base().Scopes(f.scopeExcept(dimensionCategory)).
+		Joins("JOIN product_categories ON product_categories.product_id = products.id").
+		Joins("JOIN categories ON categories.id = product_categories.category_id").
+		Select("categories.id, categories.name, categories.slug, COUNT(DISTINCT products.id) AS count").
+		Group("categories.id, categories.name, categories.slug, categories.position").
+		Order("categories.position, categories.name").
+		Scan(&facets.Categories).Error; err != nil {
+		return facets, err
+	}
+
+	// Count the products per tag
+	tags := base().Scopes(f.scopeExcept(dimensionTags)).Select("unnest(products.tags) AS tag")
+	if err := base().Session(&gorm.Session{NewDB: true}).
+		Table("(?) AS product_tags", tags).
+		Select("tag, COUNT(*) AS count").
+		Group("tag").
+		Order("count DESC, tag").
+		Limit(maxTags).
+		Scan(&facets.Tags).Error; err != nil {
+		return facets, err
+	}
+
+	// Get the range of the prices
+	if err := base().Scopes(f.scopeExcept(dimensionPrice)).
+		Select("MIN(products.price) AS min, MAX(products.price) AS max").
+		Scan(&facets.Price).Error; err != nil {
+		return facets, err
+	}
+
+	// Count the products per minimum average rating
+	var ratings struct {
+		Rating4 int64
+		Rating3 int64
+		Rating2 int64
+		Rating1 int64
+	}
+	averages := base().Scopes(f.scopeExcept(dimensionRating)).Select(averageRating + " AS rating")
+	if err := base().Session(&gorm.Session{NewDB: true}).
+		Table("(?) AS product_ratings", averages).
+		Select(`COUNT(*) FILTER (WHERE rating >= 4) AS rating4, COUNT(*) FILTER (WHERE rating >= 3) AS rating3,
+			COUNT(*) FILTER (WHERE rating >= 2) AS rating2, COUNT(*) FILTER (WHERE rating >= 1) AS rating1`).
+		Scan(&ratings).Error; err != nil {
+		return facets, err
+	}
+	facets.Ratings = []RatingCount{
+		{MinRating: 4, Count: ratings.Rating4},
+		{MinRating: 3, Count: ratings.Rating3},
+		{MinRating: 2, Count: ratings.Rating2},
+		{MinRating: 1, Count: ratings.Rating1},
+	}
+
+	// Count the products with units available
+	if err := base().Scopes(f.scopeExcept(dimensionStock)).Where(inStock).Count(&facets.InStock).Error; err != nil {
+		return facets, err
+	}
+
+	return facets, nil
+}
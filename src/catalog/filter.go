@@ -0,0 +1,140 @@
+package catalog
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/haseakito/ec_api/models"
+)
+
+// Sort orders of product listings
+const (
+	SortNewest      = "newest"
+	SortPriceAsc    = "price_asc"
+	SortPriceDesc   = "price_desc"
+	SortBestSelling = "best_selling"
+	SortTopRated    = "top_rated"
+	SortRelevance   = "relevance"
+)
+
+// Dimensions of the filters, used to leave a filter out when counting its own facet
+const (
+	dimensionCategory = "category"
+	dimensionTags     = "tags"
+	dimensionPrice    = "price"
+	dimensionStock    = "stock"
+	dimensionRating   = "rating"
+)
+
+// SQL expressions computed per product
+const (
+	// averageRating is the average rating of the reviews of the product, NULL without rated reviews
+	averageRating = "(SELECT AVG(reviews.rating) FROM reviews WHERE reviews.product_id = products.id)"
+
+	// unitsSold is the number of units of the product in paid orders
+	unitsSold = "(SELECT COALESCE(SUM(order_items.quantity), 0) FROM order_items JOIN orders ON orders.id = order_items.order_id WHERE order_items.product_id = products.id AND orders.paid)"
+
+	// inStock holds when the product, or one of its variants for products sold by variant, has units available
+	inStock = `(
+		(NOT EXISTS (SELECT 1 FROM product_variants WHERE product_variants.product_id = products.id)
+			AND (products.stock IS NULL OR products.stock > products.reserved))
+		OR EXISTS (SELECT 1 FROM product_variants WHERE product_variants.product_id = products.id
+			AND (product_variants.stock IS NULL OR product_variants.stock > product_variants.reserved))
+	)`
+)
+
+/*
+Description:
+
+	Filter holds the filters of a product listing. Zero values disable the filters.
+
+Fields:
+
+	CategoryID (string): The ID of a category. Products of its descendant categories are included.
+	Tags ([]string): Tags the products must all have.
+	MinPrice (*int64): The minimum price in minor units. Nullable.
+	MaxPrice (*int64): The maximum price in minor units. Nullable.
+	InStock (bool): Restrict to the products with units available.
+	MinRating (*float64): The minimum average rating. Nullable.
+*/
+type Filter struct {
+	CategoryID string
+	Tags       []string
+	MinPrice   *int64
+	MaxPrice   *int64
+	InStock    bool
+	MinRating  *float64
+}
+
+/*
+Description:
+
+	Scope restricting a query on products to the products matching all the filters.
+
+Parameters:
+
+	db (*gorm.DB): The query to restrict.
+
+Returns:
+
+	*gorm.DB: The restricted query.
+*/
+func (f Filter) Scope(db *gorm.DB) *gorm.DB {
+	return f.scopeExcept("")(db)
+}
+
+// scopeExcept restricts a query on products to the products matching all the filters but the one of the dimension.
+func (f Filter) scopeExcept(dimension string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if f.CategoryID != "" && dimension != dimensionCategory {
+			db = db.Scopes(models.InCategoryTree(f.CategoryID))
+		}
+		if len(f.Tags) > 0 && dimension != dimensionTags {
+			db = db.Where("products.tags @> ?::text[]", models.StringArray(f.Tags))
+		}
+		if f.MinPrice != nil && dimension != dimensionPrice {
+			db = db.Where("products.price >= ?", *f.MinPrice)
+		}
+		if f.MaxPrice != nil && dimension != dimensionPrice {
+			db = db.Where("products.price <= ?", *f.MaxPrice)
+		}
+		if f.InStock && dimension != dimensionStock {
+			db = db.Where(inStock)
+		}
+		if f.MinRating != nil && dimension != dimensionRating {
+			db = db.Where(averageRating+" >= ?", *f.MinRating)
+		}
+		return db
+	}
+}
+
+/*
+Description:
+
+	Scope ordering a query on products. Products are ordered by id last so that the order is stable.
+	Unknown sort orders, and the relevance order which is applied by the search, fall back to the newest products first.
+
+Parameters:
+
+	sort (string): The sort order, one of the Sort constants.
+
+Returns:
+
+	func(*gorm.DB) *gorm.DB: The scope ordering the query.
+*/
+func Sort(sort string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		switch sort {
+		case SortPriceAsc:
+			db = db.Order("products.price ASC NULLS LAST")
+		case SortPriceDesc:
+			db = db.Order("products.price DESC NULLS LAST")
+		case SortBestSelling:
+			db = db.Order(unitsSold + " DESC")
+		case SortTopRated:
+			db = db.Order(averageRating + " DESC NULLS LAST")
+		default:
+			db = db.Order("products.created_at DESC")
+		}
+		return db.Order("products.id")
+	}
+}
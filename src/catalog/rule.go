@@ -0,0 +1,379 @@
+package catalog
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+
+	"github.com/haseakito/ec_api/models"
+	"github.com/haseakito/ec_api/money"
+)
+
+// ErrInvalidRule is returned when a collection rule cannot be parsed.
+var ErrInvalidRule = errors.New("catalog: invalid rule")
+
+// Fields of the conditions of collection rules
+const (
+	RuleFieldTag      = "tag"
+	RuleFieldPrice    = "price"
+	RuleFieldCategory = "category"
+	RuleFieldRating   = "rating"
+	RuleFieldInStock  = "in_stock"
+)
+
+// categorySlugTree is the subquery of the products assigned to the category with the slug in the store of the product,
+// or to any of its descendants. The recursion skips the categories already visited, so that it terminates even if the tree has a cycle.
+const categorySlugTree = `products.id IN (
+	SELECT product_categories.product_id FROM product_categories WHERE product_categories.category_id IN (
+		WITH RECURSIVE tree AS (
+			SELECT id FROM categories WHERE categories.store_id = products.store_id AND categories.slug = ?
+			UNION
+			SELECT categories.id FROM categories JOIN tree ON categories.parent_id = tree.id
+		)
+		SELECT id FROM tree
+	)
+)`
+
+/*
+Description:
+
+	Rule is a parsed collection rule selecting the products of a rule-based collection, e.g. "tag = sale AND price < 20".
+
+	Conditions compare a field with a value, and are combined with AND, OR, NOT and parentheses. AND binds tighter than OR.
+	The fields are:
+
+		tag: =, != a tag of the product.
+		price: =, !=, <, <=, >, >= a decimal amount in the major unit of the currency of the store.
+		category: =, != the slug of a category. Products of its descendant categories are included.
+		rating: =, !=, <, <=, >, >= the average rating of the reviews of the product.
+		in_stock: =, != true or false.
+
+	Values are bare words or quoted with single or double quotes.
+*/
+type Rule struct {
+	root ruleNode
+}
+
+/*
+Description:
+
+	Parse a collection rule.
+
+Parameters:
+
+	rule (string): The rule to parse.
+	currency (string): The currency of the store, used to convert the prices into minor units.
+
+Returns:
+
+	(Rule, error): The parsed rule. Otherwise, an error wrapping ErrInvalidRule.
+*/
+func ParseRule(rule string, currency string) (Rule, error) {
+	tokens, err := tokenizeRule(rule)
+	if err != nil {
+		return Rule{}, err
+	}
+
+	p := &ruleParser{tokens: tokens, currency: currency}
+	root, err := p.or()
+	if err != nil {
+		return Rule{}, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return Rule{}, tok.errorf("unexpected %q", tok.text)
+	}
+
+	return Rule{root: root}, nil
+}
+
+/*
+Description:
+
+	Scope restricting a query on products to the products matching the rule.
+
+Parameters:
+
+	db (*gorm.DB): The query to restrict.
+
+Returns:
+
+	*gorm.DB: The restricted query.
+*/
+func (r Rule) Scope(db *gorm.DB) *gorm.DB {
+	var b strings.Builder
+	var vars []interface{}
+	r.root.write(&b, &vars)
+
+	return db.Where(b.String(), vars...)
+}
+
+// ruleNode is a node of the syntax tree of a rule, written as an SQL condition
+type ruleNode interface {
+	write(b *strings.Builder, vars *[]interface{})
+}
+
+// ruleLogical combines two nodes with AND or OR
+type ruleLogical struct {
+	op          string
+	left, right ruleNode
+}
+
+func (n ruleLogical) write(b *strings.Builder, vars *[]interface{}) {
+	b.WriteString("(")
+	n.left.write(b, vars)
+	b.WriteString(" " + n.op + " ")
+	n.right.write(b, vars)
+	b.WriteString(")")
+}
+
+// ruleNot negates a node
+type ruleNot struct {
+	node ruleNode
+}
+
+func (n ruleNot) write(b *strings.Builder, vars *[]interface{}) {
+	b.WriteString("NOT ")
+	n.node.write(b, vars)
+}
+
+// ruleCondition is a condition compiled into SQL
+type ruleCondition struct {
+	sql  string
+	vars []interface{}
+}
+
+func (n ruleCondition) write(b *strings.Builder, vars *[]interface{}) {
+	b.WriteString("(" + n.sql + ")")
+	*vars = append(*vars, n.vars...)
+}
+
+// Kinds of the tokens of a rule
+const (
+	tokenEOF = iota
+	tokenWord
+	tokenString
+	tokenOperator
+	tokenLParen
+	tokenRParen
+)
+
+type ruleToken struct {
+	kind int
+	text string
+	pos  int
+}
+
+// errorf builds a parse error located at the token
+func (t ruleToken) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s at position %d", ErrInvalidRule, fmt.Sprintf(format, args...), t.pos+1)
+}
+
+// tokenizeRule splits a rule into words, quoted strings, comparison operators and parentheses
+func tokenizeRule(rule string) ([]ruleToken, error) {
+	var tokens []ruleToken
+	runes := []rune(rule)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, ruleToken{kind: tokenLParen, text: "(", pos: i})
+			i++
+		case r == ')':
+			tokens = append(tokens, ruleToken{kind: tokenRParen, text: ")", pos: i})
+			i++
+		case r == '=' || r == '!' || r == '<' || r == '>':
+			op := string(r)
+			if i+1 < len(runes) && runes[i+1] == '=' && r != '=' {
+				op += "="
+			}
+			if op == "!" {
+				return nil, ruleToken{pos: i}.errorf("unexpected %q", op)
+			}
+			tokens = append(tokens, ruleToken{kind: tokenOperator, text: op, pos: i})
+			i += len(op)
+		case r == '\'' || r == '"':
+			end := i + 1
+			for end < len(runes) && runes[end] != r {
+				end++
+			}
+			if end == len(runes) {
+				return nil, ruleToken{pos: i}.errorf("unterminated string")
+			}
+			tokens = append(tokens, ruleToken{kind: tokenString, text: string(runes[i+1 : end]), pos: i})
+			i = end + 1
+		default:
+			start := i
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && !strings.ContainsRune("()=!<>'\"", runes[i]) {
+				i++
+			}
+			tokens = append(tokens, ruleToken{kind: tokenWord, text: string(runes[start:i]), pos: start})
+		}
+	}
+
+	return append(tokens, ruleToken{kind: tokenEOF, text: "end of rule", pos: len(runes)}), nil
+}
+
+// ruleParser is a recursive descent parser of the tokens of a rule
+type ruleParser struct {
+	tokens   []ruleToken
+	pos      int
+	currency string
+}
+
+func (p *ruleParser) peek() ruleToken {
+	return p.tokens[p.pos]
+}
+
+func (p *ruleParser) next() ruleToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// keyword reports whether the next token is the keyword, and consumes it if so
+func (p *ruleParser) keyword(keyword string) bool {
+	if tok := p.peek(); tok.kind == tokenWord && strings.EqualFold(tok.text, keyword) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// or parses: and { OR and }
+func (p *ruleParser) or() (ruleNode, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("OR") {
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		left = ruleLogical{op: "OR", left: left, right: right}
+	}
+	return left, nil
+}
+
+// and parses: unary { AND unary }
+func (p *ruleParser) and() (ruleNode, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("AND") {
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		left = ruleLogical{op: "AND", left: left, right: right}
+	}
+	return left, nil
+}
+
+// unary parses: NOT unary | ( or ) | condition
+func (p *ruleParser) unary() (ruleNode, error) {
+	if p.keyword("NOT") {
+		node, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return ruleNot{node: node}, nil
+	}
+
+	if p.peek().kind == tokenLParen {
+		p.next()
+		node, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if tok := p.next(); tok.kind != tokenRParen {
+			return nil, tok.errorf("expected \")\" but found %q", tok.text)
+		}
+		return node, nil
+	}
+
+	return p.condition()
+}
+
+// condition parses: field operator value
+func (p *ruleParser) condition() (ruleNode, error) {
+	field := p.next()
+	if field.kind != tokenWord {
+		return nil, field.errorf("expected a field but found %q", field.text)
+	}
+	op := p.next()
+	if op.kind != tokenOperator {
+		return nil, op.errorf("expected an operator but found %q", op.text)
+	}
+	value := p.next()
+	if value.kind != tokenWord && value.kind != tokenString {
+		return nil, value.errorf("expected a value but found %q", value.text)
+	}
+
+	equality := op.text == "=" || op.text == "!="
+	sqlOp := op.text
+	if sqlOp == "!=" {
+		sqlOp = "<>"
+	}
+
+	switch strings.ToLower(field.text) {
+	case RuleFieldTag:
+		if !equality {
+			return nil, op.errorf("tag only supports = and !=")
+		}
+		tag := strings.ToLower(strings.TrimSpace(value.text))
+		if tag == "" {
+			return nil, value.errorf("empty tag")
+		}
+		return negateIf(op.text == "!=", ruleCondition{sql: "products.tags @> ?::text[]", vars: []interface{}{models.StringArray{tag}}}), nil
+
+	case RuleFieldPrice:
+		amount, err := money.Parse(value.text, p.currency)
+		if err != nil {
+			return nil, value.errorf("invalid price %q", value.text)
+		}
+		return ruleCondition{sql: "products.price " + sqlOp + " ?", vars: []interface{}{amount}}, nil
+
+	case RuleFieldCategory:
+		if !equality {
+			return nil, op.errorf("category only supports = and !=")
+		}
+		return negateIf(op.text == "!=", ruleCondition{sql: categorySlugTree, vars: []interface{}{strings.ToLower(value.text)}}), nil
+
+	case RuleFieldRating:
+		rating, err := strconv.ParseFloat(value.text, 64)
+		if err != nil || rating < 0 || rating > 5 {
+			return nil, value.errorf("invalid rating %q", value.text)
+		}
+		return ruleCondition{sql: averageRating + " " + sqlOp + " ?", vars: []interface{}{rating}}, nil
+
+	case RuleFieldInStock:
+		if !equality {
+			return nil, op.errorf("in_stock only supports = and !=")
+		}
+		available, err := strconv.ParseBool(value.text)
+		if err != nil {
+			return nil, value.errorf("expected true or false but found %q", value.text)
+		}
+		return negateIf(available == (op.text == "!="), ruleCondition{sql: inStock}), nil
+	}
+
+	return nil, field.errorf("unknown field %q", field.text)
+}
+
+// negateIf negates the node when the condition holds
+func negateIf(negate bool, node ruleNode) ruleNode {
+	if negate {
+		return ruleNot{node: node}
+	}
+	return node
+}
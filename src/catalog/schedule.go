@@ -0,0 +1,115 @@
+package catalog
+
+import (
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/haseakito/ec_api/audit"
+	"github.com/haseakito/ec_api/models"
+)
+
+const (
+	// scheduleBatchSize is the number of products whose schedules are applied in a transaction
+	scheduleBatchSize = 100
+
+	// schedulerActorType and schedulerActorID identify the scheduler as the actor of its audit events
+	schedulerActorType = "system"
+	schedulerActorID   = "scheduler"
+)
+
+/*
+Description:
+
+	Apply the schedules of the products which are due: publish the products whose publish time has passed,
+	and unpublish the products whose unpublish time has passed. Applied schedules are cleared, and the schedules missed
+	while the scheduler was not running are applied in order. Products locked by the platform are never published.
+	Products are locked while they are updated, so that several instances never apply a schedule twice.
+
+Parameters:
+
+	db (*gorm.DB): A pointer to the GORM database connection.
+	now (time.Time): The schedules due at this time are applied.
+
+Returns:
+
+	error: Any error encountered during the queries.
+*/
+func ApplySchedules(db *gorm.DB, now time.Time) error {
+	for {
+		applied := 0
+		if err := db.Transaction(func(tx *gorm.DB) error {
+			var products []models.Product
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+				Where("publish_at <= ? OR unpublish_at <= ?", now, now).
+				Order("id").
+				Limit(scheduleBatchSize).
+				Find(&products).Error; err != nil {
+				return err
+			}
+
+			for _, product := range products {
+				before := product
+				applySchedule(&product, now)
+
+				if err := tx.Model(&product).Select("published", "publish_at", "unpublish_at").Updates(&product).Error; err != nil {
+					return err
+				}
+				if err := audit.RecordActor(tx, schedulerActorType, schedulerActorID, "", product.StoreID, "product", product.ID, models.AuditUpdate, before, product); err != nil {
+					return err
+				}
+			}
+
+			applied = len(products)
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		if applied < scheduleBatchSize {
+			return nil
+		}
+	}
+}
+
+/*
+Description:
+
+	Periodically apply the schedules of the products in the background, starting right away
+	so that the schedules missed while the server was down are applied on startup.
+
+Parameters:
+
+	db (*gorm.DB): A pointer to the GORM database connection.
+	interval (time.Duration): The time between two runs.
+*/
+func StartScheduler(db *gorm.DB, interval time.Duration) {
+	apply := func() {
+		if err := ApplySchedules(db, time.Now()); err != nil {
+			log.Println("catalog: failed to apply product schedules:", err)
+		}
+	}
+
+	go func() {
+		apply()
+		for range time.Tick(interval) {
+			apply()
+		}
+	}()
+}
+
+// applySchedule applies the due schedules of a product, the publication first since it comes before the unpublication
+func applySchedule(product *models.Product, now time.Time) {
+	if product.PublishAt != nil && !product.PublishAt.After(now) {
+		if product.LockedAt == nil {
+			product.Published = true
+		}
+		product.PublishAt = nil
+	}
+	if product.UnpublishAt != nil && !product.UnpublishAt.After(now) {
+		product.Published = false
+		product.UnpublishAt = nil
+	}
+}
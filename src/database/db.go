@@ -1,10 +1,13 @@
 package database
 
 import (
+	"fmt"
 	"log"
 	"os"
+	"strings"
 
 	"github.com/haseakito/ec_api/models"
+	"github.com/haseakito/ec_api/search"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
@@ -23,7 +26,8 @@ func Init() *gorm.DB {
 	dsn := os.Getenv("DB_URL")
 
 	// Try to establish connection to database
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	// The foreign keys are created by migrateForeignKeys instead of the migration, which fails on dangling rows
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{DisableForeignKeyConstraintWhenMigrating: true})
 
 	// If the connection was unsuccessful, then throw an error
 	if err != nil {
@@ -33,14 +37,292 @@ func Init() *gorm.DB {
 	// Add uuid-ossp extension to PostgreSQL
 	db.Exec(`CREATE EXTENSION IF NOT EXISTS "uuid-ossp";`)
 
+	// Convert the prices stored as floats into minor units before the migration changes their type
+	migrateMoneyColumns(db)
+
+	// Scope the SKUs of the variants to their store before the migration indexes them
+	migrateVariantStores(db)
+
 	// Run migration
 	db.AutoMigrate(
 		&models.Store{},
+		&models.StoreMember{},
+		&models.APIKey{},
 		&models.Product{},
 		&models.ProductImage{},
+		&models.ProductOption{},
+		&models.ProductOptionValue{},
+		&models.ProductVariant{},
+		&models.Category{},
+		&models.Collection{},
+		&models.CollectionProduct{},
+		&models.Review{},
+		&models.ProductRevision{},
+		&models.ProductDraft{},
 		&models.Order{},
 		&models.OrderItem{},
+		&models.StockReservation{},
+		&models.ModerationAction{},
+		&models.AuditEvent{},
+		&models.Job{},
+		&models.RateLimitBucket{},
 	)
 
+	// Enforce the references between the tables
+	migrateForeignKeys(db)
+
+	// Index the products for full-text search
+	migrateSearch(db)
+
+	// Backfill the snapshots of the orders placed before they were recorded
+	backfillOrderSnapshots(db)
+
+	// Close the orders placed before their cancellation and fulfillment were recorded
+	closeLegacyOrders(db)
+
 	return db
 }
+
+/*
+Description:
+
+	Backfill the price, currency and name snapshots of order items, and the amounts of orders,
+	for the rows created before the snapshots were recorded at checkout time. The live prices are the best
+	approximation available for those rows. Rows which already have a snapshot are left untouched.
+
+Parameters:
+
+	db (*gorm.DB): A pointer to the GORM database connection.
+*/
+func backfillOrderSnapshots(db *gorm.DB) {
+	db.Exec(`
+		UPDATE order_items
+		SET product_name = products.name,
+			unit_price = COALESCE((SELECT price FROM product_variants WHERE product_variants.id = order_items.variant_id), products.price, 0),
+			currency = (SELECT currency FROM stores WHERE stores.id = products.store_id)
+		FROM products
+		WHERE products.id = order_items.product_id AND order_items.currency IS NULL`)
+
+	db.Exec(`
+		UPDATE orders
+		SET currency = (SELECT currency FROM stores WHERE stores.id = orders.store_id), subtotal = totals.amount, total = totals.amount
+		FROM (SELECT order_id, SUM(unit_price * quantity) AS amount FROM order_items GROUP BY order_id) AS totals
+		WHERE totals.order_id = orders.id AND orders.currency IS NULL`)
+}
+
+/*
+Description:
+
+	Close the orders placed before their cancellation and fulfillment were recorded, which would otherwise stay open forever
+	and prevent the deletion of their store. Those orders are the ones without stock reservations, since every checkout reserves
+	the stock of its items. The unpaid ones are cancelled and the paid ones are marked as fulfilled, as of their last update.
+
+Parameters:
+
+	db (*gorm.DB): A pointer to the GORM database connection.
+*/
+func closeLegacyOrders(db *gorm.DB) {
+	legacy := `canceled_at IS NULL AND fulfilled_at IS NULL AND NOT EXISTS (SELECT 1 FROM stock_reservations WHERE stock_reservations.order_id = orders.id)`
+
+	if err := db.Exec(`UPDATE orders SET canceled_at = updated_at WHERE NOT paid AND ` + legacy).Error; err != nil {
+		log.Println("database: failed to cancel the legacy unpaid orders:", err)
+	}
+	if err := db.Exec(`UPDATE orders SET fulfilled_at = updated_at WHERE paid AND ` + legacy).Error; err != nil {
+		log.Println("database: failed to fulfill the legacy paid orders:", err)
+	}
+}
+
+/*
+Description:
+
+	Copy the store of the products onto their variants, and drop the index which made the SKUs of variants unique across all stores,
+	so that the migration can make them unique per store. Variants which already have their store are left untouched.
+
+Parameters:
+
+	db (*gorm.DB): A pointer to the GORM database connection.
+*/
+func migrateVariantStores(db *gorm.DB) {
+	if !db.Migrator().HasTable(&models.ProductVariant{}) {
+		return
+	}
+
+	if !db.Migrator().HasColumn(&models.ProductVariant{}, "StoreID") {
+		if err := db.Migrator().AddColumn(&models.ProductVariant{}, "StoreID"); err != nil {
+			log.Println("database: failed to add product_variants.store_id:", err)
+			return
+		}
+	}
+
+	db.Exec(`
+		UPDATE product_variants
+		SET store_id = products.store_id
+		FROM products
+		WHERE products.id = product_variants.product_id AND (product_variants.store_id IS NULL OR product_variants.store_id = '')`)
+
+	db.Exec(`DROP INDEX IF EXISTS idx_product_variants_sku`)
+}
+
+/*
+Description:
+
+	Convert the money columns created as floating point numbers in major units into integers in minor units.
+	All prices were in USD when they were stored as floats, so they are multiplied by 100.
+	Columns which are already integers are left untouched.
+
+Parameters:
+
+	db (*gorm.DB): A pointer to the GORM database connection.
+*/
+func migrateMoneyColumns(db *gorm.DB) {
+	columns := map[string][]string{
+		"products":         {"price"},
+		"product_variants": {"price"},
+		"order_items":      {"unit_price"},
+		"orders":           {"subtotal", "total"},
+	}
+
+	for table, names := range columns {
+		types, err := db.Migrator().ColumnTypes(table)
+		if err != nil {
+			continue
+		}
+
+		for _, column := range types {
+			for _, name := range names {
+				if column.Name() != name || !strings.HasPrefix(column.DatabaseTypeName(), "float") {
+					continue
+				}
+
+				sql := fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE bigint USING ROUND(%s * 100)", table, name, name)
+				if err := db.Exec(sql).Error; err != nil {
+					log.Println("database: failed to convert", table+"."+name, "to minor units:", err)
+				}
+			}
+		}
+	}
+}
+
+/*
+Description:
+
+	Add a generated tsvector column over the name and the description of products, weighting the name higher,
+	and a GIN index on it. The column is maintained by Postgres, so it is never written by the application.
+
+Parameters:
+
+	db (*gorm.DB): A pointer to the GORM database connection.
+*/
+func migrateSearch(db *gorm.DB) {
+	db.Exec(fmt.Sprintf(`
+		ALTER TABLE products ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+			setweight(to_tsvector('%[1]s', coalesce(name, '')), 'A') ||
+			setweight(to_tsvector('%[1]s', coalesce(description, '')), 'B')
+		) STORED`, search.Config))
+
+	db.Exec(`CREATE INDEX IF NOT EXISTS idx_products_search_vector ON products USING GIN (search_vector)`)
+}
+
+// foreignKey is a foreign key constraint from a column to the primary key of a table
+type foreignKey struct {
+	table      string
+	column     string
+	references string
+	onDelete   string
+}
+
+/*
+Description:
+
+	The foreign keys of the schema. Rows with assets in storage or with a financial meaning are restricted,
+	so that they are only removed by the purge of the trash. The other dependent rows cascade with their parent.
+	Audit events, moderation actions and jobs have no foreign keys, so that they outlive the entities they refer to.
+*/
+var foreignKeys = []foreignKey{
+	{"store_members", "store_id", "stores", "CASCADE"},
+	{"api_keys", "store_id", "stores", "CASCADE"},
+	{"products", "store_id", "stores", "RESTRICT"},
+	{"product_images", "product_id", "products", "RESTRICT"},
+	{"product_images", "variant_id", "product_variants", "RESTRICT"},
+	{"product_options", "product_id", "products", "CASCADE"},
+	{"product_option_values", "option_id", "product_options", "CASCADE"},
+	{"product_variants", "product_id", "products", "CASCADE"},
+	{"product_variants", "store_id", "stores", "CASCADE"},
+	{"variant_option_values", "product_variant_id", "product_variants", "CASCADE"},
+	{"variant_option_values", "product_option_value_id", "product_option_values", "CASCADE"},
+	{"categories", "store_id", "stores", "CASCADE"},
+	{"categories", "parent_id", "categories", "SET NULL"},
+	{"product_categories", "product_id", "products", "CASCADE"},
+	{"product_categories", "category_id", "categories", "CASCADE"},
+	{"collections", "store_id", "stores", "CASCADE"},
+	{"collection_products", "collection_id", "collections", "CASCADE"},
+	{"collection_products", "product_id", "products", "CASCADE"},
+	{"reviews", "product_id", "products", "CASCADE"},
+	{"product_revisions", "product_id", "products", "CASCADE"},
+	{"product_drafts", "product_id", "products", "CASCADE"},
+	{"orders", "store_id", "stores", "RESTRICT"},
+	{"order_items", "order_id", "orders", "CASCADE"},
+	{"order_items", "product_id", "products", "RESTRICT"},
+	{"order_items", "variant_id", "product_variants", "SET NULL"},
+	{"stock_reservations", "order_id", "orders", "CASCADE"},
+	{"stock_reservations", "product_id", "products", "RESTRICT"},
+	{"stock_reservations", "variant_id", "product_variants", "RESTRICT"},
+}
+
+// deleteActions maps the delete actions of the foreign keys to their codes in the pg_constraint catalog
+var deleteActions = map[string]string{
+	"CASCADE":  "c",
+	"RESTRICT": "r",
+	"SET NULL": "n",
+}
+
+/*
+Description:
+
+	Create the missing foreign key constraints, and recreate the constraints whose delete action changed. The constraints are created
+	without checking the existing rows, so that the rows left dangling by the deletions made before the constraints were enforced
+	do not prevent the migration. The existing rows are then checked, and the constraints which still have dangling rows are logged
+	and checked again on the next start.
+
+Parameters:
+
+	db (*gorm.DB): A pointer to the GORM database connection.
+*/
+func migrateForeignKeys(db *gorm.DB) {
+	for _, fk := range foreignKeys {
+		name := fmt.Sprintf("fk_%s_%s", fk.table, fk.column)
+
+		var constraints []struct {
+			Convalidated bool
+			Confdeltype  string
+		}
+		if err := db.Raw("SELECT convalidated, confdeltype FROM pg_constraint WHERE conname = ?", name).Scan(&constraints).Error; err != nil {
+			log.Println("database: failed to look up", name+":", err)
+			continue
+		}
+
+		// Drop the constraint if its delete action changed, so that it is recreated
+		if len(constraints) > 0 && constraints[0].Confdeltype != deleteActions[fk.onDelete] {
+			if err := db.Exec(fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s", fk.table, name)).Error; err != nil {
+				log.Println("database: failed to drop", name+":", err)
+				continue
+			}
+			constraints = nil
+		}
+		if len(constraints) > 0 && constraints[0].Convalidated {
+			continue
+		}
+
+		if len(constraints) == 0 {
+			sql := fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s (id) ON DELETE %s NOT VALID", fk.table, name, fk.column, fk.references, fk.onDelete)
+			if err := db.Exec(sql).Error; err != nil {
+				log.Println("database: failed to create", name+":", err)
+				continue
+			}
+		}
+
+		if err := db.Exec(fmt.Sprintf("ALTER TABLE %s VALIDATE CONSTRAINT %s", fk.table, name)).Error; err != nil {
+			log.Println("database: rows of", fk.table, "reference missing", fk.references+":", err)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package drafts
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/haseakito/ec_api/audit"
+	"github.com/haseakito/ec_api/auth"
+	"github.com/haseakito/ec_api/models"
+	"github.com/haseakito/ec_api/revisions"
+)
+
+/*
+Description:
+
+	Get the draft of a product, if any.
+
+Parameters:
+
+	db (*gorm.DB): A pointer to the GORM database connection.
+	productID (string): The ID of the product.
+
+Returns:
+
+	(*models.ProductDraft, error): The draft, nil if the product has no draft. Otherwise, any error encountered during the query.
+*/
+func Get(db *gorm.DB, productID string) (*models.ProductDraft, error) {
+	var draft models.ProductDraft
+	err := db.Take(&draft, "product_id = ?", productID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &draft, nil
+}
+
+/*
+Description:
+
+	Apply the staged edits of a draft to a product. The publication of the product is never staged, so it is kept as is.
+
+Parameters:
+
+	draft (models.ProductDraft): The draft.
+	product (*models.Product): The product to update.
+
+Returns:
+
+	error: Any error encountered while decoding the draft.
+*/
+func Apply(draft models.ProductDraft, product *models.Product) error {
+	var snapshot revisions.Snapshot
+	if err := json.Unmarshal(draft.Snapshot, &snapshot); err != nil {
+		return err
+	}
+
+	snapshot.Published = product.Published
+	snapshot.Apply(product)
+	return nil
+}
+
+/*
+Description:
+
+	Stage the edits of a published product in its draft on behalf of the actor of the request, replacing the previous draft.
+	The publication of the product is not staged. If the edits match the live product, then the draft is discarded instead.
+
+Parameters:
+
+	tx (*gorm.DB): The transaction staging the edits.
+	c (echo.Context): Context object containing the HTTP request information.
+	live (models.Product): The live product.
+	edited (models.Product): The product with the staged edits applied.
+
+Returns:
+
+	(*models.ProductDraft, error): The draft, nil if it was discarded. Otherwise, any error encountered while saving the draft.
+*/
+func Stage(tx *gorm.DB, c echo.Context, live, edited models.Product) (*models.ProductDraft, error) {
+	edited.Published = live.Published
+	changes, err := audit.Diff(revisions.NewSnapshot(live), revisions.NewSnapshot(edited))
+	if err != nil {
+		return nil, err
+	}
+	if len(changes) == 0 {
+		return nil, Discard(tx, live.ID)
+	}
+
+	snapshot, err := json.Marshal(revisions.NewSnapshot(edited))
+	if err != nil {
+		return nil, err
+	}
+
+	// Get the actor of the request
+	actorType, actorID := auth.CurrentActor(c)
+
+	draft := models.ProductDraft{
+		ProductID: live.ID,
+		ActorType: actorType,
+		ActorID:   actorID,
+		RequestID: c.Response().Header().Get(echo.HeaderXRequestID),
+		Snapshot:  models.JSON(snapshot),
+	}
+	if err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "product_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"actor_type", "actor_id", "request_id", "snapshot", "updated_at"}),
+	}).Create(&draft).Error; err != nil {
+		return nil, err
+	}
+
+	return &draft, nil
+}
+
+/*
+Description:
+
+	Discard the draft of a product, if any.
+
+Parameters:
+
+	tx (*gorm.DB): A pointer to the GORM database connection.
+	productID (string): The ID of the product.
+
+Returns:
+
+	error: Any error encountered during the deletion.
+*/
+func Discard(tx *gorm.DB, productID string) error {
+	return tx.Where("product_id = ?", productID).Delete(&models.ProductDraft{}).Error
+}
@@ -0,0 +1,122 @@
+package drafts
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PreviewTTL is how long a preview link of a draft stays valid
+const PreviewTTL = 24 * time.Hour
+
+var (
+	// ErrNoPreviewSecret is returned when the PREVIEW_SIGNING_SECRET environment variable is not set.
+	ErrNoPreviewSecret = errors.New("drafts: PREVIEW_SIGNING_SECRET is not set")
+
+	// ErrInvalidPreview is returned when a preview token is malformed, forged, expired or issued for another product.
+	ErrInvalidPreview = errors.New("drafts: invalid preview token")
+
+	// ErrNoPublicURL is returned when the PUBLIC_API_URL environment variable is not set to an http or https URL.
+	ErrNoPublicURL = errors.New("drafts: PUBLIC_API_URL is not an http or https URL")
+)
+
+/*
+Description:
+
+	Sign a preview token granting access to the draft of a product until it expires.
+	Tokens are signed with the PREVIEW_SIGNING_SECRET environment variable, so that changing the secret revokes all the tokens.
+
+Parameters:
+
+	productID (string): The ID of the product.
+	expiresAt (time.Time): The time the token expires.
+
+Returns:
+
+	(string, error): The preview token. Otherwise, ErrNoPreviewSecret.
+*/
+func SignPreview(productID string, expiresAt time.Time) (string, error) {
+	secret := os.Getenv("PREVIEW_SIGNING_SECRET")
+	if secret == "" {
+		return "", ErrNoPreviewSecret
+	}
+
+	expires := strconv.FormatInt(expiresAt.Unix(), 10)
+	return expires + "." + signature(secret, productID, expires), nil
+}
+
+/*
+Description:
+
+	Verify that a preview token grants access to the draft of a product.
+
+Parameters:
+
+	token (string): The preview token.
+	productID (string): The ID of the product.
+	now (time.Time): The current time.
+
+Returns:
+
+	error: ErrInvalidPreview, or ErrNoPreviewSecret. nil if the token is valid.
+*/
+func VerifyPreview(token, productID string, now time.Time) error {
+	secret := os.Getenv("PREVIEW_SIGNING_SECRET")
+	if secret == "" {
+		return ErrNoPreviewSecret
+	}
+
+	expires, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return ErrInvalidPreview
+	}
+
+	if !hmac.Equal([]byte(sig), []byte(signature(secret, productID, expires))) {
+		return ErrInvalidPreview
+	}
+
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || !now.Before(time.Unix(unix, 0)) {
+		return ErrInvalidPreview
+	}
+
+	return nil
+}
+
+/*
+Description:
+
+	Build the preview URL of the draft of a product from the PUBLIC_API_URL environment variable, the base URL of the API
+	as seen by the clients, e.g. "https://api.example.com". The URL never depends on the request, whose scheme and host
+	are rewritten by the reverse proxy and chosen by the client.
+
+Parameters:
+
+	productID (string): The ID of the product.
+	token (string): The preview token.
+
+Returns:
+
+	(string, error): The preview URL. Otherwise, ErrNoPublicURL.
+*/
+func PreviewURL(productID, token string) (string, error) {
+	base, err := url.Parse(os.Getenv("PUBLIC_API_URL"))
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") || base.Host == "" {
+		return "", ErrNoPublicURL
+	}
+
+	return strings.TrimSuffix(base.String(), "/") + "/api/v1/products/" + url.PathEscape(productID) + "?preview=" + url.QueryEscape(token), nil
+}
+
+// signature computes the signature of a preview token of a product
+func signature(secret, productID, expires string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(productID + "." + expires))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,212 @@
+package admin
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+
+	"github.com/haseakito/ec_api/audit"
+	"github.com/haseakito/ec_api/auth"
+	"github.com/haseakito/ec_api/models"
+	"github.com/haseakito/ec_api/pagination"
+	"github.com/haseakito/ec_api/requests"
+	"github.com/haseakito/ec_api/utils"
+)
+
+type AdminAPIKeyHandler struct {
+	db *gorm.DB
+}
+
+/*
+Description:
+
+	Instantiates a new AdminAPIKeyHandler with the provided database connection.
+
+Parameters:
+
+	db (*gorm.DB): A pointer to the GORM database connection.
+
+Returns:
+
+	*AdminAPIKeyHandler: A pointer to the newly created AdminAPIKeyHandler instance.
+*/
+func NewAdminAPIKeyHandler(db *gorm.DB) *AdminAPIKeyHandler {
+	return &AdminAPIKeyHandler{
+		db: db,
+	}
+}
+
+/*
+Description:
+
+	Get the API keys for a specific store with the store id one page at a time, newest first, including revoked ones.
+
+HTTP Method:
+
+	GET `/api/v1/admin/stores/:id/api-keys`
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	An error if any occurred during the execution of the function, nil otherwise.
+*/
+func (h AdminAPIKeyHandler) GetAPIKeys(c echo.Context) error {
+	// Get store id from request
+	storeID := c.Param("id")
+
+	// Get the pagination from request
+	// If the limit or the cursor is invalid, then throw an error
+	p, err := pagination.ParseKeyset(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return nil
+	}
+
+	// Get a page of the API keys of the store
+	var keys []models.APIKey
+	if err := h.db.Where("store_id = ?", storeID).Scopes(p.Newest("api_keys")).Find(&keys).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+
+	return c.JSON(http.StatusOK, pagination.NewKeysetPage(keys, p, func(k models.APIKey) models.Model { return k.Model }))
+}
+
+/*
+Description:
+
+	Create an API key for a specific store with the store id and based on the data provided in the request payload.
+	The key is only returned once and is stored hashed.
+
+HTTP Method:
+
+	POST `/api/v1/admin/stores/:id/api-keys`
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	An error if any occurred during the execution of the function, nil otherwise.
+*/
+func (h AdminAPIKeyHandler) CreateAPIKey(c echo.Context) error {
+	// Get the authenticated user from the context
+	user, err := auth.CurrentUser(c)
+	if err != nil {
+		return echo.ErrUnauthorized
+	}
+
+	// Get store id from request
+	storeID := c.Param("id")
+
+	// Parsing request payload and validate the data
+	// If there is a problem with the request, throw an error
+	var req requests.APIKeyCreateRequest
+	if err := c.Bind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return nil
+	}
+
+	// Validate request data
+	// If there is a problem with the request, throw an error
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return nil
+	}
+
+	// Generate a new API key
+	token, err := utils.GenerateToken(auth.APIKeyPrefix)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+
+	// Instantiate a new API key
+	key := models.APIKey{
+		StoreID:   storeID,
+		Name:      req.Name,
+		Prefix:    token[:len(auth.APIKeyPrefix)+8],
+		KeyHash:   utils.HashToken(token),
+		Scopes:    req.Scopes,
+		CreatedBy: user.ID,
+	}
+
+	// Create a new API key and record the audit event in a transaction
+	// If the creation is unsuccessful, then throw an error
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&key).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, c, storeID, "api_key", key.ID, models.AuditCreate, nil, key)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+
+	res := map[string]interface{}{
+		"api_key": key,
+		"key":     token,
+	}
+
+	return c.JSON(http.StatusCreated, res)
+}
+
+/*
+Description:
+
+	Revoke a specific API key with the key id. Revoked keys are rejected immediately.
+
+HTTP Method:
+
+	DELETE `/api/v1/admin/stores/:id/api-keys/:key_id`
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	An error if any occurred during the execution of the function, nil otherwise.
+*/
+func (h AdminAPIKeyHandler) RevokeAPIKey(c echo.Context) error {
+	// Get store id and key id from request
+	storeID := c.Param("id")
+	keyID := c.Param("key_id")
+
+	// Get an API key with key id and store id
+	// If there is no record, then throw a NotFound error
+	var key models.APIKey
+	if err := h.db.Take(&key, "id = ? AND store_id = ?", keyID, storeID).Error; err != nil {
+		c.JSON(http.StatusNotFound, nil)
+		return nil
+	}
+
+	// Keep a copy of the API key for the audit log
+	before := key
+
+	// Update API key fields
+	if key.RevokedAt == nil {
+		now := time.Now()
+		key.RevokedAt = &now
+	}
+
+	// Update API key with data and record the audit event in a transaction
+	// If the update is unsuccessful, then throw an error
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&key).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, c, storeID, "api_key", key.ID, models.AuditUpdate, before, key)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+
+	return c.JSON(http.StatusOK, key)
+}
@@ -0,0 +1,106 @@
+package admin
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+
+	"github.com/haseakito/ec_api/models"
+	"github.com/haseakito/ec_api/pagination"
+)
+
+type AdminAuditHandler struct {
+	db *gorm.DB
+}
+
+/*
+Description:
+
+	Instantiates a new AdminAuditHandler with the provided database connection.
+
+Parameters:
+
+	db (*gorm.DB): A pointer to the GORM database connection.
+
+Returns:
+
+	*AdminAuditHandler: A pointer to the newly created AdminAuditHandler instance.
+*/
+func NewAdminAuditHandler(db *gorm.DB) *AdminAuditHandler {
+	return &AdminAuditHandler{
+		db: db,
+	}
+}
+
+/*
+Description:
+
+	Get the audit events of a specific store with the store id, newest first.
+	The events can be filtered with the entity_type, entity_id, action, actor_id, from and to (RFC 3339) query parameters,
+	and paginated with the limit and cursor query parameters.
+
+HTTP Method:
+
+	GET `/api/v1/admin/stores/:id/audit-log`
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	An error if any occurred during the execution of the function, nil otherwise.
+*/
+func (h AdminAuditHandler) GetAuditLog(c echo.Context) error {
+	// Get store id from request
+	storeID := c.Param("id")
+
+	query := h.db.Model(&models.AuditEvent{}).Where("store_id = ?", storeID)
+
+	// Apply the optional filters
+	for param, column := range map[string]string{
+		"entity_type": "entity_type",
+		"entity_id":   "entity_id",
+		"action":      "action",
+		"actor_id":    "actor_id",
+	} {
+		if value := c.QueryParam(param); value != "" {
+			query = query.Where(column+" = ?", value)
+		}
+	}
+	if from := c.QueryParam("from"); from != "" {
+		t, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, "from must be an RFC 3339 timestamp")
+			return nil
+		}
+		query = query.Where("created_at >= ?", t)
+	}
+	if to := c.QueryParam("to"); to != "" {
+		t, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, "to must be an RFC 3339 timestamp")
+			return nil
+		}
+		query = query.Where("created_at < ?", t)
+	}
+
+	// Get the pagination from request
+	// If the limit or the cursor is invalid, then throw an error
+	p, err := pagination.ParseKeyset(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return nil
+	}
+
+	// Get the page of events
+	var events []models.AuditEvent
+	if err := query.Scopes(p.Newest("audit_events")).Find(&events).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+
+	return c.JSON(http.StatusOK, pagination.NewKeysetPage(events, p, func(e models.AuditEvent) models.Model { return e.Model }))
+}
@@ -0,0 +1,572 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/haseakito/ec_api/audit"
+	"github.com/haseakito/ec_api/models"
+	"github.com/haseakito/ec_api/requests"
+	"github.com/haseakito/ec_api/utils"
+)
+
+// errCategoryCycle is returned when a category is moved under itself or one of its descendants
+var errCategoryCycle = errors.New("the category cannot be moved under itself or its descendants")
+
+type AdminCategoryHandler struct {
+	db *gorm.DB
+}
+
+/*
+Description:
+
+	Instantiates a new AdminCategoryHandler with the provided database connection.
+
+Parameters:
+
+	db (*gorm.DB): A pointer to the GORM database connection.
+
+Returns:
+
+	*AdminCategoryHandler: A pointer to the newly created AdminCategoryHandler instance.
+*/
+func NewAdminCategoryHandler(db *gorm.DB) *AdminCategoryHandler {
+	return &AdminCategoryHandler{
+		db: db,
+	}
+}
+
+/*
+Description:
+
+	Get the category tree of a specific store with the store id.
+
+HTTP Method:
+
+	GET `/api/v1/admin/stores/:id/categories`
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	An error if any occurred during the execution of the function, nil otherwise.
+*/
+func (h AdminCategoryHandler) GetCategories(c echo.Context) error {
+	// Get store id from request
+	storeID := c.Param("id")
+
+	// Get all categories of the store
+	var categories []models.Category
+	if err := h.db.Where("store_id = ?", storeID).Find(&categories).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+
+	return c.JSON(http.StatusOK, models.BuildCategoryTree(categories))
+}
+
+/*
+Description:
+
+	Create a category for a specific store with the store id and based on the data provided in the request payload.
+	The slug is derived from the name when it is not provided.
+
+HTTP Method:
+
+	POST `/api/v1/admin/stores/:id/categories`
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	An error if any occurred during the execution of the function, nil otherwise.
+*/
+func (h AdminCategoryHandler) CreateCategory(c echo.Context) error {
+	// Get store id from request
+	storeID := c.Param("id")
+
+	// Parsing request payload and validate the data
+	// If there is a problem with the request, throw an error
+	var req requests.CategoryCreateRequest
+	if err := c.Bind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return nil
+	}
+
+	// Validate request data
+	// If there is a problem with the request, throw an error
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return nil
+	}
+
+	// If the parent category is not a category of the store, then throw an error
+	if req.ParentID != nil && !h.categoryExists(storeID, *req.ParentID) {
+		c.JSON(http.StatusBadRequest, "The parent category does not exist")
+		return nil
+	}
+
+	// Derive the slug from the name if not provided
+	slug := req.Slug
+	if slug == "" {
+		slug = utils.Slugify(req.Name)
+	}
+	if slug == "" {
+		c.JSON(http.StatusBadRequest, "A slug is required when the name has no letters or digits")
+		return nil
+	}
+
+	// If the slug is already taken in the store, then throw a Conflict error
+	if h.slugTaken(storeID, slug, "") {
+		c.JSON(http.StatusConflict, "The slug is already taken")
+		return nil
+	}
+
+	// Place the new category after its siblings
+	var count int64
+	h.siblings(storeID, req.ParentID).Count(&count)
+
+	// Instantiate a new category
+	category := models.Category{
+		StoreID:  storeID,
+		ParentID: req.ParentID,
+		Name:     req.Name,
+		Slug:     slug,
+		Position: int(count),
+	}
+
+	// Create a new category and record the audit event in a transaction
+	// If the creation is unsuccessful, then throw an error
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&category).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, c, storeID, "category", category.ID, models.AuditCreate, nil, category)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+
+	return c.JSON(http.StatusCreated, category)
+}
+
+/*
+Description:
+
+	Update a specific category with the category id and based on the data in the request payload.
+	A category can be moved under another category, but not under itself or one of its descendants.
+
+HTTP Method:
+
+	PATCH `/api/v1/admin/stores/:id/categories/:category_id`
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	An error if any occurred during the execution of the function, nil otherwise.
+*/
+func (h AdminCategoryHandler) UpdateCategory(c echo.Context) error {
+	// Get store id and category id from request
+	storeID := c.Param("id")
+	categoryID := c.Param("category_id")
+
+	// Get all categories of the store to check the moves against the tree
+	var categories []models.Category
+	if err := h.db.Where("store_id = ?", storeID).Find(&categories).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+
+	parents := make(map[string]*string, len(categories))
+	var category *models.Category
+	for i := range categories {
+		parents[categories[i].ID] = categories[i].ParentID
+		if categories[i].ID == categoryID {
+			category = &categories[i]
+		}
+	}
+
+	// If there is no record, then throw a NotFound error
+	if category == nil {
+		c.JSON(http.StatusNotFound, nil)
+		return nil
+	}
+
+	// Keep a copy of the category for the audit log
+	before := *category
+
+	// Parsing request payload and validate the data
+	// If there is a problem with the request, throw an error
+	var req requests.CategoryUpdateRequest
+	if err := c.Bind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return nil
+	}
+
+	// Validate request data
+	// If there is a problem with the request, throw an error
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return nil
+	}
+
+	// Update category fields if the fields are not empty
+	if req.Name != "" {
+		category.Name = req.Name
+	}
+	if req.Slug != "" {
+		// If the slug is already taken by another category, then throw a Conflict error
+		if h.slugTaken(storeID, req.Slug, category.ID) {
+			c.JSON(http.StatusConflict, "The slug is already taken")
+			return nil
+		}
+		category.Slug = req.Slug
+	}
+	if req.ParentID != nil {
+		if *req.ParentID == "" {
+			// Move the category to the root of the tree
+			category.ParentID = nil
+		} else {
+			// If the parent is not a category of the store, then throw an error
+			if _, ok := parents[*req.ParentID]; !ok {
+				c.JSON(http.StatusBadRequest, "The parent category does not exist")
+				return nil
+			}
+
+			// If the move would create a cycle, then throw an error
+			if createsCycle(parents, category.ID, *req.ParentID) {
+				c.JSON(http.StatusBadRequest, "A category cannot be moved under itself or its descendants")
+				return nil
+			}
+			category.ParentID = req.ParentID
+		}
+
+		// Place the moved category after its new siblings
+		if !sameParent(before.ParentID, category.ParentID) {
+			var count int64
+			h.siblings(storeID, category.ParentID).Count(&count)
+			category.Position = int(count)
+		}
+	}
+
+	// Update category with data and record the audit event in a transaction
+	// The categories of the store are locked and the move is checked again, so that concurrent moves cannot create a cycle
+	// If the update is unsuccessful, then throw an error
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if category.ParentID != nil && !sameParent(before.ParentID, category.ParentID) {
+			var locked []models.Category
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("store_id = ?", storeID).Order("id").Find(&locked).Error; err != nil {
+				return err
+			}
+
+			parents := make(map[string]*string, len(locked))
+			for _, l := range locked {
+				parents[l.ID] = l.ParentID
+			}
+			if createsCycle(parents, category.ID, *category.ParentID) {
+				return errCategoryCycle
+			}
+		}
+
+		if err := tx.Omit("Children").Save(category).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, c, storeID, "category", category.ID, models.AuditUpdate, before, *category)
+	}); err != nil {
+		if errors.Is(err, errCategoryCycle) {
+			c.JSON(http.StatusBadRequest, "A category cannot be moved under itself or its descendants")
+			return nil
+		}
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+
+	return c.JSON(http.StatusOK, category)
+}
+
+/*
+Description:
+
+	Set the order of sibling categories. The categories must share the parent given in the request payload,
+	and are positioned in the order of their ids.
+
+HTTP Method:
+
+	POST `/api/v1/admin/stores/:id/categories/reorder`
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	An error if any occurred during the execution of the function, nil otherwise.
+*/
+func (h AdminCategoryHandler) ReorderCategories(c echo.Context) error {
+	// Get store id from request
+	storeID := c.Param("id")
+
+	// Parsing request payload and validate the data
+	// If there is a problem with the request, throw an error
+	var req requests.CategoryReorderRequest
+	if err := c.Bind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return nil
+	}
+
+	// Validate request data
+	// If there is a problem with the request, throw an error
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return nil
+	}
+
+	// If any category is not a sibling under the parent, then throw an error
+	var count int64
+	h.siblings(storeID, req.ParentID).Where("id IN ?", req.CategoryIDs).Count(&count)
+	if int(count) != len(req.CategoryIDs) {
+		c.JSON(http.StatusBadRequest, "The categories must be children of the parent category")
+		return nil
+	}
+
+	// Update the positions and record the audit events in a transaction
+	// If the update is unsuccessful, then throw an error
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		for position, id := range req.CategoryIDs {
+			var category models.Category
+			if err := tx.Take(&category, "id = ?", id).Error; err != nil {
+				return err
+			}
+			if category.Position == position {
+				continue
+			}
+
+			before := category
+			category.Position = position
+			if err := tx.Model(&category).Update("position", position).Error; err != nil {
+				return err
+			}
+			if err := audit.Record(tx, c, storeID, "category", category.ID, models.AuditUpdate, before, category); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+
+	return c.JSON(http.StatusOK, "Successfully reordered the categories")
+}
+
+/*
+Description:
+
+	Delete a specific category with the category id. The children of the category are moved to its parent,
+	and the products assigned to the category are unassigned from it.
+
+HTTP Method:
+
+	DELETE `/api/v1/admin/stores/:id/categories/:category_id`
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	An error if any occurred during the execution of the function, nil otherwise.
+*/
+func (h AdminCategoryHandler) DeleteCategory(c echo.Context) error {
+	// Get store id and category id from request
+	storeID := c.Param("id")
+	categoryID := c.Param("category_id")
+
+	// Get a category with category id
+	// If there is no record, then throw a NotFound error
+	var category models.Category
+	if err := h.db.Take(&category, "id = ? AND store_id = ?", categoryID, storeID).Error; err != nil {
+		c.JSON(http.StatusNotFound, nil)
+		return nil
+	}
+
+	// Delete the category and record the audit event in a transaction
+	// If the delete is unsuccessful, then throw an error
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Category{}).Where("parent_id = ?", category.ID).Update("parent_id", category.ParentID).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM product_categories WHERE category_id = ?", category.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&category).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, c, storeID, "category", category.ID, models.AuditDelete, category, nil)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+
+	return c.JSON(http.StatusOK, "Successfully deleted the category")
+}
+
+/*
+Description:
+
+	Replace the categories a specific product with the product id is assigned to.
+	The categories must belong to the store of the product.
+
+HTTP Method:
+
+	PUT `/api/v1/admin/products/:id/categories`
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	An error if any occurred during the execution of the function, nil otherwise.
+*/
+func (h AdminCategoryHandler) SetProductCategories(c echo.Context) error {
+	// Get product id from request
+	productID := c.Param("id")
+
+	// Get a product with its categories
+	// If there is no record, then throw a NotFound error
+	var product models.Product
+	if err := h.db.Preload("Categories").Take(&product, "id = ?", productID).Error; err != nil {
+		c.JSON(http.StatusNotFound, nil)
+		return nil
+	}
+
+	// Parsing request payload and validate the data
+	// If there is a problem with the request, throw an error
+	var req requests.ProductCategoriesRequest
+	if err := c.Bind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return nil
+	}
+
+	// Validate request data
+	// If there is a problem with the request, throw an error
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return nil
+	}
+
+	// Get the categories of the store with the category ids
+	// If any category is missing, then throw an error
+	var categories []models.Category
+	if len(req.CategoryIDs) > 0 {
+		if err := h.db.Where("id IN ? AND store_id = ?", req.CategoryIDs, product.StoreID).Find(&categories).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, err)
+			return nil
+		}
+	}
+	if len(categories) != len(uniqueIDs(req.CategoryIDs)) {
+		c.JSON(http.StatusBadRequest, "The categories do not exist")
+		return nil
+	}
+
+	before := categoryIDs(product.Categories)
+
+	// Replace the categories of the product and record the audit event in a transaction
+	// If the update is unsuccessful, then throw an error
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&product).Omit("Categories.*").Association("Categories").Replace(categories); err != nil {
+			return err
+		}
+		return audit.Record(tx, c, product.StoreID, "product", product.ID, models.AuditUpdate,
+			map[string]interface{}{"category_ids": before},
+			map[string]interface{}{"category_ids": categoryIDs(categories)})
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+
+	return c.JSON(http.StatusOK, categories)
+}
+
+// categoryExists reports whether the category belongs to the store.
+func (h AdminCategoryHandler) categoryExists(storeID string, categoryID string) bool {
+	var count int64
+	h.db.Model(&models.Category{}).Where("id = ? AND store_id = ?", categoryID, storeID).Count(&count)
+	return count > 0
+}
+
+// slugTaken reports whether the slug is used by a category of the store other than the excluded one.
+func (h AdminCategoryHandler) slugTaken(storeID string, slug string, excludeID string) bool {
+	var count int64
+	h.db.Model(&models.Category{}).Where("store_id = ? AND slug = ? AND id <> ?", storeID, slug, excludeID).Count(&count)
+	return count > 0
+}
+
+// siblings scopes a query to the categories of the store under the parent.
+func (h AdminCategoryHandler) siblings(storeID string, parentID *string) *gorm.DB {
+	query := h.db.Model(&models.Category{}).Where("store_id = ?", storeID)
+	if parentID == nil {
+		return query.Where("parent_id IS NULL")
+	}
+
+	return query.Where("parent_id = ?", *parentID)
+}
+
+// createsCycle reports whether moving the category under the parent would create a cycle, walking up the tree from the parent.
+// The walk is bounded by the number of categories, so that it terminates even if the tree already has a cycle.
+func createsCycle(parents map[string]*string, categoryID string, parentID string) bool {
+	steps := 0
+	for id := &parentID; id != nil; id = parents[*id] {
+		if *id == categoryID || steps > len(parents) {
+			return true
+		}
+		steps++
+	}
+
+	return false
+}
+
+// sameParent reports whether both parent ids reference the same category, or both are roots.
+func sameParent(a *string, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return *a == *b
+}
+
+// categoryIDs gets the ids of the categories.
+func categoryIDs(categories []models.Category) []string {
+	ids := make([]string, len(categories))
+	for i, category := range categories {
+		ids[i] = category.ID
+	}
+
+	return ids
+}
+
+// uniqueIDs removes the duplicate ids.
+func uniqueIDs(ids []string) []string {
+	seen := make(map[string]bool, len(ids))
+	var unique []string
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+
+	return unique
+}
@@ -0,0 +1,462 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+
+	"github.com/haseakito/ec_api/audit"
+	"github.com/haseakito/ec_api/catalog"
+	"github.com/haseakito/ec_api/models"
+	"github.com/haseakito/ec_api/pagination"
+	"github.com/haseakito/ec_api/requests"
+	"github.com/haseakito/ec_api/utils"
+)
+
+type AdminCollectionHandler struct {
+	db *gorm.DB
+}
+
+/*
+Description:
+
+	Instantiates a new AdminCollectionHandler with the provided database connection.
+
+Parameters:
+
+	db (*gorm.DB): A pointer to the GORM database connection.
+
+Returns:
+
+	*AdminCollectionHandler: A pointer to the newly created AdminCollectionHandler instance.
+*/
+func NewAdminCollectionHandler(db *gorm.DB) *AdminCollectionHandler {
+	return &AdminCollectionHandler{
+		db: db,
+	}
+}
+
+/*
+Description:
+
+	Get the collections of a specific store with the store id one page at a time, newest first.
+
+HTTP Method:
+
+	GET `/api/v1/admin/stores/:id/collections`
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	An error if any occurred during the execution of the function, nil otherwise.
+*/
+func (h AdminCollectionHandler) GetCollections(c echo.Context) error {
+	// Get store id from request
+	storeID := c.Param("id")
+
+	// Get the pagination from request
+	// If the limit or the cursor is invalid, then throw an error
+	p, err := pagination.ParseKeyset(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return nil
+	}
+
+	// Get a page of the collections of the store
+	var collections []models.Collection
+	if err := h.db.Where("store_id = ?", storeID).Scopes(p.Newest("collections")).Find(&collections).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+
+	return c.JSON(http.StatusOK, pagination.NewKeysetPage(collections, p, func(c models.Collection) models.Model { return c.Model }))
+}
+
+/*
+Description:
+
+	Create a collection for a specific store with the store id and based on the data provided in the request payload.
+	The collection is rule-based when a rule is provided, and manual otherwise. The slug is derived from the name when it is not provided.
+
+HTTP Method:
+
+	POST `/api/v1/admin/stores/:id/collections`
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	An error if any occurred during the execution of the function, nil otherwise.
+*/
+func (h AdminCollectionHandler) CreateCollection(c echo.Context) error {
+	// Get store id from request
+	storeID := c.Param("id")
+
+	// Get a store with store id
+	// If there is no record, then throw a NotFound error
+	var store models.Store
+	if err := h.db.Take(&store, "id = ?", storeID).Error; err != nil {
+		c.JSON(http.StatusNotFound, nil)
+		return nil
+	}
+
+	// Parsing request payload and validate the data
+	// If there is a problem with the request, throw an error
+	var req requests.CollectionCreateRequest
+	if err := c.Bind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return nil
+	}
+
+	// Validate request data
+	// If there is a problem with the request, throw an error
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return nil
+	}
+
+	// Derive the slug from the name if not provided
+	slug := req.Slug
+	if slug == "" {
+		slug = utils.Slugify(req.Name)
+	}
+	if slug == "" {
+		c.JSON(http.StatusBadRequest, "A slug is required when the name has no letters or digits")
+		return nil
+	}
+
+	// If the slug is already taken in the store, then throw a Conflict error
+	if h.slugTaken(storeID, slug, "") {
+		c.JSON(http.StatusConflict, "The slug is already taken")
+		return nil
+	}
+
+	// Instantiate a new collection
+	collection := models.Collection{
+		StoreID:     storeID,
+		Name:        req.Name,
+		Slug:        slug,
+		Description: req.Description,
+		Rule:        normalizeRule(req.Rule),
+		Sort:        req.Sort,
+	}
+
+	// If the rule or the sort order is invalid, then throw an error
+	if err := validateCollection(&collection, store.Currency); err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return nil
+	}
+
+	// Create a new collection and record the audit event in a transaction
+	// If the creation is unsuccessful, then throw an error
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&collection).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, c, storeID, "collection", collection.ID, models.AuditCreate, nil, collection)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+
+	return c.JSON(http.StatusCreated, collection)
+}
+
+/*
+Description:
+
+	Update a specific collection with the collection id and based on the data in the request payload.
+	An empty rule turns the collection into a manual collection.
+
+HTTP Method:
+
+	PATCH `/api/v1/admin/stores/:id/collections/:collection_id`
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	An error if any occurred during the execution of the function, nil otherwise.
+*/
+func (h AdminCollectionHandler) UpdateCollection(c echo.Context) error {
+	// Get store id and collection id from request
+	storeID := c.Param("id")
+	collectionID := c.Param("collection_id")
+
+	// Get a store with store id
+	// If there is no record, then throw a NotFound error
+	var store models.Store
+	if err := h.db.Take(&store, "id = ?", storeID).Error; err != nil {
+		c.JSON(http.StatusNotFound, nil)
+		return nil
+	}
+
+	// Get a collection with collection id
+	// If there is no record, then throw a NotFound error
+	var collection models.Collection
+	if err := h.db.Take(&collection, "id = ? AND store_id = ?", collectionID, storeID).Error; err != nil {
+		c.JSON(http.StatusNotFound, nil)
+		return nil
+	}
+
+	// Keep a copy of the collection for the audit log
+	before := collection
+
+	// Parsing request payload and validate the data
+	// If there is a problem with the request, throw an error
+	var req requests.CollectionUpdateRequest
+	if err := c.Bind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return nil
+	}
+
+	// Validate request data
+	// If there is a problem with the request, throw an error
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return nil
+	}
+
+	// Update collection fields if the fields are not empty
+	if req.Name != "" {
+		collection.Name = req.Name
+	}
+	if req.Slug != "" {
+		// If the slug is already taken by another collection, then throw a Conflict error
+		if h.slugTaken(storeID, req.Slug, collection.ID) {
+			c.JSON(http.StatusConflict, "The slug is already taken")
+			return nil
+		}
+		collection.Slug = req.Slug
+	}
+	if req.Description != nil {
+		collection.Description = req.Description
+	}
+	if req.Rule != nil {
+		rule := normalizeRule(req.Rule)
+
+		// Fall back to the default order of the new kind of collection, unless another order is requested
+		if (rule == nil) != (collection.Rule == nil) {
+			collection.Sort = ""
+		}
+		collection.Rule = rule
+	}
+	if req.Sort != "" {
+		collection.Sort = req.Sort
+	}
+
+	// If the rule or the sort order is invalid, then throw an error
+	if err := validateCollection(&collection, store.Currency); err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return nil
+	}
+
+	// Update collection with data and record the audit event in a transaction
+	// If the update is unsuccessful, then throw an error
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		// The picked products are dropped when the collection becomes rule-based
+		if collection.Rule != nil {
+			if err := tx.Where("collection_id = ?", collection.ID).Delete(&models.CollectionProduct{}).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Save(&collection).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, c, storeID, "collection", collection.ID, models.AuditUpdate, before, collection)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+
+	return c.JSON(http.StatusOK, collection)
+}
+
+/*
+Description:
+
+	Replace the products of a specific manual collection with the collection id. The products are positioned in the order of their ids,
+	and must belong to the store of the collection.
+
+HTTP Method:
+
+	PUT `/api/v1/admin/stores/:id/collections/:collection_id/products`
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	An error if any occurred during the execution of the function, nil otherwise.
+*/
+func (h AdminCollectionHandler) SetCollectionProducts(c echo.Context) error {
+	// Get store id and collection id from request
+	storeID := c.Param("id")
+	collectionID := c.Param("collection_id")
+
+	// Get a collection with collection id
+	// If there is no record, then throw a NotFound error
+	var collection models.Collection
+	if err := h.db.Take(&collection, "id = ? AND store_id = ?", collectionID, storeID).Error; err != nil {
+		c.JSON(http.StatusNotFound, nil)
+		return nil
+	}
+
+	// If the collection is rule-based, then throw a Conflict error
+	if collection.Rule != nil {
+		c.JSON(http.StatusConflict, "The products of rule-based collections are selected by their rule")
+		return nil
+	}
+
+	// Parsing request payload and validate the data
+	// If there is a problem with the request, throw an error
+	var req requests.CollectionProductsRequest
+	if err := c.Bind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return nil
+	}
+
+	// Validate request data
+	// If there is a problem with the request, throw an error
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return nil
+	}
+
+	// If any product is not a product of the store, then throw an error
+	productIDs := uniqueIDs(req.ProductIDs)
+	if len(productIDs) > 0 {
+		var count int64
+		h.db.Model(&models.Product{}).Where("id IN ? AND store_id = ?", productIDs, storeID).Count(&count)
+		if int(count) != len(productIDs) {
+			c.JSON(http.StatusBadRequest, "The products do not exist")
+			return nil
+		}
+	}
+
+	var before []string
+	h.db.Model(&models.CollectionProduct{}).Where("collection_id = ?", collection.ID).Order("position asc").Pluck("product_id", &before)
+
+	// Replace the products of the collection and record the audit event in a transaction
+	// If the update is unsuccessful, then throw an error
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("collection_id = ?", collection.ID).Delete(&models.CollectionProduct{}).Error; err != nil {
+			return err
+		}
+		if len(productIDs) > 0 {
+			items := make([]models.CollectionProduct, len(productIDs))
+			for i, productID := range productIDs {
+				items[i] = models.CollectionProduct{CollectionID: collection.ID, ProductID: productID, Position: i}
+			}
+			if err := tx.Create(&items).Error; err != nil {
+				return err
+			}
+		}
+		return audit.Record(tx, c, storeID, "collection", collection.ID, models.AuditUpdate,
+			map[string]interface{}{"product_ids": before},
+			map[string]interface{}{"product_ids": productIDs})
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+
+	return c.JSON(http.StatusOK, "Successfully updated the products of the collection")
+}
+
+/*
+Description:
+
+	Delete a specific collection with the collection id. The products of the collection are not deleted.
+
+HTTP Method:
+
+	DELETE `/api/v1/admin/stores/:id/collections/:collection_id`
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	An error if any occurred during the execution of the function, nil otherwise.
+*/
+func (h AdminCollectionHandler) DeleteCollection(c echo.Context) error {
+	// Get store id and collection id from request
+	storeID := c.Param("id")
+	collectionID := c.Param("collection_id")
+
+	// Get a collection with collection id
+	// If there is no record, then throw a NotFound error
+	var collection models.Collection
+	if err := h.db.Take(&collection, "id = ? AND store_id = ?", collectionID, storeID).Error; err != nil {
+		c.JSON(http.StatusNotFound, nil)
+		return nil
+	}
+
+	// Delete the collection and record the audit event in a transaction
+	// If the delete is unsuccessful, then throw an error
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("collection_id = ?", collection.ID).Delete(&models.CollectionProduct{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&collection).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, c, storeID, "collection", collection.ID, models.AuditDelete, collection, nil)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+
+	return c.JSON(http.StatusOK, "Successfully deleted the collection")
+}
+
+// slugTaken reports whether the slug is used by a collection of the store other than the excluded one.
+func (h AdminCollectionHandler) slugTaken(storeID string, slug string, excludeID string) bool {
+	var count int64
+	h.db.Model(&models.Collection{}).Where("store_id = ? AND slug = ? AND id <> ?", storeID, slug, excludeID).Count(&count)
+	return count > 0
+}
+
+// normalizeRule trims the rule, dropping empty rules.
+func normalizeRule(rule *string) *string {
+	if rule == nil || strings.TrimSpace(*rule) == "" {
+		return nil
+	}
+
+	trimmed := strings.TrimSpace(*rule)
+	return &trimmed
+}
+
+// validateCollection parses the rule of the collection with the currency of the store and defaults the sort order.
+func validateCollection(collection *models.Collection, currency string) error {
+	if collection.Rule == nil {
+		if collection.Sort == "" {
+			collection.Sort = models.CollectionSortManual
+		}
+		return nil
+	}
+
+	if _, err := catalog.ParseRule(*collection.Rule, currency); err != nil {
+		return err
+	}
+	if collection.Sort == "" {
+		collection.Sort = catalog.SortNewest
+	}
+	if collection.Sort == models.CollectionSortManual {
+		return errors.New("Rule-based collections cannot be sorted manually")
+	}
+
+	return nil
+}
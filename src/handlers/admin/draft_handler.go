@@ -0,0 +1,276 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+
+	"github.com/haseakito/ec_api/audit"
+	"github.com/haseakito/ec_api/drafts"
+	"github.com/haseakito/ec_api/models"
+	"github.com/haseakito/ec_api/revisions"
+)
+
+// errDraftChanged is returned when the draft being published was edited, published or discarded in the meantime
+var errDraftChanged = errors.New("the draft was changed in the meantime")
+
+type AdminDraftHandler struct {
+	db *gorm.DB
+}
+
+/*
+Description:
+
+	Instantiates a new AdminDraftHandler with the provided database connection.
+
+Parameters:
+
+	db (*gorm.DB): A pointer to the GORM database connection.
+
+Returns:
+
+	*AdminDraftHandler: A pointer to the newly created AdminDraftHandler instance.
+*/
+func NewAdminDraftHandler(db *gorm.DB) *AdminDraftHandler {
+	return &AdminDraftHandler{
+		db: db,
+	}
+}
+
+/*
+Description:
+
+	Get the draft of a specific product with the product id, holding the edits staged until they are published.
+
+HTTP Method:
+
+	GET `/api/v1/admin/products/:id/draft`
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	An error if any occurred during the execution of the function, nil otherwise.
+*/
+func (h AdminDraftHandler) GetDraft(c echo.Context) error {
+	// Get product id from request
+	productID := c.Param("id")
+
+	// Get the draft of the product
+	// If there is no record, then throw a NotFound error
+	draft, err := drafts.Get(h.db, productID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+	if draft == nil {
+		c.JSON(http.StatusNotFound, nil)
+		return nil
+	}
+
+	return c.JSON(http.StatusOK, draft)
+}
+
+/*
+Description:
+
+	Publish the draft of a specific product with the product id: the staged edits are applied to the live product at once,
+	saved as a revision of the product, and the draft is discarded.
+
+HTTP Method:
+
+	POST `/api/v1/admin/products/:id/draft/publish`
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	An error if any occurred during the execution of the function, nil otherwise.
+*/
+func (h AdminDraftHandler) PublishDraft(c echo.Context) error {
+	// Get product id from request
+	productID := c.Param("id")
+
+	// Get a product with product id
+	// If there is no record, then throw a NotFound error
+	var product models.Product
+	if err := h.db.Take(&product, "id = ?", productID).Error; err != nil {
+		c.JSON(http.StatusNotFound, nil)
+		return nil
+	}
+
+	// Get the draft of the product
+	// If there is no record, then throw a NotFound error
+	draft, err := drafts.Get(h.db, product.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+	if draft == nil {
+		c.JSON(http.StatusNotFound, nil)
+		return nil
+	}
+
+	// Keep a copy of the product for the audit log
+	before := product
+	if err := drafts.Apply(*draft, &product); err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+
+	// If the SKU was taken by another product of the store in the meantime, then throw a Conflict error
+	if product.SKU != nil && productSKUTaken(h.db, product.StoreID, *product.SKU, product.ID) {
+		c.JSON(http.StatusConflict, "The SKU is already taken")
+		return nil
+	}
+
+	// Discard the draft, update the product with the draft, and record the audit event and the revision in a transaction
+	// The draft is discarded first, so that it is published only once and only as it was read
+	// If the draft was changed in the meantime, then throw a Conflict error
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("id = ? AND updated_at = ?", draft.ID, draft.UpdatedAt).Delete(&models.ProductDraft{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errDraftChanged
+		}
+
+		if err := tx.Model(&product).Select("sku", "name", "description", "price", "tags").Updates(&product).Error; err != nil {
+			return err
+		}
+		if err := audit.Record(tx, c, product.StoreID, "product", product.ID, models.AuditUpdate, before, product); err != nil {
+			return err
+		}
+		return revisions.Record(tx, c, before, product, nil)
+	}); err != nil {
+		if errors.Is(err, errDraftChanged) {
+			c.JSON(http.StatusConflict, "The draft was changed in the meantime")
+			return nil
+		}
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+
+	return c.JSON(http.StatusOK, product)
+}
+
+/*
+Description:
+
+	Discard the draft of a specific product with the product id, leaving the live product unchanged.
+
+HTTP Method:
+
+	DELETE `/api/v1/admin/products/:id/draft`
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	An error if any occurred during the execution of the function, nil otherwise.
+*/
+func (h AdminDraftHandler) DiscardDraft(c echo.Context) error {
+	// Get product id from request
+	productID := c.Param("id")
+
+	// Get a product with product id
+	// If there is no record, then throw a NotFound error
+	var product models.Product
+	if err := h.db.Take(&product, "id = ?", productID).Error; err != nil {
+		c.JSON(http.StatusNotFound, nil)
+		return nil
+	}
+
+	// Get the draft of the product
+	// If there is no record, then throw a NotFound error
+	draft, err := drafts.Get(h.db, product.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+	if draft == nil {
+		c.JSON(http.StatusNotFound, nil)
+		return nil
+	}
+
+	// Discard the draft of the product and record the audit event in a transaction
+	// If the deletion is unsuccessful, then throw an error
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := drafts.Discard(tx, product.ID); err != nil {
+			return err
+		}
+		return audit.Record(tx, c, product.StoreID, "product_draft", draft.ID, models.AuditDelete, draft, nil)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+
+	return c.JSON(http.StatusOK, "Successfully discarded the draft")
+}
+
+/*
+Description:
+
+	Create a signed preview URL of the draft of a specific product with the product id, to share with reviewers who are not members of the store.
+	The URL is built from the public URL of the API, and shows the product with its staged edits until it expires.
+
+HTTP Method:
+
+	POST `/api/v1/admin/products/:id/draft/preview`
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	An error if any occurred during the execution of the function, nil otherwise.
+*/
+func (h AdminDraftHandler) CreatePreview(c echo.Context) error {
+	// Get product id from request
+	productID := c.Param("id")
+
+	// Get the draft of the product
+	// If there is no record, then throw a NotFound error
+	draft, err := drafts.Get(h.db, productID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+	if draft == nil {
+		c.JSON(http.StatusNotFound, nil)
+		return nil
+	}
+
+	// Sign the preview token and build the preview URL from the public URL of the API
+	// If previews are not configured, then throw a ServiceUnavailable error
+	expiresAt := time.Now().Add(drafts.PreviewTTL)
+	token, err := drafts.SignPreview(productID, expiresAt)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, "Previews are not configured")
+		return nil
+	}
+	previewURL, err := drafts.PreviewURL(productID, token)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, "Previews are not configured")
+		return nil
+	}
+
+	res := map[string]interface{}{
+		"url":        previewURL,
+		"token":      token,
+		"expires_at": expiresAt,
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,214 @@
+package admin
+
+import (
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+
+	"github.com/haseakito/ec_api/auth"
+	"github.com/haseakito/ec_api/bulk"
+	"github.com/haseakito/ec_api/jobs"
+	"github.com/haseakito/ec_api/models"
+)
+
+// maxImportSize is the size limit of the product files, 10MB
+const maxImportSize = 10 * 1024 * 1024
+
+// Content types of the product file formats
+var importFormats = map[string]string{
+	"text/csv":             bulk.FormatCSV,
+	"application/x-ndjson": bulk.FormatNDJSON,
+	"application/ndjson":   bulk.FormatNDJSON,
+	"application/jsonl":    bulk.FormatNDJSON,
+}
+
+type AdminImportHandler struct {
+	db *gorm.DB
+}
+
+/*
+Description:
+
+	Instantiates a new AdminImportHandler with the provided database connection.
+
+Parameters:
+
+	db (*gorm.DB): A pointer to the GORM database connection.
+
+Returns:
+
+	*AdminImportHandler: A pointer to the newly created AdminImportHandler instance.
+*/
+func NewAdminImportHandler(db *gorm.DB) *AdminImportHandler {
+	return &AdminImportHandler{
+		db: db,
+	}
+}
+
+/*
+Description:
+
+	Import products into a specific store with the store id from a CSV or NDJSON file sent as the request body.
+	The format is given by the `format` query parameter or the Content-Type header. The file is processed by a background job,
+	which is returned to be polled. With the `dry_run` query parameter, the rows are validated without writing anything.
+
+HTTP Method:
+
+	POST `/api/v1/admin/stores/:id/products/import`
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	An error if any occurred during the execution of the function, nil otherwise.
+*/
+func (h AdminImportHandler) ImportProducts(c echo.Context) error {
+	// Get store id from request
+	storeID := c.Param("id")
+
+	// Get a store with store id
+	// If there is no record, then throw a NotFound error
+	var store models.Store
+	if err := h.db.Take(&store, "id = ?", storeID).Error; err != nil {
+		c.JSON(http.StatusNotFound, nil)
+		return nil
+	}
+
+	// Get the format of the file from request
+	// If the format is not supported, then throw an error
+	format := c.QueryParam("format")
+	if format == "" {
+		contentType, _, _ := mime.ParseMediaType(c.Request().Header.Get(echo.HeaderContentType))
+		format = importFormats[contentType]
+	}
+	if format != bulk.FormatCSV && format != bulk.FormatNDJSON {
+		c.JSON(http.StatusUnsupportedMediaType, "The file must be CSV or NDJSON")
+		return nil
+	}
+
+	// Get the dry run mode from request
+	dryRun := false
+	if s := c.QueryParam("dry_run"); s != "" {
+		value, err := strconv.ParseBool(s)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, "dry_run must be true or false")
+			return nil
+		}
+		dryRun = value
+	}
+
+	// Read the file from request
+	// If the file is too large, then throw an error
+	input, err := io.ReadAll(io.LimitReader(c.Request().Body, maxImportSize+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return nil
+	}
+	if len(input) > maxImportSize {
+		c.JSON(http.StatusRequestEntityTooLarge, "The file exceeds the limit of 10MB")
+		return nil
+	}
+
+	// Check the header and the number of rows now, the rows themselves are validated by the job
+	// If the file cannot be read, then throw an error
+	lines, err := bulk.ReadRows(input, format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return nil
+	}
+	if len(lines) > bulk.MaxImportRows {
+		c.JSON(http.StatusBadRequest, "The file exceeds the limit of 10000 rows")
+		return nil
+	}
+
+	params, err := json.Marshal(bulk.ImportParams{Format: format, DryRun: dryRun})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+
+	// Instantiate a new import job on behalf of the actor of the request
+	actorType, actorID := auth.CurrentActor(c)
+	job := models.Job{
+		StoreID:   store.ID,
+		Type:      bulk.JobTypeProductImport,
+		ActorType: actorType,
+		ActorID:   actorID,
+		RequestID: c.Response().Header().Get(echo.HeaderXRequestID),
+		Params:    models.JSON(params),
+		Input:     input,
+	}
+
+	// Enqueue the job
+	// If the creation is unsuccessful, then throw an error
+	if err := jobs.Enqueue(h.db, &job); err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+
+	return c.JSON(http.StatusAccepted, job)
+}
+
+/*
+Description:
+
+	Export the products of a specific store with the store id as a CSV or NDJSON file which can be imported back.
+	The format is given by the `format` query parameter, csv by default. The file is streamed as the products are read.
+
+HTTP Method:
+
+	GET `/api/v1/admin/stores/:id/products/export`
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	An error if any occurred during the execution of the function, nil otherwise.
+*/
+func (h AdminImportHandler) ExportProducts(c echo.Context) error {
+	// Get store id from request
+	storeID := c.Param("id")
+
+	// Get a store with store id
+	// If there is no record, then throw a NotFound error
+	var store models.Store
+	if err := h.db.Take(&store, "id = ?", storeID).Error; err != nil {
+		c.JSON(http.StatusNotFound, nil)
+		return nil
+	}
+
+	// Get the format of the file from request
+	// If the format is not supported, then throw an error
+	format := c.QueryParam("format")
+	if format == "" {
+		format = bulk.FormatCSV
+	}
+
+	contentType := "text/csv; charset=utf-8"
+	switch format {
+	case bulk.FormatCSV:
+	case bulk.FormatNDJSON:
+		contentType = "application/x-ndjson"
+	default:
+		c.JSON(http.StatusBadRequest, "format must be csv or ndjson")
+		return nil
+	}
+
+	// Stream the file
+	// Once the streaming has started, errors can no longer change the response status
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, contentType)
+	res.Header().Set(echo.HeaderContentDisposition, `attachment; filename="products.`+format+`"`)
+	res.WriteHeader(http.StatusOK)
+
+	return bulk.Export(res, h.db, store, format, res.Flush)
+}
@@ -0,0 +1,106 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+
+	"github.com/haseakito/ec_api/models"
+	"github.com/haseakito/ec_api/pagination"
+)
+
+type AdminJobHandler struct {
+	db *gorm.DB
+}
+
+/*
+Description:
+
+	Instantiates a new AdminJobHandler with the provided database connection.
+
+Parameters:
+
+	db (*gorm.DB): A pointer to the GORM database connection.
+
+Returns:
+
+	*AdminJobHandler: A pointer to the newly created AdminJobHandler instance.
+*/
+func NewAdminJobHandler(db *gorm.DB) *AdminJobHandler {
+	return &AdminJobHandler{
+		db: db,
+	}
+}
+
+/*
+Description:
+
+	Get the background jobs of a specific store with the store id one page at a time, newest first.
+
+HTTP Method:
+
+	GET `/api/v1/admin/stores/:id/jobs`
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	An error if any occurred during the execution of the function, nil otherwise.
+*/
+func (h AdminJobHandler) GetJobs(c echo.Context) error {
+	// Get store id from request
+	storeID := c.Param("id")
+
+	// Get the pagination from request
+	// If the limit or the cursor is invalid, then throw an error
+	p, err := pagination.ParseKeyset(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return nil
+	}
+
+	// Get a page of the jobs of the store, without their input
+	var jobs []models.Job
+	if err := h.db.Omit("input").Where("store_id = ?", storeID).Scopes(p.Newest("jobs")).Find(&jobs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+
+	return c.JSON(http.StatusOK, pagination.NewKeysetPage(jobs, p, func(j models.Job) models.Model { return j.Model }))
+}
+
+/*
+Description:
+
+	Get a specific background job with the job id, including its progress and result so far.
+
+HTTP Method:
+
+	GET `/api/v1/admin/stores/:id/jobs/:job_id`
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	An error if any occurred during the execution of the function, nil otherwise.
+*/
+func (h AdminJobHandler) GetJob(c echo.Context) error {
+	// Get store id and job id from request
+	storeID := c.Param("id")
+	jobID := c.Param("job_id")
+
+	// Get a job with job id, without its input
+	// If there is no record, then throw a NotFound error
+	var job models.Job
+	if err := h.db.Omit("input").Take(&job, "id = ? AND store_id = ?", jobID, storeID).Error; err != nil {
+		c.JSON(http.StatusNotFound, nil)
+		return nil
+	}
+
+	return c.JSON(http.StatusOK, job)
+}
@@ -0,0 +1,360 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+
+	"github.com/haseakito/ec_api/audit"
+	"github.com/haseakito/ec_api/auth"
+	"github.com/haseakito/ec_api/models"
+	"github.com/haseakito/ec_api/pagination"
+	"github.com/haseakito/ec_api/requests"
+	"github.com/haseakito/ec_api/utils"
+)
+
+type AdminMemberHandler struct {
+	db *gorm.DB
+}
+
+/*
+Description:
+
+	Instantiates a new AdminMemberHandler with the provided database connection.
+
+Parameters:
+
+	db (*gorm.DB): A pointer to the GORM database connection.
+
+Returns:
+
+	*AdminMemberHandler: A pointer to the newly created AdminMemberHandler instance.
+*/
+func NewAdminMemberHandler(db *gorm.DB) *AdminMemberHandler {
+	return &AdminMemberHandler{
+		db: db,
+	}
+}
+
+/*
+Description:
+
+	Get the members and pending invitations for a specific store with the store id one page at a time, oldest first.
+
+HTTP Method:
+
+	GET `/api/v1/admin/stores/:id/members`
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	An error if any occurred during the execution of the function, nil otherwise.
+*/
+func (h AdminMemberHandler) GetMembers(c echo.Context) error {
+	// Get store id from request
+	storeID := c.Param("id")
+
+	// Get the pagination from request
+	// If the limit or the cursor is invalid, then throw an error
+	p, err := pagination.ParseKeyset(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return nil
+	}
+
+	// Get a page of the members of the store
+	var members []models.StoreMember
+	if err := h.db.Where("store_id = ?", storeID).Scopes(p.Oldest("store_members")).Find(&members).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+
+	return c.JSON(http.StatusOK, pagination.NewKeysetPage(members, p, func(m models.StoreMember) models.Model { return m.Model }))
+}
+
+/*
+Description:
+
+	Invite a user to a specific store with the store id and based on the data provided in the request payload.
+	The invitation token is only returned once and must be passed on to the invitee.
+
+HTTP Method:
+
+	POST `/api/v1/admin/stores/:id/members`
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	An error if any occurred during the execution of the function, nil otherwise.
+*/
+func (h AdminMemberHandler) InviteMember(c echo.Context) error {
+	// Get the authenticated user from the context
+	user, err := auth.CurrentUser(c)
+	if err != nil {
+		return echo.ErrUnauthorized
+	}
+
+	// Get store id from request
+	storeID := c.Param("id")
+
+	// Parsing request payload and validate the data
+	// If there is a problem with the request, throw an error
+	var req requests.MemberInviteRequest
+	if err := c.Bind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return nil
+	}
+
+	// Validate request data
+	// If there is a problem with the request, throw an error
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return nil
+	}
+
+	// If the email is already invited to the store, then throw a Conflict error
+	email := strings.ToLower(req.Email)
+	var count int64
+	h.db.Model(&models.StoreMember{}).Where("store_id = ? AND email = ?", storeID, email).Count(&count)
+	if count > 0 {
+		c.JSON(http.StatusConflict, "The email is already invited to the store")
+		return nil
+	}
+
+	// Generate a new invitation token
+	token, err := utils.GenerateToken("inv_")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+
+	// Instantiate a new member
+	member := models.StoreMember{
+		StoreID:         storeID,
+		Email:           email,
+		Role:            req.Role,
+		InviteTokenHash: utils.HashToken(token),
+		InvitedBy:       user.ID,
+	}
+
+	// Create a new member and record the audit event in a transaction
+	// If the creation is unsuccessful, then throw an error
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&member).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, c, storeID, "store_member", member.ID, models.AuditCreate, nil, member)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+
+	res := map[string]interface{}{
+		"member": member,
+		"token":  token,
+	}
+
+	return c.JSON(http.StatusCreated, res)
+}
+
+/*
+Description:
+
+	Accept an invitation to a specific store with the store id and the invitation token provided in the request payload.
+	The invitation must have been sent to one of the verified email addresses of the authenticated user.
+
+HTTP Method:
+
+	POST `/api/v1/admin/stores/:id/members/accept`
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	An error if any occurred during the execution of the function, nil otherwise.
+*/
+func (h AdminMemberHandler) AcceptInvitation(c echo.Context) error {
+	// Get the authenticated user from the context
+	user, err := auth.CurrentUser(c)
+	if err != nil {
+		return echo.ErrUnauthorized
+	}
+
+	// Get store id from request
+	storeID := c.Param("id")
+
+	// Parsing request payload and validate the data
+	// If there is a problem with the request, throw an error
+	var req requests.MemberAcceptRequest
+	if err := c.Bind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return nil
+	}
+
+	// Validate request data
+	// If there is a problem with the request, throw an error
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return nil
+	}
+
+	// Get a pending invitation with store id and the token
+	// If there is no record, then throw a NotFound error
+	var member models.StoreMember
+	res := h.db.Take(&member, "store_id = ? AND invite_token_hash = ? AND accepted_at IS NULL", storeID, utils.HashToken(req.Token))
+	if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, nil)
+		return nil
+	}
+
+	// If the invitation was sent to another email address, or to an address the user has not verified, then throw a Forbidden error
+	if !auth.HasVerifiedEmail(user, member.Email) {
+		c.JSON(http.StatusForbidden, "The invitation was sent to another email address")
+		return nil
+	}
+
+	// Keep a copy of the member for the audit log
+	before := member
+
+	// Update member fields
+	now := time.Now()
+	member.UserID = &user.ID
+	member.AcceptedAt = &now
+	member.InviteTokenHash = ""
+
+	// Update member with data and record the audit event in a transaction
+	// If the update is unsuccessful, then throw an error
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&member).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, c, storeID, "store_member", member.ID, models.AuditUpdate, before, member)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+
+	return c.JSON(http.StatusOK, member)
+}
+
+/*
+Description:
+
+	Update the role of a specific member with the member id.
+
+HTTP Method:
+
+	PATCH `/api/v1/admin/stores/:id/members/:member_id`
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	An error if any occurred during the execution of the function, nil otherwise.
+*/
+func (h AdminMemberHandler) UpdateMember(c echo.Context) error {
+	// Get store id and member id from request
+	storeID := c.Param("id")
+	memberID := c.Param("member_id")
+
+	// Get a member with member id and store id
+	// If there is no record, then throw a NotFound error
+	var member models.StoreMember
+	if err := h.db.Take(&member, "id = ? AND store_id = ?", memberID, storeID).Error; err != nil {
+		c.JSON(http.StatusNotFound, nil)
+		return nil
+	}
+
+	// Parsing request payload and validate the data
+	// If there is a problem with the request, throw an error
+	var req requests.MemberUpdateRequest
+	if err := c.Bind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return nil
+	}
+
+	// Validate request data
+	// If there is a problem with the request, throw an error
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return nil
+	}
+
+	// Keep a copy of the member for the audit log
+	before := member
+
+	// Update member fields
+	member.Role = req.Role
+
+	// Update member with data and record the audit event in a transaction
+	// If the update is unsuccessful, then throw an error
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&member).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, c, storeID, "store_member", member.ID, models.AuditUpdate, before, member)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+
+	return c.JSON(http.StatusOK, member)
+}
+
+/*
+Description:
+
+	Revoke a specific member or pending invitation with the member id.
+
+HTTP Method:
+
+	DELETE `/api/v1/admin/stores/:id/members/:member_id`
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	An error if any occurred during the execution of the function, nil otherwise.
+*/
+func (h AdminMemberHandler) RevokeMember(c echo.Context) error {
+	// Get store id and member id from request
+	storeID := c.Param("id")
+	memberID := c.Param("member_id")
+
+	// Get a member with member id and store id
+	// If there is no record, then throw a NotFound error
+	var member models.StoreMember
+	if err := h.db.Take(&member, "id = ? AND store_id = ?", memberID, storeID).Error; err != nil {
+		c.JSON(http.StatusNotFound, nil)
+		return nil
+	}
+
+	// Delete the member record and record the audit event in a transaction
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&member).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, c, storeID, "store_member", member.ID, models.AuditDelete, member, nil)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+
+	return c.JSON(http.StatusOK, "Successfully revoked the member")
+}
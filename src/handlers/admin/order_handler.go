@@ -0,0 +1,95 @@
+package admin
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+
+	"github.com/haseakito/ec_api/audit"
+	"github.com/haseakito/ec_api/models"
+)
+
+type AdminOrderHandler struct {
+	db *gorm.DB
+}
+
+/*
+Description:
+
+	Instantiates a new AdminOrderHandler with the provided database connection.
+
+Parameters:
+
+	db (*gorm.DB): A pointer to the GORM database connection.
+
+Returns:
+
+	*AdminOrderHandler: A pointer to the newly created AdminOrderHandler instance.
+*/
+func NewAdminOrderHandler(db *gorm.DB) *AdminOrderHandler {
+	return &AdminOrderHandler{
+		db: db,
+	}
+}
+
+/*
+Description:
+
+	Mark a specific paid order of a store with the store id and the order id as fulfilled.
+
+HTTP Method:
+
+	POST `/api/v1/admin/stores/:id/orders/:order_id/fulfill`
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	An error if any occurred during the execution of the function, nil otherwise.
+*/
+func (h AdminOrderHandler) FulfillOrder(c echo.Context) error {
+	// Get store id and order id from request
+	storeID := c.Param("id")
+	orderID := c.Param("order_id")
+
+	// Get an order of the store with order id
+	// If there is no record, then throw a NotFound error
+	var order models.Order
+	if err := h.db.Take(&order, "id = ? AND store_id = ?", orderID, storeID).Error; err != nil {
+		c.JSON(http.StatusNotFound, nil)
+		return nil
+	}
+
+	// If the order is not paid or already fulfilled, then throw a Conflict error
+	if !order.Paid || order.CanceledAt != nil {
+		c.JSON(http.StatusConflict, "Only paid orders can be fulfilled")
+		return nil
+	}
+	if order.FulfilledAt != nil {
+		c.JSON(http.StatusConflict, "The order is already fulfilled")
+		return nil
+	}
+
+	// Keep a copy of the order for the audit log
+	before := order
+	now := time.Now()
+	order.FulfilledAt = &now
+
+	// Update the order and record the audit event in a transaction
+	// If the update is unsuccessful, then throw an error
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&order).Update("fulfilled_at", now).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, c, storeID, "order", order.ID, models.AuditUpdate, before, order)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+
+	return c.JSON(http.StatusOK, order)
+}
@@ -3,8 +3,11 @@ package admin
 import (
 	"net/http"
 
+	"github.com/haseakito/ec_api/audit"
+	"github.com/haseakito/ec_api/drafts"
 	"github.com/haseakito/ec_api/models"
 	"github.com/haseakito/ec_api/requests"
+	"github.com/haseakito/ec_api/revisions"
 	"github.com/haseakito/ec_api/utils"
 	"github.com/labstack/echo/v4"
 	"gorm.io/gorm"
@@ -37,6 +40,9 @@ func NewAdminProductHandler(db *gorm.DB) *AdminProductHandler {
 Description:
 
 	Update a specific product with the product id provided and based on the data in the request payload.
+	Each edit is saved as a revision of the product, which can be reverted.
+	The edits of a published product are staged in its draft until the draft is published, so that customers never see half-edited listings.
+	The publication and the stock of a published product are updated immediately.
 
 HTTP Method:
 
@@ -62,6 +68,9 @@ func (h AdminProductHandler) UpdateProduct(c echo.Context) error {
 		return nil
 	}
 
+	// Keep a copy of the product for the audit log
+	before := product
+
 	// Parsing request payload and validate the data
 	// If there is a problem with the request, throw an error
 	var req requests.ProductUpdateRequest
@@ -77,7 +86,29 @@ func (h AdminProductHandler) UpdateProduct(c echo.Context) error {
 		return nil
 	}
 
+	// Get the draft of the product, if any
+	// The edit builds on the edits staged in the draft
+	draft, err := drafts.Get(h.db, product.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+	if draft != nil {
+		if err := drafts.Apply(*draft, &product); err != nil {
+			c.JSON(http.StatusInternalServerError, err)
+			return nil
+		}
+	}
+
 	// Update product fields if the fields are not empty
+	if req.SKU != "" {
+		// If the SKU is already taken by another product of the store, then throw a Conflict error
+		if productSKUTaken(h.db, product.StoreID, req.SKU, product.ID) {
+			c.JSON(http.StatusConflict, "The SKU is already taken")
+			return nil
+		}
+		product.SKU = &req.SKU
+	}
 	if req.Name != "" {
 		product.Name = req.Name
 	}
@@ -87,12 +118,145 @@ func (h AdminProductHandler) UpdateProduct(c echo.Context) error {
 	if req.Price != 0 {
 		product.Price = &req.Price
 	}
-	product.Published = req.Published
+	if req.Stock != nil {
+		product.Stock = req.Stock
+	}
+	if req.Tags != nil {
+		product.Tags = utils.NormalizeTags(req.Tags)
+	}
+
+	// Update the publication of the product only if it is given
+	// If the product was force-unpublished by the platform, then it cannot be published
+	if req.Published != nil {
+		if *req.Published && product.LockedAt != nil {
+			c.JSON(http.StatusForbidden, "The product was unpublished by the platform and cannot be published")
+			return nil
+		}
+		product.Published = *req.Published
+	}
+
+	// If the product is published, then update its publication and its stock, and stage the other edits in its draft
+	// If the update is unsuccessful, then throw an error
+	if before.Published {
+		live := before
+		live.Published = product.Published
+		live.Stock = product.Stock
+
+		if err := h.db.Transaction(func(tx *gorm.DB) error {
+			if live.Published != before.Published || req.Stock != nil {
+				if err := tx.Model(&live).Select("published", "stock").Updates(&live).Error; err != nil {
+					return err
+				}
+				if err := audit.Record(tx, c, live.StoreID, "product", live.ID, models.AuditUpdate, before, live); err != nil {
+					return err
+				}
+				if err := revisions.Record(tx, c, before, live, nil); err != nil {
+					return err
+				}
+			}
+
+			draft, err = drafts.Stage(tx, c, live, product)
+			return err
+		}); err != nil {
+			c.JSON(http.StatusInternalServerError, err)
+			return nil
+		}
+
+		live.Draft = draft
+		return c.JSON(http.StatusOK, live)
+	}
+
+	// Update product with data, and record the audit event and the revision in a transaction
+	// Only the edited columns are written, so that the units reserved by concurrent checkouts are kept
+	// The edits staged in the draft of the product are applied along, so the draft is discarded
+	// If the update is unsuccessful, then throw an error
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&product).Select("sku", "name", "description", "price", "published", "stock", "tags").Updates(&product).Error; err != nil {
+			return err
+		}
+		if err := audit.Record(tx, c, product.StoreID, "product", product.ID, models.AuditUpdate, before, product); err != nil {
+			return err
+		}
+		if err := revisions.Record(tx, c, before, product, nil); err != nil {
+			return err
+		}
+		if draft == nil {
+			return nil
+		}
+		return drafts.Discard(tx, product.ID)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+
+	return c.JSON(http.StatusOK, product)
+}
+
+/*
+Description:
+
+	Schedule the publication and the unpublication of a specific product with the product id based on the data in the request payload.
+	The schedule replaces the previous one, and a null time cancels the scheduled change. Schedules are applied in the background.
+
+HTTP Method:
+
+	PUT `/api/v1/admin/products/:id/schedule`
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	An error if any occurred during the execution of the function, nil otherwise.
+*/
+func (h AdminProductHandler) ScheduleProduct(c echo.Context) error {
+	// Get product id from request
+	productID := c.Param("id")
+
+	// Get a product with product id
+	// If there is no record, then throw a NotFound error
+	var product models.Product
+	if err := h.db.Take(&product, "id = ?", productID).Error; err != nil {
+		c.JSON(http.StatusNotFound, nil)
+		return nil
+	}
+
+	// Keep a copy of the product for the audit log
+	before := product
+
+	// Parsing request payload and validate the data
+	// If there is a problem with the request, throw an error
+	var req requests.ProductScheduleRequest
+	if err := c.Bind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return nil
+	}
+
+	// Validate request data
+	// If there is a problem with the request, throw an error
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return nil
+	}
+
+	// If the product was force-unpublished by the platform, then it cannot be scheduled for publication
+	if req.PublishAt != nil && product.LockedAt != nil {
+		c.JSON(http.StatusForbidden, "The product was unpublished by the platform and cannot be published")
+		return nil
+	}
+	product.PublishAt = req.PublishAt
+	product.UnpublishAt = req.UnpublishAt
 
-	// Update product with data
+	// Update the schedule of the product and record the audit event in a transaction
 	// If the update is unsuccessful, then throw an error
-	if res := h.db.Save(&product); res.Error != nil {
-		c.JSON(http.StatusInternalServerError, res.Error)
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&product).Select("publish_at", "unpublish_at").Updates(&product).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, c, product.StoreID, "product", product.ID, models.AuditUpdate, before, product)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, err)
 		return nil
 	}
 
@@ -166,9 +330,14 @@ func (h AdminProductHandler) UploadImages(c echo.Context) error {
 			ProductID: productId,
 		}
 
-		// Create a new product image
-		if res := h.db.Create(&product_image); res.Error != nil {
-			c.JSON(http.StatusInternalServerError, res.Error)
+		// Create a new product image and record the audit event in a transaction
+		if err := h.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&product_image).Error; err != nil {
+				return err
+			}
+			return audit.Record(tx, c, product.StoreID, "product_image", product_image.ID, models.AuditCreate, nil, product_image)
+		}); err != nil {
+			c.JSON(http.StatusInternalServerError, err)
 			return nil
 		}
 	}
@@ -179,7 +348,8 @@ func (h AdminProductHandler) UploadImages(c echo.Context) error {
 /*
 Description:
 
-	Delete a specific product with the product id and delete the corresponding object in storage.
+	Move a specific product with the product id to the trash. The product is hidden from all APIs but keeps its images,
+	variants, categories and collections until it is restored or purged after the retention period.
 
 HTTP Method:
 
@@ -200,33 +370,20 @@ func (h AdminProductHandler) DeleteProduct(c echo.Context) error {
 	// Get a product with product id
 	// If there is no record, then throw a NotFound error
 	var product models.Product
-	if err := h.db.Preload("ProductImages").Find(&product, "id = ?", productID).Error; err != nil {
+	if err := h.db.Take(&product, "id = ?", productID).Error; err != nil {
 		c.JSON(http.StatusNotFound, nil)
 		return nil
 	}
 
-	// If the store has image urls, delete the corresponding objects from S3
-	if len(product.ProductImages) > 0 {
-		// Iterate over product images and delete the corresponding object from S3
-		for _, image := range product.ProductImages {
-			// Delete the corresponsing object from S3
-			if err := utils.Delete(image.Url); err != nil {
-				c.JSON(http.StatusInternalServerError, err)
-				return nil
-			}
-
-			// Delete the product image record
-			if res := h.db.Delete(&image); res.Error != nil {
-				c.JSON(http.StatusInternalServerError, res.Error)
-				return nil
-			}
-		}
-	}
-
-	// Delete a product
+	// Trash the product and record the audit event in a transaction
 	// If the delete is unsuccessful, then throw an error
-	if res := h.db.Delete(&product, "id = ?", productID); res.Error != nil {
-		c.JSON(http.StatusInternalServerError, res.Error)
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&product).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, c, product.StoreID, "product", product.ID, models.AuditDelete, product, nil)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, err)
 		return nil
 	}
 
@@ -236,7 +393,7 @@ func (h AdminProductHandler) DeleteProduct(c echo.Context) error {
 /*
 Description:
 
-	Delete a specific product image with the product_image id and delete the object in storage.
+	Move a specific product image with the product_image id to the trash. The object in storage is deleted when the image is purged after the retention period.
 
 HTTP Method:
 
@@ -257,25 +414,39 @@ func (h AdminProductHandler) DeleteProductImage(c echo.Context) error {
 	// Get product image id from request
 	productImageID := c.Param("image_id")
 
-	// Get a product image with product id and product image id
+	// Get a product with product id
 	// If there is no record, then throw a NotFound error
-	var productImage models.ProductImage
-	if err := h.db.Find(&productImage, "id = ? AND product_id = ?", productImageID, productID).Error; err != nil {
+	var product models.Product
+	if err := h.db.Take(&product, "id = ?", productID).Error; err != nil {
 		c.JSON(http.StatusNotFound, nil)
 		return nil
 	}
 
-	// Delete the corresponsing object from S3
-	if err := utils.Delete(productImage.Url); err != nil {
-		c.JSON(http.StatusInternalServerError, err)
+	// Get a product image with product id and product image id
+	// If there is no record, then throw a NotFound error
+	var productImage models.ProductImage
+	if err := h.db.Take(&productImage, "id = ? AND product_id = ?", productImageID, productID).Error; err != nil {
+		c.JSON(http.StatusNotFound, nil)
 		return nil
 	}
 
-	// Delete the product image record
-	if res := h.db.Delete(&productImage); res.Error != nil {
-		c.JSON(http.StatusInternalServerError, res.Error)
+	// Trash the product image and record the audit event in a transaction
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&productImage).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, c, product.StoreID, "product_image", productImage.ID, models.AuditDelete, productImage, nil)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, err)
 		return nil
 	}
 
 	return c.JSON(http.StatusOK, "Successfully deleted the product image")
 }
+
+// productSKUTaken reports whether the SKU is used by a product of the store other than the excluded one.
+func productSKUTaken(db *gorm.DB, storeID string, sku string, excludeID string) bool {
+	var count int64
+	db.Model(&models.Product{}).Where("store_id = ? AND sku = ? AND id <> ?", storeID, sku, excludeID).Count(&count)
+	return count > 0
+}
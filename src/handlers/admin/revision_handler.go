@@ -0,0 +1,189 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+
+	"github.com/haseakito/ec_api/audit"
+	"github.com/haseakito/ec_api/drafts"
+	"github.com/haseakito/ec_api/models"
+	"github.com/haseakito/ec_api/pagination"
+	"github.com/haseakito/ec_api/revisions"
+)
+
+type AdminRevisionHandler struct {
+	db *gorm.DB
+}
+
+/*
+Description:
+
+	Instantiates a new AdminRevisionHandler with the provided database connection.
+
+Parameters:
+
+	db (*gorm.DB): A pointer to the GORM database connection.
+
+Returns:
+
+	*AdminRevisionHandler: A pointer to the newly created AdminRevisionHandler instance.
+*/
+func NewAdminRevisionHandler(db *gorm.DB) *AdminRevisionHandler {
+	return &AdminRevisionHandler{
+		db: db,
+	}
+}
+
+/*
+Description:
+
+	Get the revisions of a specific product with the product id one page at a time, newest first.
+	Each revision holds the versioned fields of the product after the edit, the changed fields and the editor.
+
+HTTP Method:
+
+	GET `/api/v1/admin/products/:id/revisions`
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	An error if any occurred during the execution of the function, nil otherwise.
+*/
+func (h AdminRevisionHandler) GetRevisions(c echo.Context) error {
+	// Get product id from request
+	productID := c.Param("id")
+
+	// Get the pagination from request
+	// If the limit or the cursor is invalid, then throw an error
+	p, err := pagination.ParseKeyset(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return nil
+	}
+
+	// Get a page of the revisions of the product
+	var productRevisions []models.ProductRevision
+	if err := h.db.Where("product_id = ?", productID).Scopes(p.Newest("product_revisions")).Find(&productRevisions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+
+	return c.JSON(http.StatusOK, pagination.NewKeysetPage(productRevisions, p, func(r models.ProductRevision) models.Model { return r.Model }))
+}
+
+/*
+Description:
+
+	Revert a specific product with the product id to one of its revisions with the version.
+	The versioned fields of the product are set to their values in the revision, and the revert is saved as a new revision.
+	The revert of a published product is staged in its draft instead, keeping the product published, until the draft is published.
+
+HTTP Method:
+
+	POST `/api/v1/admin/products/:id/revisions/:version/revert`
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	An error if any occurred during the execution of the function, nil otherwise.
+*/
+func (h AdminRevisionHandler) RevertRevision(c echo.Context) error {
+	// Get product id from request
+	productID := c.Param("id")
+
+	// Get a product with product id
+	// If there is no record, then throw a NotFound error
+	var product models.Product
+	if err := h.db.Take(&product, "id = ?", productID).Error; err != nil {
+		c.JSON(http.StatusNotFound, nil)
+		return nil
+	}
+
+	// Get a revision of the product with the version
+	// If the version is invalid or there is no record, then throw a NotFound error
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, nil)
+		return nil
+	}
+	var revision models.ProductRevision
+	if err := h.db.Take(&revision, "product_id = ? AND version = ?", product.ID, version).Error; err != nil {
+		c.JSON(http.StatusNotFound, nil)
+		return nil
+	}
+
+	var snapshot revisions.Snapshot
+	if err := json.Unmarshal(revision.Snapshot, &snapshot); err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+
+	// Keep a copy of the product for the audit log
+	// The publication of a published product is not reverted, since its edits are staged in its draft
+	before := product
+	if before.Published {
+		snapshot.Published = true
+	}
+	snapshot.Apply(&product)
+
+	// If the SKU was taken by another product of the store in the meantime, then throw a Conflict error
+	if product.SKU != nil && productSKUTaken(h.db, product.StoreID, *product.SKU, product.ID) {
+		c.JSON(http.StatusConflict, "The SKU is already taken")
+		return nil
+	}
+
+	// If the product was force-unpublished by the platform, then it cannot be published
+	if product.Published && !before.Published && product.LockedAt != nil {
+		c.JSON(http.StatusForbidden, "The product was unpublished by the platform and cannot be published")
+		return nil
+	}
+
+	// If the product is published, then stage the revert in its draft, so that customers never see half-edited listings
+	// If the update is unsuccessful, then throw an error
+	if before.Published {
+		var draft *models.ProductDraft
+		if err := h.db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			draft, err = drafts.Stage(tx, c, before, product)
+			return err
+		}); err != nil {
+			c.JSON(http.StatusInternalServerError, err)
+			return nil
+		}
+
+		before.Draft = draft
+		return c.JSON(http.StatusOK, before)
+	}
+
+	// Update product with the revision, and record the audit event and the revision in a transaction
+	// Only the versioned columns are written, so that the stock and the units reserved by concurrent checkouts are kept
+	// The revert replaces the edits staged in the draft of the product, so the draft is discarded
+	// If the update is unsuccessful, then throw an error
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&product).Select("sku", "name", "description", "price", "published", "tags").Updates(&product).Error; err != nil {
+			return err
+		}
+		if err := audit.Record(tx, c, product.StoreID, "product", product.ID, models.AuditUpdate, before, product); err != nil {
+			return err
+		}
+		if err := revisions.Record(tx, c, before, product, &revision.Version); err != nil {
+			return err
+		}
+		return drafts.Discard(tx, product.ID)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+
+	return c.JSON(http.StatusOK, product)
+}
@@ -1,14 +1,21 @@
 package admin
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
+	"github.com/haseakito/ec_api/audit"
+	"github.com/haseakito/ec_api/auth"
 	"github.com/haseakito/ec_api/models"
+	"github.com/haseakito/ec_api/money"
+	"github.com/haseakito/ec_api/pagination"
 	"github.com/haseakito/ec_api/requests"
+	"github.com/haseakito/ec_api/trash"
 	"github.com/haseakito/ec_api/utils"
 	"github.com/labstack/echo/v4"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type AdminStoreHandler struct {
@@ -52,6 +59,13 @@ Returns:
 	An error if any occurred during the execution of the function, nil otherwise.
 */
 func (h AdminStoreHandler) CreateStore(c echo.Context) error {
+	// Get the authenticated user from the context
+	// If there is no user, then throw an unauthorized error
+	user, err := auth.CurrentUser(c)
+	if err != nil {
+		return echo.ErrUnauthorized
+	}
+
 	// Parsing request payload and validate the data
 	// If there is a problem with the request, throw an error
 	var req requests.StoreCreateRequest
@@ -67,17 +81,29 @@ func (h AdminStoreHandler) CreateStore(c echo.Context) error {
 		return nil
 	}
 
+	// Prices of the store are in the default currency unless specified
+	currency := req.Currency
+	if currency == "" {
+		currency = money.DefaultCurrency
+	}
+
 	// Instantiate a new store
 	store := models.Store{
-		UserID:      req.UserID,
+		UserID:      user.ID,
 		Name:        req.Name,
 		Description: &req.Description,
+		Currency:    currency,
 	}
 
-	// Create a new store
+	// Create a new store and record the audit event in a transaction
 	// If the creation is unsuccessful, then throw an error
-	if res := h.db.Create(&store); res.Error != nil {
-		c.JSON(http.StatusInternalServerError, res.Error)
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&store).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, c, store.ID, "store", store.ID, models.AuditCreate, nil, store)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, err)
 		return nil
 	}
 
@@ -113,6 +139,9 @@ func (h AdminStoreHandler) UploadImage(c echo.Context) error {
 		return nil
 	}
 
+	// Keep a copy of the store for the audit log
+	before := store
+
 	// Get file from request
 	// If there is a problem with the request, throw an error
 	file, err := c.FormFile("image")
@@ -144,10 +173,15 @@ func (h AdminStoreHandler) UploadImage(c echo.Context) error {
 	// Update the image url
 	store.ImageUrl = &url
 
-	// Update store with data
+	// Update store with data and record the audit event in a transaction
 	// If the update is unsuccessful, then throw an error
-	if res := h.db.Save(&store); res.Error != nil {
-		c.JSON(http.StatusInternalServerError, res.Error)
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&store).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, c, store.ID, "store", store.ID, models.AuditUpdate, before, store)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, err)
 		return nil
 	}
 
@@ -183,6 +217,9 @@ func (h AdminStoreHandler) UpdateStore(c echo.Context) error {
 		return nil
 	}
 
+	// Keep a copy of the store for the audit log
+	before := store
+
 	// Parsing request payload and validate the data
 	// If there is a problem with the request, throw an error
 	var req requests.StoreUpdateRequest
@@ -205,11 +242,26 @@ func (h AdminStoreHandler) UpdateStore(c echo.Context) error {
 	if req.Description != "" {
 		store.Description = &req.Description
 	}
+	if req.Currency != "" && req.Currency != store.Currency {
+		// Prices are stored in minor units of the currency, so the currency cannot change under existing prices
+		var count int64
+		h.db.Model(&models.Product{}).Where("store_id = ?", store.ID).Count(&count)
+		if count > 0 {
+			c.JSON(http.StatusConflict, "The currency cannot be changed once the store has products")
+			return nil
+		}
+		store.Currency = req.Currency
+	}
 
-	// Update store with data
+	// Update store with data and record the audit event in a transaction
 	// If the update is unsuccessful, then throw an error
-	if res := h.db.Save(&store); res.Error != nil {
-		c.JSON(http.StatusInternalServerError, res.Error)
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&store).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, c, store.ID, "store", store.ID, models.AuditUpdate, before, store)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, err)
 		return nil
 	}
 
@@ -219,7 +271,9 @@ func (h AdminStoreHandler) UpdateStore(c echo.Context) error {
 /*
 Description:
 
-	Delete a specific store with the store id and delete the corresponding object in storage.
+	Move a specific store with the store id to the trash. The store and its products are hidden from all APIs until the store is restored.
+	The store is not permanently deleted by this request: its deletion runs as a background job with progress, enqueued by the purge
+	of the store or once the retention period ends. Stores with unpaid or unfulfilled orders cannot be deleted.
 
 HTTP Method:
 
@@ -245,15 +299,36 @@ func (h AdminStoreHandler) DeleteStore(c echo.Context) error {
 		return nil
 	}
 
-	// If the store has an image url, delete the corresponding object from S3
-	if store.ImageUrl != nil {
-		utils.Delete(*store.ImageUrl)
-	}
-
-	// Delete a store
+	// Trash a store and record the audit event in a transaction
+	// The store is locked while its orders are checked, so that no checkout of the store starts until it is trashed
+	// If the store has unpaid or unfulfilled orders, then throw a Conflict error
 	// If the delete is unsuccessful, then throw an error
-	if res := h.db.Delete(&store, "id = ?", storeId); res.Error != nil {
-		c.JSON(http.StatusInternalServerError, res.Error)
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Take(&store, "id = ?", store.ID).Error; err != nil {
+			return err
+		}
+		open, err := trash.HasOpenOrders(tx, store.ID)
+		if err != nil {
+			return err
+		}
+		if open {
+			return trash.ErrOpenOrders
+		}
+
+		if err := tx.Delete(&store).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, c, store.ID, "store", store.ID, models.AuditDelete, store, nil)
+	}); err != nil {
+		if errors.Is(err, trash.ErrOpenOrders) {
+			c.JSON(http.StatusConflict, "The store has unpaid or unfulfilled orders")
+			return nil
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, nil)
+			return nil
+		}
+		c.JSON(http.StatusInternalServerError, err)
 		return nil
 	}
 
@@ -289,6 +364,9 @@ func (h AdminStoreHandler) DeleteImage(c echo.Context) error {
 		return nil
 	}
 
+	// Keep a copy of the store for the audit log
+	before := store
+
 	// If the store has an image url, delete the corresponding object from S3
 	if store.ImageUrl != nil {
 		utils.Delete(*store.ImageUrl)
@@ -296,8 +374,15 @@ func (h AdminStoreHandler) DeleteImage(c echo.Context) error {
 
 	store.ImageUrl = nil
 
-	if res := h.db.Save(&store); res.Error != nil {
-		c.JSON(http.StatusInternalServerError, res.Error)
+	// Update store with data and record the audit event in a transaction
+	// If the update is unsuccessful, then throw an error
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&store).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, c, store.ID, "store", store.ID, models.AuditUpdate, before, store)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, err)
 		return nil
 	}
 
@@ -348,17 +433,31 @@ func (h AdminStoreHandler) CreateProduct(c echo.Context) error {
 		return nil
 	}
 
+	// If the SKU is already taken in the store, then throw a Conflict error
+	if req.SKU != nil && productSKUTaken(h.db, store.ID, *req.SKU, "") {
+		c.JSON(http.StatusConflict, "The SKU is already taken")
+		return nil
+	}
+
 	// Instantiate a new product
 	product := models.Product{
 		StoreID:     store.ID,
+		SKU:         req.SKU,
 		Name:        req.Name,
 		Description: req.Description,
 		Price:       req.Price,
+		Stock:       req.Stock,
+		Tags:        utils.NormalizeTags(req.Tags),
 	}
 
-	// Create a new product for the store
-	if res := h.db.Create(&product); res.Error != nil {
-		c.JSON(http.StatusInternalServerError, res.Error)
+	// Create a new product for the store and record the audit event in a transaction
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&product).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, c, store.ID, "product", product.ID, models.AuditCreate, nil, product)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, err)
 		return nil
 	}
 
@@ -368,7 +467,7 @@ func (h AdminStoreHandler) CreateProduct(c echo.Context) error {
 /*
 Description:
 
-	Get all products for a specific store with the store id.
+	Get the products for a specific store with the store id one page at a time, newest first.
 
 HTTP Method:
 
@@ -386,47 +485,88 @@ func (h AdminStoreHandler) GetProducts(c echo.Context) error {
 	// Get store id from request
 	storeID := c.Param("id")
 
-	//
-	// offsetStr := c.QueryParam("offset")
-	// offset, err := strconv.Atoi(offsetStr)
-	// if err != nil {
-	// 	return echo.ErrBadRequest
-	// }
+	// Get the pagination from request
+	// If the limit or the cursor is invalid, then throw an error
+	p, err := pagination.ParseKeyset(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return nil
+	}
 
-	// Get a store with store id and products associated with the store
-	// If there is no record, then throw a NotFound error
+	// Get a page of the products associated with the store
 	var products []models.Product
-	if err := h.db.Preload("ProductImages").Where("store_id = ?", storeID).Limit(10).Find(&products).Error; err != nil {
-		c.JSON(http.StatusNotFound, nil)
+	if err := h.db.Preload("ProductImages").Where("store_id = ?", storeID).Scopes(p.Newest("products")).Find(&products).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, err)
 		return nil
 	}
 
-	return c.JSON(http.StatusOK, products)
+	return c.JSON(http.StatusOK, pagination.NewKeysetPage(products, p, func(product models.Product) models.Model { return product.Model }))
 }
 
+/*
+Description:
+
+	Get the revenue of a specific store with the store id over the past year, per currency, and its paid orders one page at a time, newest first.
+	The revenue and the sales count are aggregated in the database over all the paid orders of the year, not only the page.
+
+HTTP Method:
+
+	GET `/api/v1/admin/stores/:id/orders`
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	An error if any occurred during the execution of the function, nil otherwise.
+*/
 func (h AdminStoreHandler) GetRevenues(c echo.Context) error {
 	// Get store id from request
 	storeID := c.Param("id")
 
+	// Get the pagination from request
+	// If the limit or the cursor is invalid, then throw an error
+	p, err := pagination.ParseKeyset(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return nil
+	}
+
 	oneYearAgo := time.Now().AddDate(-1, 0, 0)
+	paidOrders := func() *gorm.DB {
+		return h.db.Model(&models.Order{}).Where("store_id = ? AND paid = ? AND created_at >= ?", storeID, true, oneYearAgo)
+	}
 
-	var orders []models.Order
-	if err := h.db.Preload("OrderItems.Product").Where("store_id = ? AND paid = ? AND created_at >= ?", storeID, true, oneYearAgo).Find(&orders).Error; err != nil {
-		c.JSON(http.StatusNotFound, nil)
+	// Revenue is computed from the amounts snapshotted at checkout time, per currency
+	var totals []struct {
+		Currency string
+		Amount   int64
+		Count    int64
+	}
+	if err := paidOrders().Select("currency, SUM(total) AS amount, COUNT(*) AS count").Group("currency").Scan(&totals).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, err)
 		return nil
 	}
 
-	var totalRevenue float64
-	for _, order := range orders {
-		for _, item := range order.OrderItems {
-			totalRevenue += float64(*item.Product.Price)
-		}
+	revenues := make([]money.Money, 0, len(totals))
+	var salesCount int64
+	for _, total := range totals {
+		revenues = append(revenues, money.Money{Amount: total.Amount, Currency: total.Currency})
+		salesCount += total.Count
+	}
+
+	// Get a page of the paid orders of the year
+	var orders []models.Order
+	if err := paidOrders().Preload("OrderItems").Scopes(p.Newest("orders")).Find(&orders).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
 	}
 
 	res := map[string]interface{}{
-		"orders":        orders,
-		"total_revenue": totalRevenue,
-		"sales_count":   len(orders),
+		"orders":        pagination.NewKeysetPage(orders, p, func(o models.Order) models.Model { return o.Model }),
+		"total_revenue": revenues,
+		"sales_count":   salesCount,
 	}
 
 	return c.JSON(http.StatusOK, res)
@@ -0,0 +1,414 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+
+	"github.com/haseakito/ec_api/audit"
+	"github.com/haseakito/ec_api/auth"
+	"github.com/haseakito/ec_api/jobs"
+	"github.com/haseakito/ec_api/models"
+	"github.com/haseakito/ec_api/pagination"
+	"github.com/haseakito/ec_api/trash"
+)
+
+type AdminTrashHandler struct {
+	db *gorm.DB
+}
+
+/*
+Description:
+
+	Instantiates a new AdminTrashHandler with the provided database connection.
+
+Parameters:
+
+	db (*gorm.DB): A pointer to the GORM database connection.
+
+Returns:
+
+	*AdminTrashHandler: A pointer to the newly created AdminTrashHandler instance.
+*/
+func NewAdminTrashHandler(db *gorm.DB) *AdminTrashHandler {
+	return &AdminTrashHandler{
+		db: db,
+	}
+}
+
+/*
+Description:
+
+	Get the trashed products of a specific store with the store id one page at a time, newest first.
+	Trashed products are purged after the retention period.
+
+HTTP Method:
+
+	GET `/api/v1/admin/stores/:id/trash/products`
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	An error if any occurred during the execution of the function, nil otherwise.
+*/
+func (h AdminTrashHandler) GetTrashedProducts(c echo.Context) error {
+	// Get store id from request
+	storeID := c.Param("id")
+
+	// Get the pagination from request
+	// If the limit or the cursor is invalid, then throw an error
+	p, err := pagination.ParseKeyset(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return nil
+	}
+
+	// Get a page of the trashed products of the store
+	var products []models.Product
+	if err := h.db.Unscoped().
+		Preload("ProductImages").
+		Where("store_id = ? AND deleted_at IS NOT NULL AND archived_at IS NULL", storeID).
+		Scopes(p.Newest("products")).
+		Find(&products).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+
+	return c.JSON(http.StatusOK, pagination.NewKeysetPage(products, p, func(product models.Product) models.Model { return product.Model }))
+}
+
+/*
+Description:
+
+	Get the trashed images of the products of a specific store with the store id one page at a time, newest first.
+	The images of trashed products are listed with their products instead.
+
+HTTP Method:
+
+	GET `/api/v1/admin/stores/:id/trash/images`
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	An error if any occurred during the execution of the function, nil otherwise.
+*/
+func (h AdminTrashHandler) GetTrashedImages(c echo.Context) error {
+	// Get store id from request
+	storeID := c.Param("id")
+
+	// Get the pagination from request
+	// If the limit or the cursor is invalid, then throw an error
+	p, err := pagination.ParseKeyset(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return nil
+	}
+
+	// Get a page of the trashed images of the products of the store
+	var images []models.ProductImage
+	if err := h.db.Unscoped().
+		Where("deleted_at IS NOT NULL").
+		Where("product_id IN (?)", h.db.Model(&models.Product{}).Select("id").Where("store_id = ?", storeID)).
+		Scopes(p.Newest("product_images")).
+		Find(&images).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+
+	return c.JSON(http.StatusOK, pagination.NewKeysetPage(images, p, func(image models.ProductImage) models.Model { return image.Model }))
+}
+
+/*
+Description:
+
+	Restore a specific trashed store with the store id along with its products.
+
+HTTP Method:
+
+	POST `/api/v1/admin/stores/:id/restore`
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	An error if any occurred during the execution of the function, nil otherwise.
+*/
+func (h AdminTrashHandler) RestoreStore(c echo.Context) error {
+	// Get store id from request
+	storeID := c.Param("id")
+
+	// Get a trashed store with store id
+	// If there is no record, then throw a NotFound error
+	var store models.Store
+	if err := h.db.Unscoped().Take(&store, "id = ? AND deleted_at IS NOT NULL", storeID).Error; err != nil {
+		c.JSON(http.StatusNotFound, nil)
+		return nil
+	}
+
+	// If the store was purged or is being purged, then throw a Conflict error
+	if store.ArchivedAt != nil {
+		c.JSON(http.StatusConflict, "The store was purged and can no longer be restored")
+		return nil
+	}
+	running, err := trash.RunningStoreDeletion(h.db, store.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+	if running != nil {
+		c.JSON(http.StatusConflict, "The store is being purged and can no longer be restored")
+		return nil
+	}
+
+	// Keep a copy of the store for the audit log
+	before := store
+	store.DeletedAt = gorm.DeletedAt{}
+
+	// Restore the store and record the audit event in a transaction
+	// If the update is unsuccessful, then throw an error
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&store).Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, c, store.ID, "store", store.ID, models.AuditRestore, before, store)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+
+	return c.JSON(http.StatusOK, store)
+}
+
+/*
+Description:
+
+	Restore a specific trashed product with the product id along with its images, variants, categories and collections.
+
+HTTP Method:
+
+	POST `/api/v1/admin/products/:id/restore`
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	An error if any occurred during the execution of the function, nil otherwise.
+*/
+func (h AdminTrashHandler) RestoreProduct(c echo.Context) error {
+	// Get product id from request
+	productID := c.Param("id")
+
+	// Get a trashed product with product id
+	// If there is no record, then throw a NotFound error
+	var product models.Product
+	if err := h.db.Unscoped().Take(&product, "id = ? AND deleted_at IS NOT NULL", productID).Error; err != nil {
+		c.JSON(http.StatusNotFound, nil)
+		return nil
+	}
+
+	// If the product was purged, then throw a Conflict error
+	if product.ArchivedAt != nil {
+		c.JSON(http.StatusConflict, "The product was purged and can no longer be restored")
+		return nil
+	}
+
+	// If the SKU was taken by another product of the store in the meantime, then throw a Conflict error
+	if product.SKU != nil && productSKUTaken(h.db, product.StoreID, *product.SKU, product.ID) {
+		c.JSON(http.StatusConflict, "The SKU is already taken")
+		return nil
+	}
+
+	// Keep a copy of the product for the audit log
+	before := product
+	product.DeletedAt = gorm.DeletedAt{}
+
+	// Restore the product and record the audit event in a transaction
+	// If the update is unsuccessful, then throw an error
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&product).Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, c, product.StoreID, "product", product.ID, models.AuditRestore, before, product)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+
+	return c.JSON(http.StatusOK, product)
+}
+
+/*
+Description:
+
+	Restore a specific trashed product image with the product_image id.
+
+HTTP Method:
+
+	POST `/api/v1/admin/products/:id/assets/:image_id/restore`
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	An error if any occurred during the execution of the function, nil otherwise.
+*/
+func (h AdminTrashHandler) RestoreProductImage(c echo.Context) error {
+	// Get product id from request
+	productID := c.Param("id")
+
+	// Get product image id from request
+	productImageID := c.Param("image_id")
+
+	// Get a product with product id
+	// If there is no record, then throw a NotFound error
+	var product models.Product
+	if err := h.db.Take(&product, "id = ?", productID).Error; err != nil {
+		c.JSON(http.StatusNotFound, nil)
+		return nil
+	}
+
+	// Get a trashed product image with product id and product image id
+	// If there is no record, then throw a NotFound error
+	var productImage models.ProductImage
+	if err := h.db.Unscoped().Take(&productImage, "id = ? AND product_id = ? AND deleted_at IS NOT NULL", productImageID, productID).Error; err != nil {
+		c.JSON(http.StatusNotFound, nil)
+		return nil
+	}
+
+	// Keep a copy of the product image for the audit log
+	before := productImage
+	productImage.DeletedAt = gorm.DeletedAt{}
+
+	// Restore the product image and record the audit event in a transaction
+	// If the update is unsuccessful, then throw an error
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&productImage).Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, c, product.StoreID, "product_image", productImage.ID, models.AuditRestore, before, productImage)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+
+	return c.JSON(http.StatusOK, productImage)
+}
+
+/*
+Description:
+
+	Permanently delete a specific trashed store with the store id without waiting for the end of the retention period.
+	The deletion is run by a background job, which is returned to be polled. Stores with unpaid or unfulfilled orders cannot be purged.
+
+HTTP Method:
+
+	POST `/api/v1/admin/stores/:id/purge`
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	An error if any occurred during the execution of the function, nil otherwise.
+*/
+func (h AdminTrashHandler) PurgeStore(c echo.Context) error {
+	// Get store id from request
+	storeID := c.Param("id")
+
+	// Get a trashed store with store id
+	// If there is no record, then throw a NotFound error
+	var store models.Store
+	if err := h.db.Unscoped().Take(&store, "id = ? AND deleted_at IS NOT NULL", storeID).Error; err != nil {
+		c.JSON(http.StatusNotFound, nil)
+		return nil
+	}
+
+	// If the store was already purged, then throw a Conflict error
+	if store.ArchivedAt != nil {
+		c.JSON(http.StatusConflict, "The store was already purged")
+		return nil
+	}
+
+	// If the store has unpaid or unfulfilled orders, then throw a Conflict error
+	open, err := trash.HasOpenOrders(h.db, store.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+	if open {
+		c.JSON(http.StatusConflict, "The store has unpaid or unfulfilled orders")
+		return nil
+	}
+
+	// If the store is already being purged, then throw a Conflict error
+	running, err := trash.RunningStoreDeletion(h.db, store.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+	if running != nil {
+		c.JSON(http.StatusConflict, "The store is already being purged")
+		return nil
+	}
+
+	// Enqueue the deletion job on behalf of the actor of the request
+	// If the creation is unsuccessful, then throw an error
+	actorType, actorID := auth.CurrentActor(c)
+	job := trash.NewStoreDeletionJob(store, actorType, actorID, c.Response().Header().Get(echo.HeaderXRequestID))
+	if err := jobs.Enqueue(h.db, &job); err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+
+	return c.JSON(http.StatusAccepted, job)
+}
+
+/*
+Description:
+
+	Get the latest deletion job of a specific trashed store with the store id, including its progress and result so far.
+	Once a store without orders is deleted, it is no longer found.
+
+HTTP Method:
+
+	GET `/api/v1/admin/stores/:id/purge`
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	An error if any occurred during the execution of the function, nil otherwise.
+*/
+func (h AdminTrashHandler) GetStorePurge(c echo.Context) error {
+	// Get store id from request
+	storeID := c.Param("id")
+
+	// Get the latest deletion job of the store
+	// If there is no record, then throw a NotFound error
+	var job models.Job
+	if err := h.db.Omit("input").
+		Where("store_id = ? AND type = ?", storeID, trash.JobTypeStoreDeletion).
+		Order("created_at DESC").
+		Take(&job).Error; err != nil {
+		c.JSON(http.StatusNotFound, nil)
+		return nil
+	}
+
+	return c.JSON(http.StatusOK, job)
+}
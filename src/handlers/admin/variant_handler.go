@@ -0,0 +1,670 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/haseakito/ec_api/audit"
+	"github.com/haseakito/ec_api/models"
+	"github.com/haseakito/ec_api/pagination"
+	"github.com/haseakito/ec_api/requests"
+	"github.com/haseakito/ec_api/utils"
+)
+
+// errVariantReserved is returned when a variant held by pending checkouts is deleted
+var errVariantReserved = errors.New("the variant is reserved by pending checkouts")
+
+type AdminVariantHandler struct {
+	db *gorm.DB
+}
+
+/*
+Description:
+
+	Instantiates a new AdminVariantHandler with the provided database connection.
+
+Parameters:
+
+	db (*gorm.DB): A pointer to the GORM database connection.
+
+Returns:
+
+	*AdminVariantHandler: A pointer to the newly created AdminVariantHandler instance.
+*/
+func NewAdminVariantHandler(db *gorm.DB) *AdminVariantHandler {
+	return &AdminVariantHandler{
+		db: db,
+	}
+}
+
+/*
+Description:
+
+	Create an option type with its values for a specific product with the product id and based on the data provided in the request payload.
+
+HTTP Method:
+
+	POST `/api/v1/admin/products/:id/options`
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	An error if any occurred during the execution of the function, nil otherwise.
+*/
+func (h AdminVariantHandler) CreateOption(c echo.Context) error {
+	// Get product id from request
+	productID := c.Param("id")
+
+	// Get a product with product id
+	// If there is no record, then throw a NotFound error
+	var product models.Product
+	if err := h.db.Take(&product, "id = ?", productID).Error; err != nil {
+		c.JSON(http.StatusNotFound, nil)
+		return nil
+	}
+
+	// Parsing request payload and validate the data
+	// If there is a problem with the request, throw an error
+	var req requests.OptionCreateRequest
+	if err := c.Bind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return nil
+	}
+
+	// Validate request data
+	// If there is a problem with the request, throw an error
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return nil
+	}
+
+	// Place the new option after the existing options of the product
+	var count int64
+	h.db.Model(&models.ProductOption{}).Where("product_id = ?", productID).Count(&count)
+
+	// Instantiate a new option with its values
+	option := models.ProductOption{
+		ProductID: productID,
+		Name:      req.Name,
+		Position:  int(count),
+	}
+	for i, value := range req.Values {
+		option.Values = append(option.Values, models.ProductOptionValue{
+			Value:    value,
+			Position: i,
+		})
+	}
+
+	// Create a new option and record the audit event in a transaction
+	// If the creation is unsuccessful, then throw an error
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&option).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, c, product.StoreID, "product_option", option.ID, models.AuditCreate, nil, option)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+
+	return c.JSON(http.StatusCreated, option)
+}
+
+/*
+Description:
+
+	Delete a specific option type with the option id and its values.
+	Options still used by variants of the product cannot be deleted.
+
+HTTP Method:
+
+	DELETE `/api/v1/admin/products/:id/options/:option_id`
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	An error if any occurred during the execution of the function, nil otherwise.
+*/
+func (h AdminVariantHandler) DeleteOption(c echo.Context) error {
+	// Get product id and option id from request
+	productID := c.Param("id")
+	optionID := c.Param("option_id")
+
+	// Get a product with product id
+	// If there is no record, then throw a NotFound error
+	var product models.Product
+	if err := h.db.Take(&product, "id = ?", productID).Error; err != nil {
+		c.JSON(http.StatusNotFound, nil)
+		return nil
+	}
+
+	// Get an option with option id and product id
+	// If there is no record, then throw a NotFound error
+	var option models.ProductOption
+	if err := h.db.Preload("Values").Take(&option, "id = ? AND product_id = ?", optionID, productID).Error; err != nil {
+		c.JSON(http.StatusNotFound, nil)
+		return nil
+	}
+
+	// If any variant uses a value of the option, then throw a Conflict error
+	var count int64
+	h.db.Table("variant_option_values").
+		Joins("JOIN product_option_values ON product_option_values.id = variant_option_values.product_option_value_id").
+		Where("product_option_values.option_id = ?", option.ID).
+		Count(&count)
+	if count > 0 {
+		c.JSON(http.StatusConflict, "The option is used by variants of the product")
+		return nil
+	}
+
+	// Delete the option values and the option, and record the audit event in a transaction
+	// If the delete is unsuccessful, then throw an error
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("option_id = ?", option.ID).Delete(&models.ProductOptionValue{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&option).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, c, product.StoreID, "product_option", option.ID, models.AuditDelete, option, nil)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+
+	return c.JSON(http.StatusOK, "Successfully deleted the option")
+}
+
+/*
+Description:
+
+	Get the variants of a specific product with the product id one page at a time, oldest first.
+
+HTTP Method:
+
+	GET `/api/v1/admin/products/:id/variants`
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	An error if any occurred during the execution of the function, nil otherwise.
+*/
+func (h AdminVariantHandler) GetVariants(c echo.Context) error {
+	// Get product id from request
+	productID := c.Param("id")
+
+	// Get the pagination from request
+	// If the limit or the cursor is invalid, then throw an error
+	p, err := pagination.ParseKeyset(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return nil
+	}
+
+	// Get a page of the variants of the product
+	var variants []models.ProductVariant
+	if err := h.db.Preload("OptionValues").Preload("Images").
+		Where("product_id = ?", productID).
+		Scopes(p.Oldest("product_variants")).
+		Find(&variants).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+
+	return c.JSON(http.StatusOK, pagination.NewKeysetPage(variants, p, func(v models.ProductVariant) models.Model { return v.Model }))
+}
+
+/*
+Description:
+
+	Create a variant for a specific product with the product id and based on the data provided in the request payload.
+	The variant must pick exactly one value of every option of the product, and no other variant may have the same combination.
+
+HTTP Method:
+
+	POST `/api/v1/admin/products/:id/variants`
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	An error if any occurred during the execution of the function, nil otherwise.
+*/
+func (h AdminVariantHandler) CreateVariant(c echo.Context) error {
+	// Get product id from request
+	productID := c.Param("id")
+
+	// Get a product with its options and variants
+	// If there is no record, then throw a NotFound error
+	var product models.Product
+	if err := h.db.Preload("Options.Values").Preload("Variants.OptionValues").Take(&product, "id = ?", productID).Error; err != nil {
+		c.JSON(http.StatusNotFound, nil)
+		return nil
+	}
+
+	// Parsing request payload and validate the data
+	// If there is a problem with the request, throw an error
+	var req requests.VariantCreateRequest
+	if err := c.Bind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return nil
+	}
+
+	// Validate request data
+	// If there is a problem with the request, throw an error
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return nil
+	}
+
+	// Resolve the option values of the variant against the options of the product
+	// If the combination is invalid, then throw an error
+	values, err := resolveOptionValues(product.Options, req.OptionValueIDs)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return nil
+	}
+
+	// If another variant has the same combination, then throw a Conflict error
+	key := combinationKey(values)
+	for _, variant := range product.Variants {
+		if combinationKey(variant.OptionValues) == key {
+			c.JSON(http.StatusConflict, "A variant with the same options already exists")
+			return nil
+		}
+	}
+
+	// If the SKU is already taken by another variant of the store, then throw a Conflict error
+	if req.SKU != nil && h.skuTaken(product.StoreID, *req.SKU, "") {
+		c.JSON(http.StatusConflict, "The SKU is already taken")
+		return nil
+	}
+
+	// Instantiate a new variant
+	variant := models.ProductVariant{
+		StoreID:      product.StoreID,
+		ProductID:    productID,
+		SKU:          req.SKU,
+		Price:        req.Price,
+		Stock:        req.Stock,
+		OptionValues: values,
+	}
+
+	// Create a new variant and record the audit event in a transaction
+	// If the creation is unsuccessful, then throw an error
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("OptionValues.*").Create(&variant).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, c, product.StoreID, "product_variant", variant.ID, models.AuditCreate, nil, variant)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+
+	return c.JSON(http.StatusCreated, variant)
+}
+
+/*
+Description:
+
+	Update a specific variant with the variant id and based on the data in the request payload.
+
+HTTP Method:
+
+	PATCH `/api/v1/admin/products/:id/variants/:variant_id`
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	An error if any occurred during the execution of the function, nil otherwise.
+*/
+func (h AdminVariantHandler) UpdateVariant(c echo.Context) error {
+	// Get product id and variant id from request
+	productID := c.Param("id")
+	variantID := c.Param("variant_id")
+
+	// Get a product with product id
+	// If there is no record, then throw a NotFound error
+	var product models.Product
+	if err := h.db.Take(&product, "id = ?", productID).Error; err != nil {
+		c.JSON(http.StatusNotFound, nil)
+		return nil
+	}
+
+	// Get a variant with variant id and product id
+	// If there is no record, then throw a NotFound error
+	var variant models.ProductVariant
+	if err := h.db.Take(&variant, "id = ? AND product_id = ?", variantID, productID).Error; err != nil {
+		c.JSON(http.StatusNotFound, nil)
+		return nil
+	}
+
+	// Keep a copy of the variant for the audit log
+	before := variant
+
+	// Parsing request payload and validate the data
+	// If there is a problem with the request, throw an error
+	var req requests.VariantUpdateRequest
+	if err := c.Bind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return nil
+	}
+
+	// Validate request data
+	// If there is a problem with the request, throw an error
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return nil
+	}
+
+	// Update variant fields if the fields are not empty
+	if req.SKU != "" {
+		// If the SKU is already taken by another variant of the store, then throw a Conflict error
+		if h.skuTaken(product.StoreID, req.SKU, variant.ID) {
+			c.JSON(http.StatusConflict, "The SKU is already taken")
+			return nil
+		}
+		variant.SKU = &req.SKU
+	}
+	if req.Price != 0 {
+		variant.Price = &req.Price
+	}
+	if req.Stock != nil {
+		variant.Stock = req.Stock
+	}
+
+	// Update variant with data and record the audit event in a transaction
+	// Only the edited columns are written, so that the units reserved by concurrent checkouts are kept
+	// If the update is unsuccessful, then throw an error
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&variant).Select("sku", "price", "stock").Updates(&variant).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, c, product.StoreID, "product_variant", variant.ID, models.AuditUpdate, before, variant)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+
+	return c.JSON(http.StatusOK, variant)
+}
+
+/*
+Description:
+
+	Upload image files specific to a variant to storage and attach them to the variant with the variant id.
+
+HTTP Method:
+
+	POST `/api/v1/admin/products/:id/variants/:variant_id/upload`
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	An error if any occurred during the execution of the function, nil otherwise.
+*/
+func (h AdminVariantHandler) UploadVariantImages(c echo.Context) error {
+	// Get product id and variant id from request
+	productID := c.Param("id")
+	variantID := c.Param("variant_id")
+
+	// Get a product with product id
+	// If there is no record, then throw a NotFound error
+	var product models.Product
+	if err := h.db.Take(&product, "id = ?", productID).Error; err != nil {
+		c.JSON(http.StatusNotFound, nil)
+		return nil
+	}
+
+	// Get a variant with variant id and product id
+	// If there is no record, then throw a NotFound error
+	var variant models.ProductVariant
+	if err := h.db.Take(&variant, "id = ? AND product_id = ?", variantID, productID).Error; err != nil {
+		c.JSON(http.StatusNotFound, nil)
+		return nil
+	}
+
+	// Multipart form
+	form, err := c.MultipartForm()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return nil
+	}
+
+	// Get files from request
+	files := form.File["images"]
+
+	if len(files) == 0 {
+		c.JSON(http.StatusBadRequest, "No files uploaded")
+		return nil
+	}
+
+	// Iterate over images and upload them to S3
+	for _, file := range files {
+		// Validate request file
+		// If there is a problem with the request, throw an error
+		if err := requests.ValidateFile(file); err != nil {
+			c.JSON(http.StatusBadRequest, err.Error())
+			return nil
+		}
+
+		// Upload file to AWS S3 bucket
+		// If the upload is unsuccessful, then throw an error
+		url, err := utils.Upload(file, "products/")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, err)
+			return nil
+		}
+
+		// Instantiate a new product image for the variant
+		image := models.ProductImage{
+			Url:       url,
+			ProductID: productID,
+			VariantID: &variant.ID,
+		}
+
+		// Create a new product image and record the audit event in a transaction
+		if err := h.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&image).Error; err != nil {
+				return err
+			}
+			return audit.Record(tx, c, product.StoreID, "product_image", image.ID, models.AuditCreate, nil, image)
+		}); err != nil {
+			c.JSON(http.StatusInternalServerError, err)
+			return nil
+		}
+	}
+
+	return c.JSON(http.StatusOK, "Successfully uploaded the images")
+}
+
+/*
+Description:
+
+	Delete a specific variant with the variant id, its images and the corresponding objects in storage.
+	Variants held by pending checkouts cannot be deleted until their stock is released or committed.
+
+HTTP Method:
+
+	DELETE `/api/v1/admin/products/:id/variants/:variant_id`
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	An error if any occurred during the execution of the function, nil otherwise.
+*/
+func (h AdminVariantHandler) DeleteVariant(c echo.Context) error {
+	// Get product id and variant id from request
+	productID := c.Param("id")
+	variantID := c.Param("variant_id")
+
+	// Get a product with product id
+	// If there is no record, then throw a NotFound error
+	var product models.Product
+	if err := h.db.Take(&product, "id = ?", productID).Error; err != nil {
+		c.JSON(http.StatusNotFound, nil)
+		return nil
+	}
+
+	// Get a variant with its images, including the trashed ones
+	// If there is no record, then throw a NotFound error
+	var variant models.ProductVariant
+	if err := h.db.Preload("Images", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).Take(&variant, "id = ? AND product_id = ?", variantID, productID).Error; err != nil {
+		c.JSON(http.StatusNotFound, nil)
+		return nil
+	}
+
+	// If the variant is held by pending checkouts, then throw a Conflict error
+	// The stock of the variant must be released or committed with the variant
+	reserved, err := variantReserved(h.db, variant.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+	if reserved {
+		c.JSON(http.StatusConflict, "The variant is reserved by pending checkouts")
+		return nil
+	}
+
+	// Iterate over variant images and delete the corresponding object from S3
+	for _, image := range variant.Images {
+		if err := utils.Delete(image.Url); err != nil {
+			c.JSON(http.StatusInternalServerError, err)
+			return nil
+		}
+	}
+
+	// Delete the images, the option value associations and the variant, and record the audit events in a transaction
+	// The variant is locked, so that no checkout reserves it until it is deleted
+	// The objects of the images are already deleted, so the images are not moved to the trash
+	// If the variant was reserved in the meantime, then throw a Conflict error
+	// If the delete is unsuccessful, then throw an error
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").Take(&models.ProductVariant{}, "id = ?", variant.ID).Error; err != nil {
+			return err
+		}
+		if reserved, err := variantReserved(tx, variant.ID); err != nil || reserved {
+			if err == nil {
+				err = errVariantReserved
+			}
+			return err
+		}
+
+		// The released and committed reservations of the variant no longer hold stock, so they outlive it without it
+		if err := tx.Model(&models.StockReservation{}).Where("variant_id = ?", variant.ID).Update("variant_id", nil).Error; err != nil {
+			return err
+		}
+
+		for _, image := range variant.Images {
+			if err := tx.Unscoped().Delete(&image).Error; err != nil {
+				return err
+			}
+			if err := audit.Record(tx, c, product.StoreID, "product_image", image.ID, models.AuditDelete, image, nil); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&variant).Association("OptionValues").Clear(); err != nil {
+			return err
+		}
+		if err := tx.Delete(&variant).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, c, product.StoreID, "product_variant", variant.ID, models.AuditDelete, variant, nil)
+	}); err != nil {
+		if errors.Is(err, errVariantReserved) {
+			c.JSON(http.StatusConflict, "The variant is reserved by pending checkouts")
+			return nil
+		}
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+
+	return c.JSON(http.StatusOK, "Successfully deleted the variant")
+}
+
+// variantReserved reports whether pending checkouts hold stock of the variant.
+func variantReserved(db *gorm.DB, variantID string) (bool, error) {
+	var count int64
+	err := db.Model(&models.StockReservation{}).Where("variant_id = ? AND status = ?", variantID, models.ReservationActive).Count(&count).Error
+	return count > 0, err
+}
+
+// skuTaken reports whether the SKU is used by a variant of the store other than the excluded one.
+func (h AdminVariantHandler) skuTaken(storeID string, sku string, excludeID string) bool {
+	var count int64
+	h.db.Model(&models.ProductVariant{}).Where("store_id = ? AND sku = ? AND id <> ?", storeID, sku, excludeID).Count(&count)
+	return count > 0
+}
+
+// resolveOptionValues maps the requested option value ids onto the options of the product,
+// requiring exactly one value for every option.
+func resolveOptionValues(options []models.ProductOption, ids []string) ([]models.ProductOptionValue, error) {
+	if len(options) == 0 {
+		return nil, errors.New("The product has no options")
+	}
+
+	requested := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		requested[id] = true
+	}
+
+	values := make([]models.ProductOptionValue, 0, len(options))
+	for _, option := range options {
+		var picked []models.ProductOptionValue
+		for _, value := range option.Values {
+			if requested[value.ID] {
+				picked = append(picked, value)
+				delete(requested, value.ID)
+			}
+		}
+		if len(picked) != 1 {
+			return nil, errors.New("Exactly one value is required for option " + option.Name)
+		}
+		values = append(values, picked[0])
+	}
+
+	if len(requested) > 0 {
+		return nil, errors.New("Unknown option values for the product")
+	}
+
+	return values, nil
+}
+
+// combinationKey builds a key identifying the combination of option values regardless of their order.
+func combinationKey(values []models.ProductOptionValue) string {
+	ids := make([]string, len(values))
+	for i, value := range values {
+		ids[i] = value.ID
+	}
+	sort.Strings(ids)
+
+	return strings.Join(ids, ",")
+}
@@ -0,0 +1,485 @@
+package platform
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+
+	"github.com/haseakito/ec_api/auth"
+	"github.com/haseakito/ec_api/models"
+	"github.com/haseakito/ec_api/pagination"
+	"github.com/haseakito/ec_api/requests"
+)
+
+type PlatformHandler struct {
+	db    *gorm.DB
+	users auth.UserDirectory
+}
+
+// Owner information returned along with the stores
+type storeOwner struct {
+	ID        string  `json:"id"`
+	Email     *string `json:"email"`
+	FirstName *string `json:"first_name"`
+	LastName  *string `json:"last_name"`
+}
+
+// Store returned by the platform APIs
+type platformStore struct {
+	models.Store
+	Owner storeOwner `json:"owner"`
+}
+
+/*
+Description:
+
+	Instantiates a new PlatformHandler with the provided database connection and user directory.
+
+Parameters:
+
+	db (*gorm.DB): A pointer to the GORM database connection.
+	users (auth.UserDirectory): The directory used to look up store owners. Nullable, only the owner id is returned when nil.
+
+Returns:
+
+	*PlatformHandler: A pointer to the newly created PlatformHandler instance.
+*/
+func NewPlatformHandler(db *gorm.DB, users auth.UserDirectory) *PlatformHandler {
+	return &PlatformHandler{
+		db:    db,
+		users: users,
+	}
+}
+
+/*
+Description:
+
+	Get the stores including suspended ones one page at a time, newest first, along with the information of their owners.
+
+HTTP Method:
+
+	GET `/api/v1/platform/stores`
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	An error if any occurred during the execution of the function, nil otherwise.
+*/
+func (h PlatformHandler) GetStores(c echo.Context) error {
+	// Get the pagination from request
+	// If the limit or the cursor is invalid, then throw an error
+	p, err := pagination.ParseKeyset(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return nil
+	}
+
+	// Get a page of the stores
+	var stores []models.Store
+	if err := h.db.Scopes(p.Newest("stores")).Find(&stores).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+	page := pagination.NewKeysetPage(stores, p, func(s models.Store) models.Model { return s.Model })
+
+	// Look up the owners of the stores in a single request
+	userIDs := make([]string, 0, len(page.Data))
+	for _, store := range page.Data {
+		userIDs = append(userIDs, store.UserID)
+	}
+	owners := h.lookupOwners(userIDs)
+
+	res := make([]platformStore, 0, len(page.Data))
+	for _, store := range page.Data {
+		res = append(res, platformStore{Store: store, Owner: owners[store.UserID]})
+	}
+
+	return c.JSON(http.StatusOK, pagination.Page[platformStore]{Data: res, NextCursor: page.NextCursor, HasMore: page.HasMore})
+}
+
+/*
+Description:
+
+	Suspend a specific store with the store id. Suspended stores and their products are hidden from the public APIs.
+
+HTTP Method:
+
+	POST `/api/v1/platform/stores/:id/suspend`
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	An error if any occurred during the execution of the function, nil otherwise.
+*/
+func (h PlatformHandler) SuspendStore(c echo.Context) error {
+	// Parsing request payload and validate the data
+	// If there is a problem with the request, throw an error
+	req, ok := bindModerationRequest(c)
+	if !ok {
+		return nil
+	}
+
+	// Get a store with store id
+	// If there is no record, then throw a NotFound error
+	var store models.Store
+	if err := h.db.Take(&store, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, nil)
+		return nil
+	}
+
+	// Update store fields
+	now := time.Now()
+	store.SuspendedAt = &now
+	store.SuspensionReason = optionalReason(req.Reason)
+
+	// Update the store and record the action in a transaction
+	// If the transaction failed, then throw an error
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&store).Error; err != nil {
+			return err
+		}
+		return recordAction(tx, c, models.ModerationSuspendStore, "store", store.ID, optionalReason(req.Reason))
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+
+	return c.JSON(http.StatusOK, store)
+}
+
+/*
+Description:
+
+	Lift the suspension of a specific store with the store id.
+
+HTTP Method:
+
+	POST `/api/v1/platform/stores/:id/unsuspend`
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	An error if any occurred during the execution of the function, nil otherwise.
+*/
+func (h PlatformHandler) UnsuspendStore(c echo.Context) error {
+	// Parsing request payload and validate the data
+	// If there is a problem with the request, throw an error
+	req, ok := bindModerationRequest(c)
+	if !ok {
+		return nil
+	}
+
+	// Get a store with store id
+	// If there is no record, then throw a NotFound error
+	var store models.Store
+	if err := h.db.Take(&store, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, nil)
+		return nil
+	}
+
+	// Update store fields
+	store.SuspendedAt = nil
+	store.SuspensionReason = nil
+
+	// Update the store and record the action in a transaction
+	// If the transaction failed, then throw an error
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&store).Error; err != nil {
+			return err
+		}
+		return recordAction(tx, c, models.ModerationUnsuspendStore, "store", store.ID, optionalReason(req.Reason))
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+
+	return c.JSON(http.StatusOK, store)
+}
+
+/*
+Description:
+
+	Force-unpublish a specific product with the product id. The product is locked and cannot be published by the store until it is unlocked.
+
+HTTP Method:
+
+	POST `/api/v1/platform/products/:id/unpublish`
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	An error if any occurred during the execution of the function, nil otherwise.
+*/
+func (h PlatformHandler) UnpublishProduct(c echo.Context) error {
+	// Parsing request payload and validate the data
+	// If there is a problem with the request, throw an error
+	req, ok := bindModerationRequest(c)
+	if !ok {
+		return nil
+	}
+
+	// Get a product with product id
+	// If there is no record, then throw a NotFound error
+	var product models.Product
+	if err := h.db.Take(&product, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, nil)
+		return nil
+	}
+
+	// Update product fields
+	now := time.Now()
+	product.Published = false
+	product.LockedAt = &now
+
+	// Update the product and record the action in a transaction
+	// Only the moderated columns are written, so that the units reserved by concurrent checkouts are kept
+	// If the transaction failed, then throw an error
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&product).Select("published", "locked_at").Updates(&product).Error; err != nil {
+			return err
+		}
+		return recordAction(tx, c, models.ModerationUnpublishProduct, "product", product.ID, optionalReason(req.Reason))
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+
+	return c.JSON(http.StatusOK, product)
+}
+
+/*
+Description:
+
+	Unlock a specific product with the product id so that the store can publish it again.
+
+HTTP Method:
+
+	POST `/api/v1/platform/products/:id/unlock`
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	An error if any occurred during the execution of the function, nil otherwise.
+*/
+func (h PlatformHandler) UnlockProduct(c echo.Context) error {
+	// Parsing request payload and validate the data
+	// If there is a problem with the request, throw an error
+	req, ok := bindModerationRequest(c)
+	if !ok {
+		return nil
+	}
+
+	// Get a product with product id
+	// If there is no record, then throw a NotFound error
+	var product models.Product
+	if err := h.db.Take(&product, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, nil)
+		return nil
+	}
+
+	// Update product fields
+	product.LockedAt = nil
+
+	// Update the product and record the action in a transaction
+	// Only the moderated columns are written, so that the units reserved by concurrent checkouts are kept
+	// If the transaction failed, then throw an error
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&product).Select("locked_at").Updates(&product).Error; err != nil {
+			return err
+		}
+		return recordAction(tx, c, models.ModerationUnlockProduct, "product", product.ID, optionalReason(req.Reason))
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+
+	return c.JSON(http.StatusOK, product)
+}
+
+/*
+Description:
+
+	Remove an abusive review with the review id.
+
+HTTP Method:
+
+	DELETE `/api/v1/platform/reviews/:review_id`
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	An error if any occurred during the execution of the function, nil otherwise.
+*/
+func (h PlatformHandler) DeleteReview(c echo.Context) error {
+	// Parsing request payload and validate the data
+	// If there is a problem with the request, throw an error
+	req, ok := bindModerationRequest(c)
+	if !ok {
+		return nil
+	}
+
+	// Get a review with review id
+	// If there is no record, then throw a NotFound error
+	var review models.Review
+	if err := h.db.Take(&review, "id = ?", c.Param("review_id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, nil)
+		return nil
+	}
+
+	// Delete the review and record the action in a transaction
+	// If the transaction failed, then throw an error
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&review).Error; err != nil {
+			return err
+		}
+		return recordAction(tx, c, models.ModerationDeleteReview, "review", review.ID, optionalReason(req.Reason))
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+
+	return c.JSON(http.StatusOK, "Successfully deleted the review")
+}
+
+/*
+Description:
+
+	Get the actions taken by platform operators one page at a time, newest first, optionally filtered by target type and target id.
+
+HTTP Method:
+
+	GET `/api/v1/platform/actions`
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	An error if any occurred during the execution of the function, nil otherwise.
+*/
+func (h PlatformHandler) GetActions(c echo.Context) error {
+	// Get the pagination from request
+	// If the limit or the cursor is invalid, then throw an error
+	p, err := pagination.ParseKeyset(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return nil
+	}
+
+	query := h.db.Scopes(p.Newest("moderation_actions"))
+
+	// Apply the optional filters
+	if targetType := c.QueryParam("target_type"); targetType != "" {
+		query = query.Where("target_type = ?", targetType)
+	}
+	if targetID := c.QueryParam("target_id"); targetID != "" {
+		query = query.Where("target_id = ?", targetID)
+	}
+
+	// Get a page of the moderation actions
+	var actions []models.ModerationAction
+	if err := query.Find(&actions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+
+	return c.JSON(http.StatusOK, pagination.NewKeysetPage(actions, p, func(a models.ModerationAction) models.Model { return a.Model }))
+}
+
+// Look up the owners in the user directory at once, falling back to the owner ids only
+func (h PlatformHandler) lookupOwners(userIDs []string) map[string]storeOwner {
+	owners := make(map[string]storeOwner, len(userIDs))
+	unique := make([]string, 0, len(userIDs))
+	for _, userID := range userIDs {
+		if _, ok := owners[userID]; !ok {
+			owners[userID] = storeOwner{ID: userID}
+			unique = append(unique, userID)
+		}
+	}
+	if h.users == nil || len(unique) == 0 {
+		return owners
+	}
+
+	users, err := h.users.GetUsers(unique)
+	if err != nil {
+		return owners
+	}
+
+	for _, user := range users {
+		if _, ok := owners[user.ID]; !ok {
+			continue
+		}
+
+		owner := storeOwner{ID: user.ID, FirstName: user.FirstName, LastName: user.LastName}
+		for _, address := range user.EmailAddresses {
+			if user.PrimaryEmailAddressID != nil && address.ID == *user.PrimaryEmailAddressID {
+				email := address.EmailAddress
+				owner.Email = &email
+			}
+		}
+		owners[user.ID] = owner
+	}
+
+	return owners
+}
+
+// Return nil for an empty reason
+func optionalReason(reason string) *string {
+	if reason == "" {
+		return nil
+	}
+	return &reason
+}
+
+// Parse and validate the moderation request payload, writing a Bad Request response on failure
+func bindModerationRequest(c echo.Context) (requests.ModerationRequest, bool) {
+	var req requests.ModerationRequest
+	if err := c.Bind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return req, false
+	}
+
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return req, false
+	}
+
+	return req, true
+}
+
+// Record the action taken by the authenticated platform operator
+func recordAction(tx *gorm.DB, c echo.Context, action, targetType, targetID string, reason *string) error {
+	user, err := auth.CurrentUser(c)
+	if err != nil {
+		return err
+	}
+
+	return tx.Create(&models.ModerationAction{
+		ActorID:    user.ID,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Reason:     reason,
+	}).Error
+}
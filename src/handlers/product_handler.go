@@ -3,8 +3,12 @@ package handlers
 import (
 	"errors"
 	"net/http"
+	"time"
 
+	"github.com/haseakito/ec_api/auth"
+	"github.com/haseakito/ec_api/drafts"
 	"github.com/haseakito/ec_api/models"
+	"github.com/haseakito/ec_api/pagination"
 	"github.com/haseakito/ec_api/requests"
 	"github.com/labstack/echo/v4"
 	"gorm.io/gorm"
@@ -36,7 +40,9 @@ func NewProductHandler(db *gorm.DB) *ProductHandler {
 /*
 Description:
 
-	Get a specific product with the product id provided. Return nil if no record is found.
+	Get a specific product with the product id provided, including its options and variants. Return nil if no record is found.
+	Only published products are shown, unless a signed preview token is given in the preview query parameter:
+	the product is then shown with the edits staged in its draft, even if it is not published.
 
 HTTP Method:
 
@@ -54,9 +60,30 @@ func (h ProductHandler) GetProduct(c echo.Context) error {
 	// Get product id from request
 	productId := c.Param("id")
 
+	// If a preview token is given, then verify that it was signed for the product
+	// If the token is invalid or expired, then throw a Forbidden error
+	preview := c.QueryParam("preview")
+	if preview != "" {
+		if err := drafts.VerifyPreview(preview, productId, time.Now()); err != nil {
+			c.JSON(http.StatusForbidden, "The preview link is invalid or expired")
+			return nil
+		}
+	}
+
 	// Get a product with product id
+	// Unpublished products are only shown with a preview token
+	query := h.db.Scopes(models.ActiveStoreProducts)
+	if preview == "" {
+		query = query.Scopes(models.PublishedProducts)
+	}
 	var product models.Product
-	res := h.db.Preload("ProductImages").Take(&product, "id = ?", productId)
+	res := query.Preload("ProductImages").
+		Preload("Options", func(db *gorm.DB) *gorm.DB { return db.Order("position asc") }).
+		Preload("Options.Values", func(db *gorm.DB) *gorm.DB { return db.Order("position asc") }).
+		Preload("Variants.OptionValues").
+		Preload("Variants.Images").
+		Preload("Categories").
+		Take(&product, "id = ?", productId)
 
 	// If there is no record, then throw a NotFound error
 	if errors.Is(res.Error, gorm.ErrRecordNotFound) {
@@ -64,6 +91,22 @@ func (h ProductHandler) GetProduct(c echo.Context) error {
 		return nil
 	}
 
+	// Show the edits staged in the draft of the product, if any, and keep the preview out of shared caches
+	if preview != "" {
+		draft, err := drafts.Get(h.db, product.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, err)
+			return nil
+		}
+		if draft != nil {
+			if err := drafts.Apply(*draft, &product); err != nil {
+				c.JSON(http.StatusInternalServerError, err)
+				return nil
+			}
+		}
+		c.Response().Header().Set(echo.HeaderCacheControl, "private, no-store")
+	}
+
 	return c.JSON(http.StatusOK, product)
 }
 
@@ -85,12 +128,19 @@ Returns:
 	An error if any occurred during the execution of the function, nil otherwise.
 */
 func (h ProductHandler) CreateReview(c echo.Context) error {
+	// Get the authenticated user from the context
+	// If there is no user, then throw an unauthorized error
+	user, err := auth.CurrentUser(c)
+	if err != nil {
+		return echo.ErrUnauthorized
+	}
+
 	// Get product id from request
 	productId := c.Param("id")
 
-	// Get a store with store id
+	// Get a product with product id
 	var product models.Product
-	res := h.db.Take(&product, "id = ?", productId)
+	res := h.db.Scopes(models.ActiveStoreProducts, models.PublishedProducts).Take(&product, "id = ?", productId)
 
 	// If there is no record, then throw a NotFound error
 	if errors.Is(res.Error, gorm.ErrRecordNotFound) {
@@ -116,8 +166,9 @@ func (h ProductHandler) CreateReview(c echo.Context) error {
 	// Instantiate a new review
 	review := models.Review{
 		ProductID: productId,
-		UserID:    req.UserID,
+		UserID:    user.ID,
 		Content:   req.Content,
+		Rating:    &req.Rating,
 	}
 
 	// Create a new review for the product
@@ -132,7 +183,8 @@ func (h ProductHandler) CreateReview(c echo.Context) error {
 /*
 Description:
 
-	Get all reviews for a specific product with the product id.
+	Get the reviews for a specific product with the product id one page at a time, newest first.
+	Only the reviews of the products visible to customers are served.
 
 HTTP Method:
 
@@ -150,23 +202,36 @@ func (h ProductHandler) GetReviews(c echo.Context) error {
 	// Get product id from request
 	productId := c.Param("id")
 
-	// Get a product with product id
-	// If there is no record, then throw a NotFound error
-	var reviews []models.Review
-	res := h.db.Where("product_id = ?", productId).Find(&reviews)
+	// Get the pagination from request
+	// If the limit or the cursor is invalid, then throw an error
+	p, err := pagination.ParseKeyset(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return nil
+	}
 
-	if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+	// Get a published product of an active store with product id
+	// If there is no record, then throw a NotFound error
+	var product models.Product
+	if err := h.db.Scopes(models.ActiveStoreProducts, models.PublishedProducts).Take(&product, "id = ?", productId).Error; err != nil {
 		c.JSON(http.StatusNotFound, nil)
 		return nil
 	}
 
-	return c.JSON(http.StatusOK, reviews)
+	// Get a page of the reviews for the product
+	var reviews []models.Review
+	if err := h.db.Where("product_id = ?", product.ID).Scopes(p.Newest("reviews")).Find(&reviews).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+
+	return c.JSON(http.StatusOK, pagination.NewKeysetPage(reviews, p, func(r models.Review) models.Model { return r.Model }))
 }
 
 /*
 Description:
 
-	Delete a specific review with the review id.
+	Delete a specific review with the review id. Only the author of the review or the owner of the store can delete it.
 
 HTTP Method:
 
@@ -181,22 +246,44 @@ Returns:
 	An error if any occurred during the execution of the function, nil otherwise.
 */
 func (h ProductHandler) DeleteReview(c echo.Context) error {
+	// Get the authenticated user from the context
+	// If there is no user, then throw an unauthorized error
+	user, err := auth.CurrentUser(c)
+	if err != nil {
+		return echo.ErrUnauthorized
+	}
+
 	// Get product id from request
 	productId := c.Param("id")
 
 	// Get review id from request
 	reviewId := c.Param("review_id")
 
-	// Get a product image with product id and product image id
+	// Get a review with review id and product id
 	// If there is no record, then throw a NotFound error
 	var review models.Review
-	res := h.db.Find(&review, "id = ? AND product_id = ?", reviewId, productId)
+	res := h.db.Take(&review, "id = ? AND product_id = ?", reviewId, productId)
 
 	if errors.Is(res.Error, gorm.ErrRecordNotFound) {
 		c.JSON(http.StatusNotFound, nil)
 		return nil
 	}
 
+	// If the user is not the author of the review, check whether the user owns the store
+	// If the user is neither, then throw a Forbidden error
+	if review.UserID != user.ID {
+		var count int64
+		h.db.Model(&models.Product{}).
+			Joins("JOIN stores ON stores.id = products.store_id").
+			Where("products.id = ? AND stores.user_id = ?", productId, user.ID).
+			Count(&count)
+
+		if count == 0 {
+			c.JSON(http.StatusForbidden, nil)
+			return nil
+		}
+	}
+
 	// Delete the review record
 	if res := h.db.Delete(&review); res.Error != nil {
 		c.JSON(http.StatusInternalServerError, res.Error)
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+
+	"github.com/haseakito/ec_api/models"
+	"github.com/haseakito/ec_api/pagination"
+	"github.com/haseakito/ec_api/search"
+)
+
+type SearchHandler struct {
+	db *gorm.DB
+}
+
+/*
+Description:
+
+	Instantiates a new SearchHandler with the provided database connection.
+
+Parameters:
+
+	db (*gorm.DB): A pointer to the GORM database connection.
+
+Returns:
+
+	*SearchHandler: A pointer to the newly created SearchHandler instance.
+*/
+func NewSearchHandler(db *gorm.DB) *SearchHandler {
+	return &SearchHandler{
+		db: db,
+	}
+}
+
+/*
+Description:
+
+	Search the published products of all stores which are not suspended with the `q` query parameter.
+	Every term matches as a prefix, and the products are ordered by relevance with highlighted snippets.
+	The products are returned one page at a time with the `limit` and `cursor` query parameters.
+
+HTTP Method:
+
+	GET `/api/v1/search`
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	An error if any occurred during the execution of the function, nil otherwise.
+*/
+func (h SearchHandler) SearchProducts(c echo.Context) error {
+	// Get the search terms from request
+	// If there are no terms, then throw an error
+	q := c.QueryParam("q")
+	if search.PrefixQuery(q) == "" {
+		c.JSON(http.StatusBadRequest, "The search terms must contain letters or digits")
+		return nil
+	}
+
+	// Get the pagination from request
+	// If the limit or the cursor is invalid, then throw an error
+	p, err := pagination.ParseOffset(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return nil
+	}
+
+	// Get a page of the published products matching the terms
+	var products []models.Product
+	if err := h.db.Preload("ProductImages").
+		Scopes(models.ActiveStoreProducts, models.PublishedProducts, search.Match(q), p.Offset).
+		Find(&products).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+
+	return c.JSON(http.StatusOK, pagination.NewOffsetPage(products, p))
+}
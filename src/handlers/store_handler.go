@@ -4,14 +4,21 @@ import (
 	"errors"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/stripe/stripe-go/v76"
 	"github.com/stripe/stripe-go/v76/checkout/session"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
+	"github.com/haseakito/ec_api/auth"
+	"github.com/haseakito/ec_api/catalog"
+	"github.com/haseakito/ec_api/inventory"
 	"github.com/haseakito/ec_api/models"
+	"github.com/haseakito/ec_api/pagination"
 	"github.com/haseakito/ec_api/requests"
+	"github.com/haseakito/ec_api/search"
 )
 
 type StoreHandler struct {
@@ -40,7 +47,7 @@ func NewStoreHandler(db *gorm.DB) *StoreHandler {
 /*
 Description:
 
-	Get all stores. Return empty array if no record is found.
+	Get the stores which are not suspended, newest first, one page at a time. Return empty array if no record is found.
 
 HTTP Method:
 
@@ -55,15 +62,23 @@ Returns:
 	An error if any occurred during the execution of the function, nil otherwise.
 */
 func (h StoreHandler) GetStores(c echo.Context) error {
-	// Get all stores
+	// Get the pagination from request
+	// If the limit or the cursor is invalid, then throw an error
+	p, err := pagination.ParseKeyset(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return nil
+	}
+
+	// Get a page of stores
 	// If there is no record, then throw a NotFound error
 	var stores []models.Store
-	if err := h.db.Find(&stores).Error; err != nil {
+	if err := h.db.Scopes(models.ActiveStores, p.Newest("stores")).Find(&stores).Error; err != nil {
 		c.JSON(http.StatusNotFound, nil)
 		return nil
 	}
 
-	return c.JSON(http.StatusOK, stores)
+	return c.JSON(http.StatusOK, pagination.NewKeysetPage(stores, p, func(s models.Store) models.Model { return s.Model }))
 }
 
 /*
@@ -90,7 +105,7 @@ func (h StoreHandler) GetStore(c echo.Context) error {
 	// Get a store with store id
 	// If there is no record, then throw a NotFound error
 	var store models.Store
-	if err := h.db.Take(&store, "id = ?", storeId).Error; err != nil {
+	if err := h.db.Scopes(models.ActiveStores).Take(&store, "id = ?", storeId).Error; err != nil {
 		c.JSON(http.StatusNotFound, nil)
 		return nil
 	}
@@ -101,7 +116,17 @@ func (h StoreHandler) GetStore(c echo.Context) error {
 /*
 Description:
 
-	Get all published products for a specific store with the store id.
+	Get the published products for a specific store with the store id one page at a time, with the facet counts of the filters.
+
+	Query parameters:
+		q: Only the products matching the search terms, ordered by relevance with highlighted snippets.
+		category: The slug or the id of a category. Products of its descendant categories are included.
+		tags: Comma-separated tags the products must all have.
+		min_price, max_price: The price range in minor units.
+		in_stock: Only the products with units available when true.
+		min_rating: The minimum average rating from 1 to 5.
+		sort: One of newest (default), price_asc, price_desc, best_selling, top_rated or relevance (default with q).
+		limit, cursor: The pagination of the products.
 
 HTTP Method:
 
@@ -119,24 +144,250 @@ func (h StoreHandler) GetProducts(c echo.Context) error {
 	// Get store id from request
 	storeId := c.Param("id")
 
-	//
-	// offsetStr := c.QueryParam("offset")
-	// offset, err := strconv.Atoi(offsetStr)
-	// if err != nil {
-	// 	return echo.ErrBadRequest
-	// }
+	// Parsing query parameters and validate the data
+	// If there is a problem with the request, throw an error
+	var req requests.ProductListRequest
+	if err := c.Bind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return nil
+	}
+
+	// Validate request data
+	// If there is a problem with the request, throw an error
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return nil
+	}
+
+	// If the search terms have no letters or digits, then throw an error
+	if req.Q != "" && search.PrefixQuery(req.Q) == "" {
+		c.JSON(http.StatusBadRequest, "The search terms must contain letters or digits")
+		return nil
+	}
+
+	filter := catalog.Filter{
+		Tags:      req.TagList(),
+		MinPrice:  req.MinPrice,
+		MaxPrice:  req.MaxPrice,
+		InStock:   req.InStock,
+		MinRating: req.MinRating,
+	}
+
+	// Resolve the category filter
+	// If there is no such category in the store, then throw a NotFound error
+	if req.Category != "" {
+		var category models.Category
+		if err := h.db.Take(&category, "store_id = ? AND (slug = ? OR id = ?)", storeId, req.Category, req.Category).Error; err != nil {
+			c.JSON(http.StatusNotFound, nil)
+			return nil
+		}
+		filter.CategoryID = category.ID
+	}
+
+	// The published products of the store, matching the search terms if any
+	base := func() *gorm.DB {
+		query := h.db.Model(&models.Product{}).
+			Scopes(models.ActiveStoreProducts, models.PublishedProducts).
+			Where("products.store_id = ?", storeId)
+		if req.Q != "" {
+			query = query.Scopes(search.Matching(req.Q))
+		}
+		return query
+	}
+
+	// Get the pagination from request. The newest products are paged by keyset, other orders by offset
+	// If the limit or the cursor is invalid, then throw an error
+	keyset := req.Q == "" && (req.Sort == "" || req.Sort == catalog.SortNewest)
+	parse := pagination.ParseOffset
+	if keyset {
+		parse = pagination.ParseKeyset
+	}
+	p, err := parse(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return nil
+	}
+
+	// Get a page of the filtered products in the requested order
+	// Search results are ordered by relevance unless another order is requested
+	query := h.db.Preload("ProductImages").
+		Scopes(models.ActiveStoreProducts, models.PublishedProducts, filter.Scope).
+		Where("products.store_id = ?", storeId)
+	if keyset {
+		query = query.Scopes(p.Newest("products"))
+	} else {
+		if req.Q == "" || (req.Sort != "" && req.Sort != catalog.SortRelevance) {
+			query = query.Scopes(catalog.Sort(req.Sort))
+		}
+		if req.Q != "" {
+			query = query.Scopes(search.Match(req.Q))
+		}
+		query = query.Scopes(p.Offset)
+	}
 
-	// Get a store with store id and products associated with the store
 	var products []models.Product
-	res := h.db.Preload("ProductImages").Where("store_id = ? AND published = ?", storeId, true).Order("created_at desc").Limit(10).Find(&products)
+	if err := query.Find(&products).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+
+	page := pagination.NewOffsetPage(products, p)
+	if keyset {
+		page = pagination.NewKeysetPage(products, p, func(product models.Product) models.Model { return product.Model })
+	}
+
+	// Count the facets of the filters
+	facets, err := catalog.ComputeFacets(base, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+
+	res := struct {
+		pagination.Page[models.Product]
+		Facets catalog.Facets `json:"facets"`
+	}{page, facets}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+/*
+Description:
 
+	Get the category tree of a specific store with the store id.
+
+HTTP Method:
+
+	GET `/api/v1/stores/:id/categories`
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	An error if any occurred during the execution of the function, nil otherwise.
+*/
+func (h StoreHandler) GetCategories(c echo.Context) error {
+	// Get store id from request
+	storeID := c.Param("id")
+
+	// Get a store with store id
 	// If there is no record, then throw a NotFound error
-	if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+	var store models.Store
+	if err := h.db.Scopes(models.ActiveStores).Take(&store, "id = ?", storeID).Error; err != nil {
 		c.JSON(http.StatusNotFound, nil)
 		return nil
 	}
 
-	return c.JSON(http.StatusOK, products)
+	// Get all categories of the store
+	var categories []models.Category
+	if err := h.db.Where("store_id = ?", storeID).Find(&categories).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+
+	return c.JSON(http.StatusOK, models.BuildCategoryTree(categories))
+}
+
+/*
+Description:
+
+	Get the published products of a specific collection with the store id and the collection slug one page at a time, along with the collection.
+	The products of rule-based collections are the products matching the rule at the time of the request.
+
+HTTP Method:
+
+	GET `/api/v1/stores/:id/collections/:slug/products`
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	An error if any occurred during the execution of the function, nil otherwise.
+*/
+func (h StoreHandler) GetCollectionProducts(c echo.Context) error {
+	// Get store id and collection slug from request
+	storeID := c.Param("id")
+	slug := c.Param("slug")
+
+	// Get a store with store id
+	// If there is no record, then throw a NotFound error
+	var store models.Store
+	if err := h.db.Scopes(models.ActiveStores).Take(&store, "id = ?", storeID).Error; err != nil {
+		c.JSON(http.StatusNotFound, nil)
+		return nil
+	}
+
+	// Get a collection with the slug
+	// If there is no record, then throw a NotFound error
+	var collection models.Collection
+	if err := h.db.Take(&collection, "store_id = ? AND slug = ?", storeID, slug).Error; err != nil {
+		c.JSON(http.StatusNotFound, nil)
+		return nil
+	}
+
+	// Get the pagination from request. The newest products are paged by keyset, other orders by offset
+	// If the limit or the cursor is invalid, then throw an error
+	keyset := collection.Sort == catalog.SortNewest
+	parse := pagination.ParseOffset
+	if keyset {
+		parse = pagination.ParseKeyset
+	}
+	p, err := parse(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return nil
+	}
+
+	// Get a page of the published products of the collection in its order
+	query := h.db.Preload("ProductImages").
+		Scopes(models.PublishedProducts).
+		Where("products.store_id = ?", storeID)
+	if collection.Rule != nil {
+		// If the rule no longer parses, e.g. after a change of currency, then throw an error
+		rule, err := catalog.ParseRule(*collection.Rule, store.Currency)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, err.Error())
+			return nil
+		}
+		query = query.Scopes(rule.Scope)
+	} else if collection.Sort == models.CollectionSortManual {
+		query = query.Joins("JOIN collection_products ON collection_products.product_id = products.id AND collection_products.collection_id = ?", collection.ID).
+			Order("collection_products.position ASC").
+			Order("products.id")
+	} else {
+		query = query.Where("products.id IN (SELECT product_id FROM collection_products WHERE collection_id = ?)", collection.ID)
+	}
+
+	switch {
+	case keyset:
+		query = query.Scopes(p.Newest("products"))
+	case collection.Sort == models.CollectionSortManual:
+		query = query.Scopes(p.Offset)
+	default:
+		query = query.Scopes(catalog.Sort(collection.Sort), p.Offset)
+	}
+
+	var products []models.Product
+	if err := query.Find(&products).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+
+	page := pagination.NewOffsetPage(products, p)
+	if keyset {
+		page = pagination.NewKeysetPage(products, p, func(product models.Product) models.Model { return product.Model })
+	}
+
+	res := struct {
+		pagination.Page[models.Product]
+		Collection models.Collection `json:"collection"`
+	}{page, collection}
+
+	return c.JSON(http.StatusOK, res)
 }
 
 /*
@@ -157,13 +408,20 @@ Returns:
 	An error if any occurred during the execution of the function, Stripe checkout session url otherwise.
 */
 func (h StoreHandler) CreateOrder(c echo.Context) error {
+	// Get the authenticated user from the context
+	// If there is no user, then throw an unauthorized error
+	user, err := auth.CurrentUser(c)
+	if err != nil {
+		return echo.ErrUnauthorized
+	}
+
 	// Get store id from request
 	storeID := c.Param("id")
 
 	// Get a store with store id
 	// If there is no record, then throw a NotFound error
 	var store models.Store
-	if err := h.db.Take(&store, "id = ?", storeID).Error; err != nil {
+	if err := h.db.Scopes(models.ActiveStores).Take(&store, "id = ?", storeID).Error; err != nil {
 		c.JSON(http.StatusNotFound, nil)
 		return nil
 	}
@@ -175,42 +433,92 @@ func (h StoreHandler) CreateOrder(c echo.Context) error {
 		c.JSON(http.StatusBadRequest, err)
 		return nil
 	}
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return nil
+	}
 
-	// Iterate through product IDs to instantiate a new checkout session line items
+	// Iterate through the checkout lines to instantiate a new checkout session line items and order items
 	var lineItems []*stripe.CheckoutSessionLineItemParams
-	for _, productID := range req.ProductIDs {
-		// Get a product with product id
+	var orderItems []models.OrderItem
+	var subtotal int64
+	for _, item := range req.Lines() {
+		// Get a published product of the store with product id
 		var product models.Product
-		if err := h.db.Where("id = ? AND published = ?", productID, true).Take(&product).Error; err != nil {
+		if err := h.db.Preload("Variants").Scopes(models.PublishedProducts).Where("products.id = ? AND products.store_id = ?", item.ProductID, storeID).Take(&product).Error; err != nil {
 			c.JSON(http.StatusNotFound, err)
 			return nil
 		}
 
-		// Instantiate a new checkout session line item
-		lineItem := &stripe.CheckoutSessionLineItemParams{
-			PriceData: &stripe.CheckoutSessionLineItemPriceDataParams{
-				Currency: stripe.String("usd"),
-				ProductData: &stripe.CheckoutSessionLineItemPriceDataProductDataParams{
-					Name: stripe.String(product.Name),
-				},
-				UnitAmount: stripe.Int64(int64(*product.Price * 100)),
-			},
-			Quantity: stripe.Int64(1),
+		name := product.Name
+		price := product.Price
+		var variantLabel *string
+
+		if item.VariantID != nil {
+			// Get a variant of the product with variant id
+			var variant models.ProductVariant
+			if err := h.db.Preload("OptionValues").Take(&variant, "id = ? AND product_id = ?", *item.VariantID, product.ID).Error; err != nil {
+				c.JSON(http.StatusNotFound, err)
+				return nil
+			}
+
+			// The price of the variant overrides the price of the product
+			label := variant.Label()
+			variantLabel = &label
+			name += " (" + label + ")"
+			if variant.Price != nil {
+				price = variant.Price
+			}
+		} else if len(product.Variants) > 0 {
+			// Products with variants are sold by variant
+			c.JSON(http.StatusBadRequest, "A variant of the product "+product.Name+" is required")
+			return nil
+		}
+
+		// If the item has no price, then it cannot be purchased
+		if price == nil {
+			c.JSON(http.StatusBadRequest, "The product "+product.Name+" has no price")
+			return nil
 		}
 
-		// Add checkout session line item to array
-		lineItems = append(lineItems, lineItem)
+		// Snapshot the price and the name of the item so that later edits of the product do not rewrite the order
+		lineItems = append(lineItems, checkoutLineItem(name, *price, store.Currency, item.Quantity))
+		orderItems = append(orderItems, models.OrderItem{
+			ProductID:    product.ID,
+			VariantID:    item.VariantID,
+			Quantity:     item.Quantity,
+			UnitPrice:    *price,
+			Currency:     store.Currency,
+			ProductName:  product.Name,
+			VariantLabel: variantLabel,
+		})
+		subtotal += *price * int64(item.Quantity)
 	}
 
-	// Transaction to create an order and order items associated with the order
+	// Stock is held until the checkout session expires
+	ttl := inventory.ReservationTTLFromEnv()
+	expiresAt := time.Now().Add(ttl + inventory.ReservationGrace)
+
+	// Transaction to create an order and order items associated with the order, and reserve their stock
+	// The store is locked until the order is created, so that it cannot be trashed in the meantime
+	// If the store was trashed or suspended since, then throw a NotFound error
+	// If an item is out of stock, then throw a Conflict error
 	// If the transaction failed, then throw an error
 	var order models.Order
-	h.db.Transaction(func(tx *gorm.DB) error {
+	var outOfStock string
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "SHARE"}).Scopes(models.ActiveStores).Take(&models.Store{}, "id = ?", storeID).Error; err != nil {
+			return err
+		}
+
 		// Instantiate a new order
 		order = models.Order{
-			StoreID: storeID,
-			UserID:  req.UserID,
-			Paid:    false,
+			StoreID:  storeID,
+			UserID:   user.ID,
+			Paid:     false,
+			Currency: store.Currency,
+			Subtotal: subtotal,
+			Total:    subtotal,
 		}
 
 		// Create a new order
@@ -218,22 +526,44 @@ func (h StoreHandler) CreateOrder(c echo.Context) error {
 			return err
 		}
 
-		// Iterate through product IDs to create order items associated with the order
-		for _, productID := range req.ProductIDs {
-			// Instantiate a new order item
-			orderItem := models.OrderItem{
-				OrderID:   order.ID,
-				ProductID: productID,
-			}
+		// Iterate through order items to create them associated with the order
+		for i, orderItem := range orderItems {
+			orderItem.OrderID = order.ID
 
 			// Create a new order item
 			if err := tx.Create(&orderItem).Error; err != nil {
 				return err
 			}
+
+			// Reserve the stock of the order item
+			err := inventory.Reserve(tx, order.ID, orderItem.ProductID, orderItem.VariantID, orderItem.Quantity, expiresAt)
+			if errors.Is(err, inventory.ErrOutOfStock) {
+				outOfStock = *lineItems[i].PriceData.ProductData.Name
+			}
+			if err != nil {
+				return err
+			}
 		}
 
 		return nil
-	})
+	}); err != nil {
+		if outOfStock != "" {
+			c.JSON(http.StatusConflict, "The product "+outOfStock+" is out of stock")
+			return nil
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, nil)
+			return nil
+		}
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+
+	// The checkout session expires after the TTL from its creation, and never after the reservations
+	sessionExpiresAt := time.Now().Add(ttl)
+	if sessionExpiresAt.After(expiresAt) {
+		sessionExpiresAt = expiresAt
+	}
 
 	// Instantiate a stripe checkout session
 	params := &stripe.CheckoutSessionParams{
@@ -244,11 +574,23 @@ func (h StoreHandler) CreateOrder(c echo.Context) error {
 		},
 		SuccessURL: stripe.String(os.Getenv("FRONT_URL") + "/" + storeID + "/cart?success=true"),
 		CancelURL:  stripe.String(os.Getenv("FRONT_URL") + "/" + storeID + "/cart?canceled=true"),
+		ExpiresAt:  stripe.Int64(sessionExpiresAt.Unix()),
 	}
 
 	// Create a new stripe checkout session
+	// If the creation is unsuccessful, then cancel the order and throw an error
 	res, err := session.New(params)
 	if err != nil {
+		h.db.Transaction(func(tx *gorm.DB) error {
+			return cancelOrder(tx, &order)
+		})
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
+	}
+
+	// Link the checkout session to the order
+	order.CheckoutSessionID = &res.ID
+	if err := h.db.Model(&order).Update("checkout_session_id", res.ID).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, err)
 		return nil
 	}
@@ -259,7 +601,7 @@ func (h StoreHandler) CreateOrder(c echo.Context) error {
 /*
 Description:
 
-	Get specific orders with the store id provided. Return nil if no record is found.
+	Get the orders of the authenticated user for the store with the store id provided, newest first, one page at a time.
 
 HTTP Method:
 
@@ -274,14 +616,148 @@ Returns:
 	An error if any occurred during the execution of the function, nil otherwise.
 */
 func (h StoreHandler) GetOrders(c echo.Context) error {
+	// Get the authenticated user from the context
+	// If there is no user, then throw an unauthorized error
+	user, err := auth.CurrentUser(c)
+	if err != nil {
+		return echo.ErrUnauthorized
+	}
+
 	// Get store id from request
 	storeID := c.Param("id")
 
-	// 
+	// Get the pagination from request
+	// If the limit or the cursor is invalid, then throw an error
+	p, err := pagination.ParseKeyset(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return nil
+	}
+
+	// Get a page of orders placed by the user for the store, with the products trashed since the orders were placed
 	var orders []models.Order
-	if err := h.db.Preload("OrderItems.Product").Where("store_id = ?", storeID).Order("created_at desc").Limit(10).Find(&orders).Error; err != nil {
+	if err := h.db.Preload("OrderItems.Product", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).Where("store_id = ? AND user_id = ?", storeID, user.ID).Scopes(p.Newest("orders")).Find(&orders).Error; err != nil {
 		c.JSON(http.StatusNotFound, err)
+		return nil
+	}
+
+	return c.JSON(http.StatusOK, pagination.NewKeysetPage(orders, p, func(o models.Order) models.Model { return o.Model }))
+}
+
+/*
+Description:
+
+	Cancel an unpaid order of the authenticated user, release its reserved stock and expire its checkout session.
+
+HTTP Method:
+
+	POST `/api/v1/stores/:id/orders/:order_id/cancel`
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	An error if any occurred during the execution of the function, nil otherwise.
+*/
+func (h StoreHandler) CancelOrder(c echo.Context) error {
+	// Get the authenticated user from the context
+	// If there is no user, then throw an unauthorized error
+	user, err := auth.CurrentUser(c)
+	if err != nil {
+		return echo.ErrUnauthorized
+	}
+
+	// Get store id and order id from request
+	storeID := c.Param("id")
+	orderID := c.Param("order_id")
+
+	// Get an order placed by the user for the store
+	// If there is no record, then throw a NotFound error
+	var order models.Order
+	if err := h.db.Take(&order, "id = ? AND store_id = ? AND user_id = ?", orderID, storeID, user.ID).Error; err != nil {
+		c.JSON(http.StatusNotFound, nil)
+		return nil
+	}
+
+	// If the order is already paid or cancelled, then throw a Conflict error
+	if order.Paid || order.CanceledAt != nil {
+		c.JSON(http.StatusConflict, "Only pending orders can be cancelled")
+		return nil
+	}
+
+	// Expire the checkout session first so that the order can no longer be paid
+	// If the session is already completed, then throw a Conflict error
+	if order.CheckoutSessionID != nil {
+		if _, err := session.Expire(*order.CheckoutSessionID, nil); err != nil {
+			c.JSON(http.StatusConflict, "The checkout session can no longer be cancelled")
+			return nil
+		}
+	}
+
+	// Cancel the order and release its reserved stock in a transaction
+	// If the update is unsuccessful, then throw an error
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		return cancelOrder(tx, &order)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return nil
 	}
 
-	return c.JSON(http.StatusOK, orders)
+	return c.JSON(http.StatusOK, order)
+}
+
+/*
+Description:
+
+	Mark an order as cancelled and release its reserved stock.
+
+Parameters:
+
+	tx (*gorm.DB): The transaction to run the queries in.
+	order (*models.Order): The order to cancel.
+
+Returns:
+
+	error: Any error encountered during the queries.
+*/
+func cancelOrder(tx *gorm.DB, order *models.Order) error {
+	now := time.Now()
+	order.CanceledAt = &now
+	if err := tx.Model(order).Update("canceled_at", now).Error; err != nil {
+		return err
+	}
+
+	return inventory.Release(tx, order.ID)
+}
+
+/*
+Description:
+
+	Instantiate a new checkout session line item. The unit amount is passed in minor units,
+	which Stripe expects for every currency including zero-decimal ones such as JPY.
+
+Parameters:
+
+	name (string): The name of the item displayed on the checkout page.
+	price (int64): The unit price of the item in minor units.
+	currency (string): The lowercase ISO 4217 code of the currency.
+	quantity (int): The number of units.
+
+Returns:
+
+	*stripe.CheckoutSessionLineItemParams: The checkout session line item.
+*/
+func checkoutLineItem(name string, price int64, currency string, quantity int) *stripe.CheckoutSessionLineItemParams {
+	return &stripe.CheckoutSessionLineItemParams{
+		PriceData: &stripe.CheckoutSessionLineItemPriceDataParams{
+			Currency: stripe.String(currency),
+			ProductData: &stripe.CheckoutSessionLineItemPriceDataProductDataParams{
+				Name: stripe.String(name),
+			},
+			UnitAmount: stripe.Int64(price),
+		},
+		Quantity: stripe.Int64(int64(quantity)),
+	}
 }
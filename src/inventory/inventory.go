@@ -0,0 +1,304 @@
+package inventory
+
+import (
+	"errors"
+	"log"
+	"os"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/haseakito/ec_api/models"
+)
+
+var (
+	// ErrOutOfStock is returned when there are not enough units available to reserve.
+	ErrOutOfStock = errors.New("inventory: out of stock")
+
+	// ErrStockNotFound is returned when the product or variant of a reservation no longer exists.
+	ErrStockNotFound = errors.New("inventory: the reserved product or variant no longer exists")
+)
+
+// Bounds of the reservation TTL imposed by the expiry of Stripe checkout sessions, which must be between 30 minutes and 24 hours
+// from their creation. The minimum leaves a minute for the checkout request to reach Stripe.
+const (
+	MinReservationTTL = 31 * time.Minute
+	MaxReservationTTL = 24 * time.Hour
+)
+
+// ReservationGrace is how long reservations outlive the checkout session, so that a session never outlives the stock it holds
+const ReservationGrace = 5 * time.Minute
+
+/*
+Description:
+
+	Reserve units of a product, or of a variant when the variant id is not nil, for an order.
+	The available quantity is checked and reserved in a single conditional update, so concurrent checkouts
+	can never reserve more units than on hand. Products and variants without tracked stock are always available.
+	Must be called inside the transaction creating the order.
+
+Parameters:
+
+	tx (*gorm.DB): The transaction creating the order.
+	orderID (string): The ID of the order.
+	productID (string): The ID of the product.
+	variantID (*string): The ID of the variant. Nullable.
+	quantity (int): The number of units to reserve.
+	expiresAt (time.Time): The time the reservation expires if the order is not paid.
+
+Returns:
+
+	error: ErrOutOfStock if not enough units are available, otherwise any error encountered during the queries.
+*/
+func Reserve(tx *gorm.DB, orderID string, productID string, variantID *string, quantity int, expiresAt time.Time) error {
+	res := stockQuery(tx, productID, variantID).
+		Where("stock IS NULL OR stock - reserved >= ?", quantity).
+		UpdateColumn("reserved", gorm.Expr("reserved + ?", quantity))
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrOutOfStock
+	}
+
+	reservation := models.StockReservation{
+		OrderID:   orderID,
+		ProductID: productID,
+		VariantID: variantID,
+		Quantity:  quantity,
+		Status:    models.ReservationActive,
+		ExpiresAt: expiresAt,
+	}
+
+	return tx.Create(&reservation).Error
+}
+
+/*
+Description:
+
+	Release the active reservations of an order, making the units available again.
+	Releasing an order twice is a no-op.
+
+Parameters:
+
+	tx (*gorm.DB): The transaction to run the queries in.
+	orderID (string): The ID of the order.
+
+Returns:
+
+	error: ErrStockNotFound if a reserved product or variant no longer exists, otherwise any error encountered during the queries.
+*/
+func Release(tx *gorm.DB, orderID string) error {
+	reservations, err := lockReservations(tx, orderID, models.ReservationActive)
+	if err != nil {
+		return err
+	}
+
+	for _, reservation := range reservations {
+		if err := updateStock(tx, reservation, map[string]interface{}{
+			"reserved": gorm.Expr("reserved - ?", reservation.Quantity),
+		}, false); err != nil {
+			return err
+		}
+		if err := tx.Model(&reservation).Update("status", models.ReservationReleased).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+/*
+Description:
+
+	Commit the reservations of a paid order, removing the units from stock.
+	Reservations already released, e.g. because the payment completed after the expiry, are taken from stock directly.
+	Stock never goes negative: if the units are no longer in stock, then the reservation is marked as oversold
+	and the order is flagged as oversold instead. Committing an order twice is a no-op.
+
+Parameters:
+
+	tx (*gorm.DB): The transaction to run the queries in.
+	orderID (string): The ID of the order.
+
+Returns:
+
+	error: ErrStockNotFound if a reserved product or variant no longer exists, otherwise any error encountered during the queries.
+*/
+func Commit(tx *gorm.DB, orderID string) error {
+	reservations, err := lockReservations(tx, orderID, models.ReservationActive, models.ReservationReleased)
+	if err != nil {
+		return err
+	}
+
+	for _, reservation := range reservations {
+		columns := map[string]interface{}{
+			"stock": gorm.Expr("stock - ?", reservation.Quantity),
+		}
+		if reservation.Status == models.ReservationActive {
+			columns["reserved"] = gorm.Expr("reserved - ?", reservation.Quantity)
+		}
+
+		// If the units were sold to other orders since the reservation was released, then flag the order instead
+		status := models.ReservationCommitted
+		err := updateStock(tx, reservation, columns, true)
+		if errors.Is(err, ErrOutOfStock) {
+			status = models.ReservationOversold
+			err = oversell(tx, reservation)
+		}
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&reservation).Update("status", status).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+/*
+Description:
+
+	Release the active reservations which expired before the cutoff, and cancel their orders if they are unpaid.
+	This is a safety net for checkout sessions whose expiry webhook was never delivered.
+
+Parameters:
+
+	db (*gorm.DB): A pointer to the GORM database connection.
+	cutoff (time.Time): Reservations expired before this time are released.
+
+Returns:
+
+	error: Any error encountered during the queries.
+*/
+func ReleaseExpired(db *gorm.DB, cutoff time.Time) error {
+	var orderIDs []string
+	if err := db.Model(&models.StockReservation{}).
+		Distinct("order_id").
+		Where("status = ? AND expires_at < ?", models.ReservationActive, cutoff).
+		Pluck("order_id", &orderIDs).Error; err != nil {
+		return err
+	}
+
+	for _, orderID := range orderIDs {
+		if err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&models.Order{}).
+				Where("id = ? AND NOT paid AND canceled_at IS NULL", orderID).
+				Update("canceled_at", time.Now()).Error; err != nil {
+				return err
+			}
+			return Release(tx, orderID)
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+/*
+Description:
+
+	Periodically release the reservations which expired more than the grace period ago.
+	The grace period leaves time for the webhooks of sessions completed right before their expiry.
+
+Parameters:
+
+	db (*gorm.DB): A pointer to the GORM database connection.
+	interval (time.Duration): The time between two sweeps.
+	grace (time.Duration): The time to wait after the expiry before releasing a reservation.
+*/
+func StartSweeper(db *gorm.DB, interval time.Duration, grace time.Duration) {
+	go func() {
+		for range time.Tick(interval) {
+			if err := ReleaseExpired(db, time.Now().Add(-grace)); err != nil {
+				log.Println("inventory: failed to release expired reservations:", err)
+			}
+		}
+	}()
+}
+
+/*
+Description:
+
+	Get the time checkout reservations are held from the CHECKOUT_RESERVATION_TTL environment variable, e.g. "45m".
+	The TTL is clamped to the bounds of the expiry of Stripe checkout sessions, and defaults to MinReservationTTL.
+
+Returns:
+
+	time.Duration: The reservation TTL.
+*/
+func ReservationTTLFromEnv() time.Duration {
+	ttl, err := time.ParseDuration(os.Getenv("CHECKOUT_RESERVATION_TTL"))
+	if err != nil || ttl < MinReservationTTL {
+		return MinReservationTTL
+	}
+	if ttl > MaxReservationTTL {
+		return MaxReservationTTL
+	}
+
+	return ttl
+}
+
+// stockQuery scopes a query to the row holding the stock of the product or variant.
+func stockQuery(tx *gorm.DB, productID string, variantID *string) *gorm.DB {
+	if variantID != nil {
+		return tx.Model(&models.ProductVariant{}).Where("id = ?", *variantID)
+	}
+
+	return tx.Model(&models.Product{}).Where("id = ?", productID)
+}
+
+// updateStock updates the row holding the stock of a reservation, including the products moved to the trash since the reservation,
+// so that their reserved units never leak. When guarded, the row is only updated if the units of the reservation are in stock,
+// and ErrOutOfStock is returned otherwise.
+func updateStock(tx *gorm.DB, reservation models.StockReservation, columns map[string]interface{}, guarded bool) error {
+	query := stockQuery(tx.Unscoped(), reservation.ProductID, reservation.VariantID)
+	if guarded {
+		query = query.Where("stock IS NULL OR stock >= ?", reservation.Quantity)
+	}
+
+	res := query.UpdateColumns(columns)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		if guarded {
+			var count int64
+			if err := stockQuery(tx.Unscoped(), reservation.ProductID, reservation.VariantID).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return ErrOutOfStock
+			}
+		}
+		return ErrStockNotFound
+	}
+
+	return nil
+}
+
+// oversell flags the order of a reservation whose units are no longer in stock, releasing the units the reservation still holds.
+func oversell(tx *gorm.DB, reservation models.StockReservation) error {
+	if reservation.Status == models.ReservationActive {
+		if err := updateStock(tx, reservation, map[string]interface{}{
+			"reserved": gorm.Expr("reserved - ?", reservation.Quantity),
+		}, false); err != nil {
+			return err
+		}
+	}
+
+	return tx.Model(&models.Order{}).Where("id = ?", reservation.OrderID).Update("oversold", true).Error
+}
+
+// lockReservations gets the reservations of the order in the given statuses, locking them until the end of the transaction.
+func lockReservations(tx *gorm.DB, orderID string, statuses ...string) ([]models.StockReservation, error) {
+	var reservations []models.StockReservation
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("order_id = ? AND status IN ?", orderID, statuses).
+		Find(&reservations).Error
+
+	return reservations, err
+}
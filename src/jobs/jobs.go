@@ -0,0 +1,273 @@
+package jobs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/haseakito/ec_api/models"
+)
+
+var (
+	// ErrUnknownType is returned when no handler is registered for the type of a job.
+	ErrUnknownType = errors.New("jobs: unknown job type")
+
+	// ErrLeaseLost is returned when the lease of a job expired and another worker claimed the job.
+	ErrLeaseLost = errors.New("jobs: the job was claimed by another worker")
+)
+
+const (
+	// Lease is how long a worker holds a job without saving progress nor renewing the lease before the job is considered abandoned and resumed
+	Lease = 2 * time.Minute
+
+	// MaxAttempts is the number of times a job is started before it is failed, so that a job crashing the worker is not retried forever
+	MaxAttempts = 3
+)
+
+/*
+Description:
+
+	Handler runs a job. Handlers must resume from job.Progress, and save their progress with Checkpoint
+	in the transaction of the work done, so that a job interrupted by a restart never repeats nor skips an item.
+	Work taking longer than the lease before the next checkpoint must renew the lease with Renew first.
+
+Parameters:
+
+	db (*gorm.DB): A pointer to the GORM database connection.
+	job (*models.Job): The job to run.
+
+Returns:
+
+	error: Any error failing the job.
+*/
+type Handler func(db *gorm.DB, job *models.Job) error
+
+var (
+	mu       sync.RWMutex
+	handlers = map[string]Handler{}
+)
+
+/*
+Description:
+
+	Register the handler of a type of job.
+
+Parameters:
+
+	jobType (string): The type of the job.
+	handler (Handler): The handler running the jobs of the type.
+*/
+func Register(jobType string, handler Handler) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	handlers[jobType] = handler
+}
+
+/*
+Description:
+
+	Enqueue a job to be run by a worker.
+
+Parameters:
+
+	tx (*gorm.DB): The database connection or transaction creating the job.
+	job (*models.Job): The job to enqueue.
+
+Returns:
+
+	error: Any error encountered while creating the job.
+*/
+func Enqueue(tx *gorm.DB, job *models.Job) error {
+	job.Status = models.JobPending
+	return tx.Create(job).Error
+}
+
+/*
+Description:
+
+	Save the progress and the result so far of a running job, and extend its lease.
+	Must be called in the transaction of the work done for the items, which must be rolled back if the job was claimed by another worker.
+
+Parameters:
+
+	tx (*gorm.DB): The transaction of the work done.
+	job (*models.Job): The running job.
+	progress (int): The number of input items processed.
+	result (interface{}): The result of the job so far, encoded as JSON.
+
+Returns:
+
+	error: ErrLeaseLost if the job was claimed by another worker, otherwise any error encountered while saving the progress.
+*/
+func Checkpoint(tx *gorm.DB, job *models.Job, progress int, result interface{}) error {
+	raw, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+
+	lockedUntil := time.Now().Add(Lease)
+	if err := update(tx, job, map[string]interface{}{
+		"progress":     progress,
+		"result":       models.JSON(raw),
+		"locked_until": lockedUntil,
+	}); err != nil {
+		return err
+	}
+
+	job.Progress = progress
+	job.Result = raw
+	job.LockedUntil = &lockedUntil
+	return nil
+}
+
+/*
+Description:
+
+	Extend the lease of a running job without saving progress, before work which may take longer than the lease.
+
+Parameters:
+
+	db (*gorm.DB): A pointer to the GORM database connection.
+	job (*models.Job): The running job.
+
+Returns:
+
+	error: ErrLeaseLost if the job was claimed by another worker, otherwise any error encountered while extending the lease.
+*/
+func Renew(db *gorm.DB, job *models.Job) error {
+	lockedUntil := time.Now().Add(Lease)
+	if err := update(db, job, map[string]interface{}{"locked_until": lockedUntil}); err != nil {
+		return err
+	}
+
+	job.LockedUntil = &lockedUntil
+	return nil
+}
+
+/*
+Description:
+
+	Claim the oldest pending job, or a running job whose worker stopped renewing its lease, and run it.
+
+Parameters:
+
+	db (*gorm.DB): A pointer to the GORM database connection.
+
+Returns:
+
+	(bool, error): Whether a job was run. Otherwise, any error encountered while claiming or finishing the job.
+*/
+func RunNext(db *gorm.DB) (bool, error) {
+	job, err := claim(db)
+	if err != nil || job == nil {
+		return false, err
+	}
+
+	return true, finish(db, job, run(db, job))
+}
+
+/*
+Description:
+
+	Periodically run the pending jobs in the background, one at a time.
+
+Parameters:
+
+	db (*gorm.DB): A pointer to the GORM database connection.
+	interval (time.Duration): The time between two polls for pending jobs.
+*/
+func StartWorker(db *gorm.DB, interval time.Duration) {
+	go func() {
+		for range time.Tick(interval) {
+			for {
+				ran, err := RunNext(db)
+				if err != nil {
+					log.Println("jobs: failed to run a job:", err)
+				}
+				if !ran || err != nil {
+					break
+				}
+			}
+		}
+	}()
+}
+
+// claim marks the next runnable job as running under a new lease, skipping the jobs held by other workers
+func claim(db *gorm.DB) (*models.Job, error) {
+	now := time.Now()
+
+	var job models.Job
+	if err := db.Raw(`UPDATE jobs SET status = ?, attempts = attempts + 1, locked_until = ?, started_at = COALESCE(started_at, ?), updated_at = ?
+		WHERE id = (
+			SELECT id FROM jobs WHERE status = ? OR (status = ? AND locked_until < ?)
+			ORDER BY created_at LIMIT 1 FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`,
+		models.JobRunning, now.Add(Lease), now, now, models.JobPending, models.JobRunning, now).
+		Scan(&job).Error; err != nil {
+		return nil, err
+	}
+	if job.ID == "" {
+		return nil, nil
+	}
+
+	return &job, nil
+}
+
+// run calls the handler of the job, turning panics into errors
+func run(db *gorm.DB, job *models.Job) (err error) {
+	if job.Attempts > MaxAttempts {
+		return fmt.Errorf("jobs: gave up after %d attempts", MaxAttempts)
+	}
+
+	mu.RLock()
+	handler, ok := handlers[job.Type]
+	mu.RUnlock()
+	if !ok {
+		return ErrUnknownType
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("jobs: panic: %v", r)
+		}
+	}()
+
+	return handler(db, job)
+}
+
+// finish marks the job as succeeded, or failed with the error, unless the job was claimed by another worker in the meantime
+func finish(db *gorm.DB, job *models.Job, jobErr error) error {
+	columns := map[string]interface{}{
+		"status":       models.JobSucceeded,
+		"locked_until": nil,
+		"finished_at":  time.Now(),
+	}
+	if jobErr != nil {
+		columns["status"] = models.JobFailed
+		columns["error"] = jobErr.Error()
+	}
+
+	return update(db, job, columns)
+}
+
+// update updates the columns of a job held by the worker, which claimed the job for its current attempt
+func update(tx *gorm.DB, job *models.Job, columns map[string]interface{}) error {
+	res := tx.Model(&models.Job{}).
+		Where("id = ? AND status = ? AND attempts = ?", job.ID, models.JobRunning, job.Attempts).
+		Updates(columns)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrLeaseLost
+	}
+
+	return nil
+}
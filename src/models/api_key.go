@@ -0,0 +1,70 @@
+package models
+
+import "time"
+
+// Scopes an API key can be granted
+const (
+	ScopeCatalogRead  = "catalog:read"
+	ScopeCatalogWrite = "catalog:write"
+	ScopeOrdersRead   = "orders:read"
+	ScopeOrdersWrite  = "orders:write"
+)
+
+/*
+Description:
+
+	Represents the model for an API key of a store in the database.
+
+Fields:
+
+	Model: Embedded struct containing fields for primary key (ID), creation time (CreatedAt), and update time (UpdatedAt).
+	StoreID (string): The ID of the store which issued the key. Indexed field for efficient querying.
+	Name (string): The name of the key, e.g. the integration using it.
+	Prefix (string): The first characters of the key, used to identify the key without exposing it.
+	KeyHash (string): The SHA-256 hash of the key. Never exposed.
+	Scopes (StringArray): The scopes granted to the key.
+	CreatedBy (string): The ID of the user who created the key.
+	LastUsedAt (*time.Time): The time the key was last used. Nullable.
+	RevokedAt (*time.Time): The time the key was revoked. Nullable. Revoked keys are rejected.
+
+Relations:
+
+	Store: Belongs-to relationship to a store. Each API key belongs to a store.
+*/
+type APIKey struct {
+	Model
+
+	StoreID    string      `gorm:"index" json:"store_id"`
+	Name       string      `json:"name"`
+	Prefix     string      `json:"prefix"`
+	KeyHash    string      `gorm:"uniqueIndex" json:"-"`
+	Scopes     StringArray `gorm:"type:text[]" json:"scopes"`
+	CreatedBy  string      `json:"created_by"`
+	LastUsedAt *time.Time  `json:"last_used_at"`
+	RevokedAt  *time.Time  `json:"revoked_at"`
+}
+
+/*
+Description:
+
+	Check whether the key is granted the scope. catalog:write and orders:write imply their read scope.
+
+Parameters:
+
+	scope (string): The scope to check.
+
+Returns:
+
+	bool: true if the key is granted the scope, false otherwise.
+*/
+func (k APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope ||
+			(scope == ScopeCatalogRead && s == ScopeCatalogWrite) ||
+			(scope == ScopeOrdersRead && s == ScopeOrdersWrite) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,43 @@
+package models
+
+// Actions recorded in the audit log
+const (
+	AuditCreate  = "create"
+	AuditUpdate  = "update"
+	AuditDelete  = "delete"
+	AuditRestore = "restore"
+)
+
+/*
+Description:
+
+	Represents the model for an audit event of an admin mutation in the database.
+
+Fields:
+
+	Model: Embedded struct containing fields for primary key (ID), creation time (CreatedAt), and update time (UpdatedAt).
+	StoreID (string): The ID of the store the mutated entity belongs to. Indexed field for efficient querying.
+	ActorType (string): The type of the actor, either user, api_key or system for background tasks.
+	ActorID (string): The ID of the user, the API key or the background task which made the mutation. Indexed field for efficient querying.
+	EntityType (string): The type of the mutated entity, e.g. store, product or product_image.
+	EntityID (string): The ID of the mutated entity. Indexed field for efficient querying.
+	Action (string): The mutation, one of create, update, delete or restore.
+	Changes (JSON): The changed fields with their values before and after the mutation.
+	RequestID (string): The ID of the request which made the mutation.
+
+Relations:
+
+	Store: Belongs-to relationship to a store. Each audit event belongs to a store.
+*/
+type AuditEvent struct {
+	Model
+
+	StoreID    string `gorm:"index" json:"store_id"`
+	ActorType  string `json:"actor_type"`
+	ActorID    string `gorm:"index" json:"actor_id"`
+	EntityType string `json:"entity_type"`
+	EntityID   string `gorm:"index" json:"entity_id"`
+	Action     string `json:"action"`
+	Changes    JSON   `gorm:"type:jsonb" json:"changes"`
+	RequestID  string `json:"request_id"`
+}
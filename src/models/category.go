@@ -0,0 +1,77 @@
+package models
+
+import "sort"
+
+/*
+Description:
+
+	Represents the model for a category of the taxonomy of a store in the database. Categories form a tree.
+
+Fields:
+
+	Model: Embedded struct containing fields for primary key (ID), creation time (CreatedAt), and update time (UpdatedAt).
+	StoreID (string): The ID of the store to which the category belongs. Unique with the slug.
+	ParentID (*string): The ID of the parent category. Nullable. Root categories have no parent.
+	Name (string): The name of the category.
+	Slug (string): The URL-friendly identifier of the category. Unique per store.
+	Position (int): The display order of the category among its siblings.
+	Children ([]Category): Slice of child categories.
+
+Relations:
+
+	Store: Belongs-to relationship to stores. Each category belongs to a store.
+	Parent: Belongs-to relationship to categories. A category may have a parent category.
+	Products: Many-to-many relationship between categories and products.
+*/
+type Category struct {
+	Model
+
+	StoreID  string     `gorm:"uniqueIndex:idx_categories_store_slug" json:"store_id"`
+	ParentID *string    `gorm:"index" json:"parent_id"`
+	Name     string     `json:"name"`
+	Slug     string     `gorm:"uniqueIndex:idx_categories_store_slug" json:"slug"`
+	Position int        `json:"position"`
+	Children []Category `gorm:"foreignKey:ParentID" json:"children"`
+}
+
+/*
+Description:
+
+	Build the category trees from a flat list of categories, ordering siblings by position.
+	Categories whose parent is not in the list are treated as roots.
+
+Parameters:
+
+	categories ([]Category): The flat list of categories.
+
+Returns:
+
+	[]Category: The root categories with their descendants set in Children.
+*/
+func BuildCategoryTree(categories []Category) []Category {
+	ids := make(map[string]bool, len(categories))
+	for _, category := range categories {
+		ids[category.ID] = true
+	}
+
+	children := make(map[string][]Category)
+	var roots []Category
+	for _, category := range categories {
+		if category.ParentID != nil && ids[*category.ParentID] {
+			children[*category.ParentID] = append(children[*category.ParentID], category)
+		} else {
+			roots = append(roots, category)
+		}
+	}
+
+	var attach func(nodes []Category) []Category
+	attach = func(nodes []Category) []Category {
+		sort.SliceStable(nodes, func(i, j int) bool { return nodes[i].Position < nodes[j].Position })
+		for i := range nodes {
+			nodes[i].Children = attach(children[nodes[i].ID])
+		}
+		return nodes
+	}
+
+	return attach(roots)
+}
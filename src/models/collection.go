@@ -0,0 +1,54 @@
+package models
+
+// CollectionSortManual orders the products of a manual collection by their position in the collection
+const CollectionSortManual = "manual"
+
+/*
+Description:
+
+	Represents the model for a curated collection of products of a store in the database, such as "Summer Sale".
+	Manual collections list the products picked by the merchant. Rule-based collections list the published products
+	matching their rule, evaluated on every request.
+
+Fields:
+
+	Model: Embedded struct containing fields for primary key (ID), creation time (CreatedAt), and update time (UpdatedAt).
+	StoreID (string): The ID of the store to which the collection belongs. Unique with the slug.
+	Name (string): The name of the collection.
+	Slug (string): The URL-friendly identifier of the collection. Unique per store.
+	Description (*string): The description of the collection. Nullable.
+	Rule (*string): The rule selecting the products, e.g. "tag = sale AND price < 20". Nullable. Manual collections have no rule.
+	Sort (string): The order of the products, manual or one of the sort orders of product listings.
+
+Relations:
+
+	Store: Belongs-to relationship to stores. Each collection belongs to a store.
+	Products: Many-to-many relationship between manual collections and products through CollectionProduct.
+*/
+type Collection struct {
+	Model
+
+	StoreID     string  `gorm:"uniqueIndex:idx_collections_store_slug" json:"store_id"`
+	Name        string  `json:"name"`
+	Slug        string  `gorm:"uniqueIndex:idx_collections_store_slug" json:"slug"`
+	Description *string `json:"description"`
+	Rule        *string `json:"rule"`
+	Sort        string  `gorm:"not null;default:manual" json:"sort"`
+}
+
+/*
+Description:
+
+	Represents the model for a product picked in a manual collection in the database.
+
+Fields:
+
+	CollectionID (string): The ID of the collection.
+	ProductID (string): The ID of the product.
+	Position (int): The display order of the product in the collection.
+*/
+type CollectionProduct struct {
+	CollectionID string `gorm:"primaryKey;size:255" json:"collection_id"`
+	ProductID    string `gorm:"primaryKey;size:255;index" json:"product_id"`
+	Position     int    `json:"position"`
+}
@@ -0,0 +1,60 @@
+package models
+
+import "time"
+
+// Statuses of background jobs
+const (
+	JobPending   = "pending"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+)
+
+/*
+Description:
+
+	Represents the model for a background job of a store in the database, such as a product import.
+	Jobs save their progress as they go, so that a job interrupted by a restart resumes where it stopped.
+
+Fields:
+
+	Model: Embedded struct containing fields for primary key (ID), creation time (CreatedAt), and update time (UpdatedAt).
+	StoreID (string): The ID of the store the job works on. Indexed field for efficient querying.
+	Type (string): The type of the job, e.g. product_import.
+	Status (string): The status of the job, one of pending, running, succeeded or failed. Indexed field for efficient querying.
+	ActorType (string): The type of the actor who started the job, either user or api_key.
+	ActorID (string): The ID of the user or the API key who started the job.
+	RequestID (string): The ID of the request which started the job.
+	Params (JSON): The parameters of the job, specific to its type.
+	Input ([]byte): The input of the job, e.g. the uploaded file. Not exposed in JSON.
+	Progress (int): The number of input items processed.
+	Result (JSON): The result of the job so far, specific to its type.
+	Error (*string): The reason of the failure of the job. Nullable.
+	Attempts (int): The number of times the job was started.
+	LockedUntil (*time.Time): The time until which the job is held by a worker. Nullable. Not exposed in JSON.
+	StartedAt (*time.Time): The time the job was first started. Nullable.
+	FinishedAt (*time.Time): The time the job succeeded or failed. Nullable.
+
+Relations:
+
+	Store: Belongs-to relationship to stores. Each job belongs to a store.
+*/
+type Job struct {
+	Model
+
+	StoreID     string     `gorm:"index" json:"store_id"`
+	Type        string     `json:"type"`
+	Status      string     `gorm:"not null;default:pending;index" json:"status"`
+	ActorType   string     `json:"actor_type"`
+	ActorID     string     `json:"actor_id"`
+	RequestID   string     `json:"request_id"`
+	Params      JSON       `gorm:"type:jsonb" json:"params"`
+	Input       []byte     `json:"-"`
+	Progress    int        `gorm:"not null;default:0" json:"progress"`
+	Result      JSON       `gorm:"type:jsonb" json:"result"`
+	Error       *string    `json:"error"`
+	Attempts    int        `gorm:"not null;default:0" json:"attempts"`
+	LockedUntil *time.Time `json:"-"`
+	StartedAt   *time.Time `json:"started_at"`
+	FinishedAt  *time.Time `json:"finished_at"`
+}
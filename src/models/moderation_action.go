@@ -0,0 +1,34 @@
+package models
+
+// Actions a platform operator can take
+const (
+	ModerationSuspendStore     = "store.suspend"
+	ModerationUnsuspendStore   = "store.unsuspend"
+	ModerationUnpublishProduct = "product.unpublish"
+	ModerationUnlockProduct    = "product.unlock"
+	ModerationDeleteReview     = "review.delete"
+)
+
+/*
+Description:
+
+	Represents the model for an action taken by a platform operator in the database.
+
+Fields:
+
+	Model: Embedded struct containing fields for primary key (ID), creation time (CreatedAt), and update time (UpdatedAt).
+	ActorID (string): The ID of the platform operator who took the action. Indexed field for efficient querying.
+	Action (string): The action taken, e.g. store.suspend.
+	TargetType (string): The type of the moderated entity, e.g. store, product or review.
+	TargetID (string): The ID of the moderated entity. Indexed field for efficient querying.
+	Reason (*string): The reason given by the operator. Nullable.
+*/
+type ModerationAction struct {
+	Model
+
+	ActorID    string  `gorm:"index" json:"actor_id"`
+	Action     string  `json:"action"`
+	TargetType string  `json:"target_type"`
+	TargetID   string  `gorm:"index" json:"target_id"`
+	Reason     *string `json:"reason"`
+}
@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 /*
 Description:
 
@@ -12,6 +14,13 @@ Fields:
 	UserID (string): The ID of the user associated with the order. Indexed field for efficient querying.
 	OrderItems ([]OrderItem): Slice of order itens associated with the order.
 	Paid (bool): Indicates whether the order is paid or not.
+	Currency (string): The currency of the amounts of the order.
+	Subtotal (int64): The sum of the line totals of the order items at checkout time, in minor units.
+	Total (int64): The amount charged for the order, in minor units.
+	CheckoutSessionID (*string): The ID of the Stripe checkout session of the order. Nullable.
+	CanceledAt (*time.Time): The time the order was cancelled or its checkout session expired. Nullable.
+	FulfilledAt (*time.Time): The time the paid order was fulfilled by the store. Nullable.
+	Oversold (bool): Indicates whether the order was paid for units which were no longer in stock, so that the store must restock or refund it.
 
 Relations:
 
@@ -21,10 +30,17 @@ Relations:
 type Order struct {
 	Model
 
-	StoreID    string      `gorm:"index" json:"store_id"`
-	UserID     string      `json:"user_id"`
-	OrderItems []OrderItem `json:"order_items"`
-	Paid       bool        `json:"is_paid"`
+	StoreID           string      `gorm:"index" json:"store_id"`
+	UserID            string      `json:"user_id"`
+	OrderItems        []OrderItem `json:"order_items"`
+	Paid              bool        `json:"is_paid"`
+	Currency          string      `json:"currency"`
+	Subtotal          int64       `json:"subtotal"`
+	Total             int64       `json:"total"`
+	CheckoutSessionID *string     `gorm:"index" json:"checkout_session_id"`
+	CanceledAt        *time.Time  `json:"canceled_at"`
+	FulfilledAt       *time.Time  `json:"fulfilled_at"`
+	Oversold          bool        `gorm:"not null;default:false" json:"is_oversold"`
 }
 
 /*
@@ -36,17 +52,31 @@ Fields:
 
 	Model: Embedded struct containing fields for primary key (ID), creation time (CreatedAt), and update time (UpdatedAt).
 	ProductID (string): The ID of the product associated with the order. Indexed field for efficient querying.
+	VariantID (*string): The ID of the variant of the product associated with the order. Nullable.
+	Quantity (int): The number of units ordered.
+	UnitPrice (int64): The price of a unit at checkout time, in minor units.
+	Currency (string): The currency of the unit price.
+	ProductName (string): The name of the product at checkout time.
+	VariantLabel (*string): The label of the variant at checkout time, e.g. "M / Red". Nullable.
 	OrderID (string): The ID of the order to which the order item belongs. Indexed field for efficient querying.
 
 Relations:
 
 	Product: One-to-one relationship between an order and a product. Each order item has a product.
+	Variant: One-to-one relationship between an order item and a variant. An order item may have a variant.
 	Order: One-to-one relationship between an order and an order items. Each order item belongs to an order.
 */
 type OrderItem struct {
 	Model
 
-	ProductID string  `gorm:"index" json:"product_id"`
-	Product   Product `json:"product"`
-	OrderID   string  `gorm:"index" json:"order_id"`
+	ProductID    string          `gorm:"index" json:"product_id"`
+	Product      Product         `json:"product"`
+	VariantID    *string         `gorm:"index" json:"variant_id"`
+	Variant      *ProductVariant `json:"variant,omitempty"`
+	Quantity     int             `gorm:"not null;default:1" json:"quantity"`
+	UnitPrice    int64           `json:"unit_price"`
+	Currency     string          `json:"currency"`
+	ProductName  string          `json:"product_name"`
+	VariantLabel *string         `json:"variant_label"`
+	OrderID      string          `gorm:"index" json:"order_id"`
 }
@@ -1,5 +1,11 @@
 package models
 
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
 /*
 Description:
 
@@ -8,29 +14,60 @@ Description:
 Fields:
 
 	Model: Embedded struct containing fields for primary key (ID), creation time (CreatedAt), and update time (UpdatedAt).
+	DeletedAt (gorm.DeletedAt): The time the product was moved to the trash. Nullable. Trashed products are hidden from all queries unless unscoped.
+	ArchivedAt (*time.Time): The time the trashed product was purged while orders referenced it. Nullable. Archived products keep only the rows the orders need and cannot be restored.
 	StoreID (string): The ID of the store to which the product belongs. Indexed field for efficient querying.
+	SKU (*string): The stock keeping unit of the product. Nullable. Unique per store among the products which are not trashed.
 	Name (string): The name of the product.
 	Description (*string): The description of the product. Nullable.
-	Price (*float32): The price of the product. Nullable.
+	Price (*int64): The price of the product in minor units of the currency of the store. Nullable.
 	Published (bool): Indicates whether the product is published or not.
+	PublishAt (*time.Time): The time the product is scheduled to be published. Nullable. Cleared once applied.
+	UnpublishAt (*time.Time): The time the product is scheduled to be unpublished. Nullable. Cleared once applied.
+	Stock (*int): The number of units on hand. Nullable. Inventory is not tracked when null.
+	Reserved (int): The number of units reserved by pending checkouts.
+	LockedAt (*time.Time): The time the product was force-unpublished by a platform operator. Nullable. Locked products cannot be published by the store.
 	ProductImages ([]ProductImage): Slice of product images associated with the product.
+	Options ([]ProductOption): Slice of option types of the product, e.g. size or color.
+	Variants ([]ProductVariant): Slice of variants of the product.
+	Categories ([]Category): Slice of categories the product is assigned to.
+	Tags (StringArray): The free-form lowercase tags of the product, e.g. sale.
+	Snippet (*string): The highlighted extract of the product matching a search. Read-only. Only set on search results.
+	Draft (*ProductDraft): The edits of the product staged until they are published. Read-only. Only set on the responses of the edits.
 
 Relations:
 
 	Store: Belongs-to relationship to stores. Each product belongs to a store.
 	Reviews: One-to-many relationship between products and reviews. Each product can have multiple reviews.
 	ProductImages: One-to-many relationship between products and product images. Each product can have multiple images.
+	Options: One-to-many relationship between products and options. Each product can have multiple options.
+	Variants: One-to-many relationship between products and variants. Each product can have multiple variants.
+	Categories: Many-to-many relationship between products and categories.
 */
 type Product struct {
 	Model
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+	ArchivedAt *time.Time     `json:"archived_at"`
 
-	StoreID       string         `gorm:"index" json:"store_id"`
-	Name          string         `json:"name"`
-	Description   *string        `json:"description"`
-	Price         *float32       `json:"price"`
-	Published     bool           `json:"is_published"`
-	Reviews       []Review       `json:"reviews"`
-	ProductImages []ProductImage `json:"product_images"`
+	StoreID       string           `gorm:"index;uniqueIndex:idx_products_store_sku,where:deleted_at IS NULL" json:"store_id"`
+	SKU           *string          `gorm:"uniqueIndex:idx_products_store_sku,where:deleted_at IS NULL" json:"sku"`
+	Name          string           `json:"name"`
+	Description   *string          `json:"description"`
+	Price         *int64           `json:"price"`
+	Published     bool             `json:"is_published"`
+	PublishAt     *time.Time       `gorm:"index" json:"publish_at"`
+	UnpublishAt   *time.Time       `gorm:"index" json:"unpublish_at"`
+	Stock         *int             `json:"stock"`
+	Reserved      int              `gorm:"not null;default:0" json:"reserved"`
+	LockedAt      *time.Time       `json:"locked_at"`
+	Reviews       []Review         `json:"reviews"`
+	ProductImages []ProductImage   `json:"product_images"`
+	Options       []ProductOption  `json:"options"`
+	Variants      []ProductVariant `json:"variants"`
+	Categories    []Category       `gorm:"many2many:product_categories" json:"categories"`
+	Tags          StringArray      `gorm:"type:text[];index:,type:gin" json:"tags"`
+	Snippet       *string          `gorm:"->;-:migration" json:"snippet,omitempty"`
+	Draft         *ProductDraft    `gorm:"-" json:"draft,omitempty"`
 }
 
 /*
@@ -41,16 +78,21 @@ Description:
 Fields:
 
 	Model: Embedded struct containing fields for primary key (ID), creation time (CreatedAt), and update time (UpdatedAt).
+	DeletedAt (gorm.DeletedAt): The time the product image was moved to the trash. Nullable. Trashed images are hidden from all queries unless unscoped.
 	ProductID (string): The ID of the product to which the product image belongs. Indexed field for efficient querying.
+	VariantID (*string): The ID of the variant the image is specific to. Nullable. Indexed field for efficient querying.
 	Url (string): The URL of the product image.
 
 Relations:
 
 	Product: Belongs-to relation to product. Each product image belongs to a product.
+	Variant: Belongs-to relation to variant. A product image may be specific to a variant.
 */
 type ProductImage struct {
 	Model
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
 
-	ProductID string `gorm:"index" json:"product_id"`
-	Url       string `json:"url"`
+	ProductID string  `gorm:"index" json:"product_id"`
+	VariantID *string `gorm:"index" json:"variant_id"`
+	Url       string  `json:"url"`
 }
@@ -0,0 +1,30 @@
+package models
+
+/*
+Description:
+
+	Represents the model for the draft of a published product in the database, holding the edits staged by the store until they are published.
+	A product has at most one draft, which builds on the previous edits until it is published or discarded.
+
+Fields:
+
+	Model: Embedded struct containing fields for primary key (ID), creation time (CreatedAt), and update time (UpdatedAt).
+	ProductID (string): The ID of the product the draft belongs to. Unique field.
+	ActorType (string): The type of the actor who last edited the draft, either user or api_key.
+	ActorID (string): The ID of the user or the API key who last edited the draft.
+	RequestID (string): The ID of the request which last edited the draft.
+	Snapshot (JSON): The versioned fields of the product with the staged edits applied.
+
+Relations:
+
+	Product: Belongs-to relationship to a product. Each draft belongs to a product.
+*/
+type ProductDraft struct {
+	Model
+
+	ProductID string `gorm:"uniqueIndex" json:"product_id"`
+	ActorType string `json:"actor_type"`
+	ActorID   string `json:"actor_id"`
+	RequestID string `json:"request_id"`
+	Snapshot  JSON   `gorm:"type:jsonb" json:"snapshot"`
+}
@@ -0,0 +1,36 @@
+package models
+
+/*
+Description:
+
+	Represents the model for a revision of a product in the database, saved each time the product is edited or reverted by the store.
+	The first revision of a product is the product as it was before its first recorded edit, and has no actor.
+
+Fields:
+
+	Model: Embedded struct containing fields for primary key (ID), creation time (CreatedAt), and update time (UpdatedAt).
+	ProductID (string): The ID of the product the revision belongs to. Unique with the version.
+	Version (int): The number of the revision, starting from 1 for each product.
+	ActorType (string): The type of the actor who made the edit, either user or api_key. Empty for the first revision.
+	ActorID (string): The ID of the user or the API key who made the edit. Empty for the first revision.
+	RequestID (string): The ID of the request which made the edit.
+	RevertedFrom (*int): The version the product was reverted to, if the revision is a revert. Nullable.
+	Snapshot (JSON): The versioned fields of the product after the edit.
+	Changes (JSON): The changed fields with their values before and after the edit.
+
+Relations:
+
+	Product: Belongs-to relationship to a product. Each revision belongs to a product.
+*/
+type ProductRevision struct {
+	Model
+
+	ProductID    string `gorm:"uniqueIndex:idx_product_revisions_product_version" json:"product_id"`
+	Version      int    `gorm:"uniqueIndex:idx_product_revisions_product_version" json:"version"`
+	ActorType    string `json:"actor_type"`
+	ActorID      string `json:"actor_id"`
+	RequestID    string `json:"request_id"`
+	RevertedFrom *int   `json:"reverted_from"`
+	Snapshot     JSON   `gorm:"type:jsonb" json:"snapshot"`
+	Changes      JSON   `gorm:"type:jsonb" json:"changes"`
+}
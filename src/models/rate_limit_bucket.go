@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+/*
+Description:
+
+	Represents the model for a token bucket of the rate limiter in the database.
+	It is shared by every instance of the application when the Postgres store is used.
+
+Fields:
+
+	Key (string): The key of the bucket, made of the policy name and the client key. Primary key.
+	Tokens (float64): The number of tokens left in the bucket at UpdatedAt.
+	UpdatedAt (time.Time): The time the bucket was last refilled.
+*/
+type RateLimitBucket struct {
+	Key       string    `gorm:"primaryKey;size:255"`
+	Tokens    float64   `gorm:"not null"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime:false;not null;index"`
+}
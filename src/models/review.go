@@ -11,6 +11,7 @@ Fields:
 	ProductID (string): The ID of the product to which the review belongs. Indexed field for efficient querying.
 	UserID (string): The ID of the user associated with the store. Indexed field for efficient querying.
 	Content (string): The content of this review.
+	Rating (*int): The rating of the product from 1 to 5. Nullable for the reviews written before ratings.
 
 Relations:
 
@@ -22,4 +23,5 @@ type Review struct {
 	ProductID string `gorm:"index" json:"product_id"`
 	UserID    string `gorm:"index" json:"user_id"`
 	Content   string `json:"content"`
+	Rating    *int   `json:"rating"`
 }
@@ -0,0 +1,104 @@
+package models
+
+import "gorm.io/gorm"
+
+/*
+Description:
+
+	Scope restricting a query on stores to the stores which are not suspended.
+
+Parameters:
+
+	db (*gorm.DB): The query to restrict.
+
+Returns:
+
+	*gorm.DB: The restricted query.
+*/
+func ActiveStores(db *gorm.DB) *gorm.DB {
+	return db.Where("stores.suspended_at IS NULL")
+}
+
+/*
+Description:
+
+	Scope restricting a query on products to the products of stores which are neither suspended nor trashed.
+
+Parameters:
+
+	db (*gorm.DB): The query to restrict.
+
+Returns:
+
+	*gorm.DB: The restricted query.
+*/
+func ActiveStoreProducts(db *gorm.DB) *gorm.DB {
+	return db.Where("products.store_id IN (SELECT id FROM stores WHERE suspended_at IS NULL AND deleted_at IS NULL)")
+}
+
+/*
+Description:
+
+	Scope restricting a query on orders to the open orders: the orders which are neither cancelled nor fulfilled,
+	i.e. the orders awaiting their payment and the paid orders awaiting their fulfillment.
+
+Parameters:
+
+	db (*gorm.DB): The query to restrict.
+
+Returns:
+
+	*gorm.DB: The restricted query.
+*/
+func OpenOrders(db *gorm.DB) *gorm.DB {
+	return db.Where("orders.canceled_at IS NULL AND orders.fulfilled_at IS NULL")
+}
+
+/*
+Description:
+
+	Scope restricting a query on products to the products visible to customers: the published products and the products
+	whose scheduled publication is due, unless their scheduled unpublication is due. The schedules are compared with
+	the time of the database, so that listings respect them even when the scheduler has not applied them yet.
+
+Parameters:
+
+	db (*gorm.DB): The query to restrict.
+
+Returns:
+
+	*gorm.DB: The restricted query.
+*/
+func PublishedProducts(db *gorm.DB) *gorm.DB {
+	return db.Where("products.published OR (products.publish_at <= CURRENT_TIMESTAMP AND products.locked_at IS NULL)").
+		Where("products.unpublish_at IS NULL OR products.unpublish_at > CURRENT_TIMESTAMP")
+}
+
+/*
+Description:
+
+	Scope restricting a query on products to the products assigned to the category or any of its descendants.
+	The recursion skips the categories already visited, so that it terminates even if the tree has a cycle.
+
+Parameters:
+
+	categoryID (string): The ID of the root of the category subtree.
+
+Returns:
+
+	func(*gorm.DB) *gorm.DB: The scope restricting the query.
+*/
+func InCategoryTree(categoryID string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(`products.id IN (
+			SELECT product_categories.product_id FROM product_categories WHERE product_categories.category_id IN (
+				WITH RECURSIVE tree AS (
+					SELECT id FROM categories WHERE id = ?
+					UNION
+					SELECT categories.id FROM categories JOIN tree ON categories.parent_id = tree.id
+				)
+				SELECT id FROM tree
+			)
+		)`, categoryID)
+	}
+}
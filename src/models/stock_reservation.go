@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// Statuses of a stock reservation
+const (
+	ReservationActive    = "active"
+	ReservationCommitted = "committed"
+	ReservationReleased  = "released"
+	ReservationOversold  = "oversold"
+)
+
+/*
+Description:
+
+	Represents the model for units of a product or variant held for a pending order in the database.
+
+Fields:
+
+	Model: Embedded struct containing fields for primary key (ID), creation time (CreatedAt), and update time (UpdatedAt).
+	OrderID (string): The ID of the order holding the units. Indexed field for efficient querying.
+	ProductID (string): The ID of the reserved product.
+	VariantID (*string): The ID of the reserved variant. Nullable. The stock of the product is reserved when null.
+	Quantity (int): The number of reserved units.
+	Status (string): The status of the reservation. One of active, committed, released or oversold, when the order was paid
+		after the reservation was released and the units were no longer in stock.
+	ExpiresAt (time.Time): The time the reservation expires if the order is not paid. Indexed field for efficient querying.
+
+Relations:
+
+	Order: Belongs-to relationship to orders. Each reservation belongs to an order.
+*/
+type StockReservation struct {
+	Model
+
+	OrderID   string    `gorm:"index" json:"order_id"`
+	ProductID string    `json:"product_id"`
+	VariantID *string   `json:"variant_id"`
+	Quantity  int       `json:"quantity"`
+	Status    string    `gorm:"index" json:"status"`
+	ExpiresAt time.Time `gorm:"index" json:"expires_at"`
+}
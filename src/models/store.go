@@ -1,5 +1,11 @@
 package models
 
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
 /*
 Description:
 
@@ -8,11 +14,16 @@ Description:
 Fields:
 
 	Model: Embedded struct containing fields for primary key (ID), creation time (CreatedAt), and update time (UpdatedAt).
+	DeletedAt (gorm.DeletedAt): The time the store was moved to the trash. Nullable. Trashed stores are hidden from all queries unless unscoped.
+	ArchivedAt (*time.Time): The time the trashed store was purged while orders referenced it. Nullable. Archived stores keep only their orders and cannot be restored.
 	UserID (string): The ID of the user associated with the store. Indexed field for efficient querying.
 	Name (string): The name of the store.
 	Description (*string): The description of the store. Nullable.
 	ImageUrl (*string): The URL of the store image. Nullable.
+	Currency (string): The lowercase ISO 4217 code of the currency of the prices of the store, e.g. usd.
 	Products ([]Product): Slice of products associated with the store.
+	SuspendedAt (*time.Time): The time the store was suspended by a platform operator. Nullable. Suspended stores are hidden from the public APIs.
+	SuspensionReason (*string): The reason the store was suspended. Nullable.
 
 Relations:
 
@@ -21,11 +32,17 @@ Relations:
 */
 type Store struct {
 	Model
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+	ArchivedAt *time.Time     `json:"archived_at"`
 
 	UserID      string    `gorm:"index" json:"user_id"`
 	Name        string    `json:"name"`
 	Description *string   `json:"description"`
 	ImageUrl    *string   `json:"image_url"`
+	Currency    string    `gorm:"not null;default:usd" json:"currency"`
 	Products    []Product `json:"products"`
 	Orders      []Order   `json:"orders"`
+
+	SuspendedAt      *time.Time `gorm:"index" json:"suspended_at"`
+	SuspensionReason *string    `json:"suspension_reason"`
 }
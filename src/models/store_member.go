@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// Roles a user can hold in a store
+const (
+	RoleOwner       = "owner"
+	RoleManager     = "manager"
+	RoleFulfillment = "fulfillment"
+	RoleAnalyst     = "analyst"
+)
+
+/*
+Description:
+
+	Represents the model for a staff member of a store in the database.
+
+Fields:
+
+	Model: Embedded struct containing fields for primary key (ID), creation time (CreatedAt), and update time (UpdatedAt).
+	StoreID (string): The ID of the store to which the member belongs. Indexed field for efficient querying.
+	UserID (*string): The ID of the user who accepted the invitation. Nullable until the invitation is accepted.
+	Email (string): The email address the invitation was sent to. Unique per store.
+	Role (string): The role of the member in the store. One of owner, manager, fulfillment or analyst.
+	InviteTokenHash (string): The SHA-256 hash of the invitation token. Never exposed.
+	InvitedBy (string): The ID of the user who sent the invitation.
+	AcceptedAt (*time.Time): The time the invitation was accepted. Nullable.
+
+Relations:
+
+	Store: Belongs-to relationship to a store. Each member belongs to a store.
+*/
+type StoreMember struct {
+	Model
+
+	StoreID         string     `gorm:"index;uniqueIndex:idx_store_members_store_email" json:"store_id"`
+	UserID          *string    `gorm:"index" json:"user_id"`
+	Email           string     `gorm:"uniqueIndex:idx_store_members_store_email" json:"email"`
+	Role            string     `json:"role"`
+	InviteTokenHash string     `gorm:"index" json:"-"`
+	InvitedBy       string     `json:"invited_by"`
+	AcceptedAt      *time.Time `json:"accepted_at"`
+}
@@ -0,0 +1,208 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+/*
+Description:
+
+	StringArray maps a Go string slice to a PostgreSQL text[] column.
+*/
+type StringArray []string
+
+/*
+Description:
+
+	Encode the slice as a PostgreSQL array literal.
+
+Returns:
+
+	(driver.Value, error): The array literal, e.g. {"a","b"}.
+*/
+func (a StringArray) Value() (driver.Value, error) {
+	if a == nil {
+		return "{}", nil
+	}
+
+	quoted := make([]string, len(a))
+	for i, s := range a {
+		s = strings.ReplaceAll(s, `\`, `\\`)
+		s = strings.ReplaceAll(s, `"`, `\"`)
+		quoted[i] = `"` + s + `"`
+	}
+
+	return "{" + strings.Join(quoted, ",") + "}", nil
+}
+
+/*
+Description:
+
+	Decode a one-dimensional PostgreSQL array literal into the slice.
+
+Parameters:
+
+	src (interface{}): The value read from the database.
+
+Returns:
+
+	error: An error if the value is not a valid array literal, otherwise nil.
+*/
+func (a *StringArray) Scan(src interface{}) error {
+	var literal string
+	switch v := src.(type) {
+	case nil:
+		*a = nil
+		return nil
+	case string:
+		literal = v
+	case []byte:
+		literal = string(v)
+	default:
+		return errors.New("models: unsupported type for StringArray")
+	}
+
+	if len(literal) < 2 || literal[0] != '{' || literal[len(literal)-1] != '}' {
+		return errors.New("models: invalid array literal")
+	}
+	literal = literal[1 : len(literal)-1]
+
+	res := StringArray{}
+	if literal == "" {
+		*a = res
+		return nil
+	}
+
+	var (
+		elem    strings.Builder
+		quoted  bool
+		escaped bool
+		inQuote bool
+	)
+
+	// Append the current element to the result
+	flush := func() error {
+		if !quoted && elem.String() == "NULL" {
+			return errors.New("models: NULL elements are not supported in StringArray")
+		}
+		res = append(res, elem.String())
+		elem.Reset()
+		quoted = false
+		return nil
+	}
+
+	for _, r := range literal {
+		switch {
+		case escaped:
+			elem.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '"':
+			inQuote = !inQuote
+			quoted = true
+		case r == ',' && !inQuote:
+			if err := flush(); err != nil {
+				return err
+			}
+		default:
+			elem.WriteRune(r)
+		}
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+
+	*a = res
+	return nil
+}
+
+/*
+Description:
+
+	JSON maps raw JSON to a PostgreSQL jsonb column.
+*/
+type JSON json.RawMessage
+
+/*
+Description:
+
+	Encode the raw JSON for the database.
+
+Returns:
+
+	(driver.Value, error): The JSON document, or nil if empty.
+*/
+func (j JSON) Value() (driver.Value, error) {
+	if len(j) == 0 {
+		return nil, nil
+	}
+
+	return string(j), nil
+}
+
+/*
+Description:
+
+	Decode the JSON document read from the database.
+
+Parameters:
+
+	src (interface{}): The value read from the database.
+
+Returns:
+
+	error: An error if the value is not a JSON document, otherwise nil.
+*/
+func (j *JSON) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*j = nil
+	case string:
+		*j = JSON(v)
+	case []byte:
+		*j = append(JSON(nil), v...)
+	default:
+		return errors.New("models: unsupported type for JSON")
+	}
+
+	return nil
+}
+
+/*
+Description:
+
+	Marshal the raw JSON as is, or null if empty.
+
+Returns:
+
+	([]byte, error): The JSON document.
+*/
+func (j JSON) MarshalJSON() ([]byte, error) {
+	if len(j) == 0 {
+		return []byte("null"), nil
+	}
+
+	return j, nil
+}
+
+/*
+Description:
+
+	Unmarshal keeps a copy of the raw JSON document.
+
+Parameters:
+
+	data ([]byte): The JSON document.
+
+Returns:
+
+	error: Always nil.
+*/
+func (j *JSON) UnmarshalJSON(data []byte) error {
+	*j = append((*j)[0:0], data...)
+	return nil
+}
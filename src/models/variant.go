@@ -0,0 +1,108 @@
+package models
+
+import "strings"
+
+/*
+Description:
+
+	Represents the model for an option type of a product in the database, e.g. size or color.
+
+Fields:
+
+	Model: Embedded struct containing fields for primary key (ID), creation time (CreatedAt), and update time (UpdatedAt).
+	ProductID (string): The ID of the product to which the option belongs. Indexed field for efficient querying.
+	Name (string): The name of the option, e.g. Size.
+	Position (int): The display order of the option.
+	Values ([]ProductOptionValue): Slice of values of the option.
+
+Relations:
+
+	Product: Belongs-to relationship to products. Each option belongs to a product.
+	Values: One-to-many relationship between options and option values. Each option can have multiple values.
+*/
+type ProductOption struct {
+	Model
+
+	ProductID string               `gorm:"index" json:"product_id"`
+	Name      string               `json:"name"`
+	Position  int                  `json:"position"`
+	Values    []ProductOptionValue `gorm:"foreignKey:OptionID" json:"values"`
+}
+
+/*
+Description:
+
+	Represents the model for a value of a product option in the database, e.g. M or Red.
+
+Fields:
+
+	Model: Embedded struct containing fields for primary key (ID), creation time (CreatedAt), and update time (UpdatedAt).
+	OptionID (string): The ID of the option to which the value belongs. Indexed field for efficient querying.
+	Value (string): The value, e.g. M.
+	Position (int): The display order of the value within the option.
+
+Relations:
+
+	Option: Belongs-to relationship to product options. Each value belongs to an option.
+*/
+type ProductOptionValue struct {
+	Model
+
+	OptionID string `gorm:"index" json:"option_id"`
+	Value    string `json:"value"`
+	Position int    `json:"position"`
+}
+
+/*
+Description:
+
+	Represents the model for a variant of a product in the database, i.e. a combination of option values sold as its own SKU.
+
+Fields:
+
+	Model: Embedded struct containing fields for primary key (ID), creation time (CreatedAt), and update time (UpdatedAt).
+	StoreID (string): The ID of the store of the product, which scopes the uniqueness of the SKU.
+	ProductID (string): The ID of the product to which the variant belongs. Indexed field for efficient querying.
+	SKU (*string): The stock keeping unit of the variant. Nullable. Unique per store when set.
+	Price (*int64): The price of the variant in minor units of the currency of the store, overriding the price of the product. Nullable.
+	Stock (*int): The number of units on hand. Nullable. Inventory is not tracked when null.
+	Reserved (int): The number of units reserved by pending checkouts.
+	OptionValues ([]ProductOptionValue): Slice of option values defining the variant.
+	Images ([]ProductImage): Slice of images specific to the variant.
+
+Relations:
+
+	Product: Belongs-to relationship to products. Each variant belongs to a product.
+	OptionValues: Many-to-many relationship between variants and option values.
+	Images: One-to-many relationship between variants and product images. Each variant can have multiple images.
+*/
+type ProductVariant struct {
+	Model
+
+	StoreID      string               `gorm:"uniqueIndex:idx_product_variants_store_sku" json:"store_id"`
+	ProductID    string               `gorm:"index" json:"product_id"`
+	SKU          *string              `gorm:"uniqueIndex:idx_product_variants_store_sku" json:"sku"`
+	Price        *int64               `json:"price"`
+	Stock        *int                 `json:"stock"`
+	Reserved     int                  `gorm:"not null;default:0" json:"reserved"`
+	OptionValues []ProductOptionValue `gorm:"many2many:variant_option_values" json:"option_values"`
+	Images       []ProductImage       `gorm:"foreignKey:VariantID" json:"images"`
+}
+
+/*
+Description:
+
+	Build the label of the variant from its option values, e.g. "M / Red".
+
+Returns:
+
+	string: The label of the variant.
+*/
+func (v ProductVariant) Label() string {
+	values := make([]string, len(v.OptionValues))
+	for i, value := range v.OptionValues {
+		values[i] = value.Value
+	}
+
+	return strings.Join(values, " / ")
+}
@@ -0,0 +1,196 @@
+package money
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is the currency of stores which did not choose one.
+const DefaultCurrency = "usd"
+
+// ErrInvalidAmount is returned when a decimal amount cannot be represented in minor units of the currency.
+var ErrInvalidAmount = errors.New("money: invalid amount")
+
+// exponents maps the supported ISO 4217 currencies, in lowercase as used by Stripe, to the number of digits of their minor unit.
+var exponents = map[string]int{
+	"aud": 2, "brl": 2, "cad": 2, "chf": 2, "cny": 2, "czk": 2, "dkk": 2, "eur": 2, "gbp": 2, "hkd": 2,
+	"inr": 2, "mxn": 2, "nok": 2, "nzd": 2, "pln": 2, "sek": 2, "sgd": 2, "usd": 2, "zar": 2,
+	// Zero-decimal currencies
+	"clp": 0, "jpy": 0, "krw": 0, "vnd": 0,
+	// Three-decimal currencies
+	"bhd": 3, "jod": 3, "kwd": 3, "omr": 3, "tnd": 3,
+}
+
+/*
+Description:
+
+	Money is an amount in the minor unit of its currency, e.g. 1999 USD is $19.99 and 1999 JPY is ¥1999.
+
+Fields:
+
+	Amount (int64): The amount in minor units.
+	Currency (string): The lowercase ISO 4217 code of the currency.
+*/
+type Money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+/*
+Description:
+
+	Format the amount as a decimal string in the major unit of the currency, e.g. "19.99".
+
+Returns:
+
+	string: The formatted amount.
+*/
+func (m Money) String() string {
+	return Format(m.Amount, m.Currency)
+}
+
+/*
+Description:
+
+	Report whether the currency is supported.
+
+Parameters:
+
+	currency (string): The ISO 4217 code of the currency. Case-insensitive.
+
+Returns:
+
+	bool: True if the currency is supported.
+*/
+func IsSupported(currency string) bool {
+	_, ok := exponents[strings.ToLower(currency)]
+	return ok
+}
+
+/*
+Description:
+
+	Get the codes of all supported currencies.
+
+Returns:
+
+	[]interface{}: The lowercase ISO 4217 codes, usable with validation.In.
+*/
+func Currencies() []interface{} {
+	currencies := make([]interface{}, 0, len(exponents))
+	for currency := range exponents {
+		currencies = append(currencies, currency)
+	}
+
+	return currencies
+}
+
+/*
+Description:
+
+	Get the number of digits of the minor unit of the currency, e.g. 2 for USD and 0 for JPY.
+	Unknown currencies are assumed to have 2 digits.
+
+Parameters:
+
+	currency (string): The ISO 4217 code of the currency. Case-insensitive.
+
+Returns:
+
+	int: The number of digits of the minor unit.
+*/
+func Exponent(currency string) int {
+	if exponent, ok := exponents[strings.ToLower(currency)]; ok {
+		return exponent
+	}
+
+	return 2
+}
+
+/*
+Description:
+
+	Parse a decimal string in the major unit of the currency into minor units without floating point arithmetic,
+	e.g. "19.99" USD is 1999 and "1999" JPY is 1999. Amounts with more decimals than the currency allows are rejected.
+
+Parameters:
+
+	s (string): The decimal amount.
+	currency (string): The ISO 4217 code of the currency. Case-insensitive.
+
+Returns:
+
+	(int64, error): The amount in minor units. Otherwise, ErrInvalidAmount.
+*/
+func Parse(s string, currency string) (int64, error) {
+	s = strings.TrimSpace(s)
+	exponent := Exponent(currency)
+
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+
+	whole, fraction, _ := strings.Cut(s, ".")
+	if whole == "" && fraction == "" {
+		return 0, ErrInvalidAmount
+	}
+	if len(fraction) > exponent {
+		// Trailing zeros do not change the amount
+		if strings.TrimRight(fraction[exponent:], "0") != "" {
+			return 0, ErrInvalidAmount
+		}
+		fraction = fraction[:exponent]
+	}
+	fraction += strings.Repeat("0", exponent-len(fraction))
+
+	digits := whole + fraction
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return 0, ErrInvalidAmount
+		}
+	}
+
+	amount, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return 0, ErrInvalidAmount
+	}
+	if negative {
+		amount = -amount
+	}
+
+	return amount, nil
+}
+
+/*
+Description:
+
+	Format an amount in minor units as a decimal string in the major unit of the currency, e.g. 1999 USD is "19.99".
+
+Parameters:
+
+	amount (int64): The amount in minor units.
+	currency (string): The ISO 4217 code of the currency. Case-insensitive.
+
+Returns:
+
+	string: The formatted amount.
+*/
+func Format(amount int64, currency string) string {
+	exponent := Exponent(currency)
+
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	digits := strconv.FormatInt(amount, 10)
+	if exponent == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exponent {
+		digits = strings.Repeat("0", exponent-len(digits)+1) + digits
+	}
+
+	return sign + digits[:len(digits)-exponent] + "." + digits[len(digits)-exponent:]
+}
@@ -0,0 +1,327 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+
+	"github.com/haseakito/ec_api/models"
+)
+
+// Bounds of the number of items per page
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+// ErrInvalidCursor is returned when the cursor of a request was not issued by the same listing.
+var ErrInvalidCursor = errors.New("pagination: invalid cursor")
+
+// ErrInvalidLimit is returned when the limit of a request is out of bounds.
+var ErrInvalidLimit = errors.New("pagination: limit must be between 1 and 100")
+
+/*
+Description:
+
+	Cursor is the position after the last item of a page. It is opaque to clients.
+	Listings ordered by creation time are paged by keyset on (created_at, id), so that items created or deleted
+	between requests never shift the pages. Listings ordered by computed values, such as relevance or sales,
+	are paged by offset since the values themselves change between requests.
+
+Fields:
+
+	CreatedAt (time.Time): The creation time of the last item. Keyset cursors only.
+	ID (string): The ID of the last item. Keyset cursors only.
+	Offset (int): The number of items before the next page. Offset cursors only.
+*/
+type Cursor struct {
+	CreatedAt time.Time `json:"c,omitempty"`
+	ID        string    `json:"i,omitempty"`
+	Offset    int       `json:"o,omitempty"`
+}
+
+/*
+Description:
+
+	Encode the cursor into an opaque URL-safe string.
+
+Returns:
+
+	string: The encoded cursor.
+*/
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+/*
+Description:
+
+	Decode a cursor encoded with Cursor.Encode.
+
+Parameters:
+
+	s (string): The encoded cursor.
+
+Returns:
+
+	(*Cursor, error): The cursor. Otherwise, ErrInvalidCursor.
+*/
+func Decode(s string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &cursor, nil
+}
+
+/*
+Description:
+
+	Params holds the pagination of a request.
+
+Fields:
+
+	Limit (int): The number of items per page.
+	Cursor (*Cursor): The position after the previous page. Nullable for the first page.
+*/
+type Params struct {
+	Limit  int
+	Cursor *Cursor
+}
+
+/*
+Description:
+
+	Page is the envelope of every list response.
+
+Fields:
+
+	Data ([]T): The items of the page.
+	NextCursor (*string): The cursor of the next page, passed back in the `cursor` query parameter. Null on the last page.
+	HasMore (bool): Indicates whether there is a next page.
+*/
+type Page[T any] struct {
+	Data       []T     `json:"data"`
+	NextCursor *string `json:"next_cursor"`
+	HasMore    bool    `json:"has_more"`
+}
+
+/*
+Description:
+
+	Get the pagination of a listing paged by keyset from the `limit` and `cursor` query parameters.
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	(Params, error): The pagination. Otherwise, ErrInvalidLimit or ErrInvalidCursor.
+*/
+func ParseKeyset(c echo.Context) (Params, error) {
+	p, err := parse(c)
+	if err != nil {
+		return p, err
+	}
+	if p.Cursor != nil && (p.Cursor.ID == "" || p.Cursor.Offset != 0) {
+		return p, ErrInvalidCursor
+	}
+
+	return p, nil
+}
+
+/*
+Description:
+
+	Get the pagination of a listing paged by offset from the `limit` and `cursor` query parameters.
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	(Params, error): The pagination. Otherwise, ErrInvalidLimit or ErrInvalidCursor.
+*/
+func ParseOffset(c echo.Context) (Params, error) {
+	p, err := parse(c)
+	if err != nil {
+		return p, err
+	}
+	if p.Cursor != nil && (p.Cursor.ID != "" || p.Cursor.Offset <= 0) {
+		return p, ErrInvalidCursor
+	}
+
+	return p, nil
+}
+
+/*
+Description:
+
+	Scope paging a query newest first by keyset on the creation time and the id of the table.
+
+Parameters:
+
+	table (string): The table of the listed items.
+
+Returns:
+
+	func(*gorm.DB) *gorm.DB: The scope paging the query.
+*/
+func (p Params) Newest(table string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if p.Cursor != nil {
+			db = db.Where("("+table+".created_at, "+table+".id) < (?, ?)", p.Cursor.CreatedAt, p.Cursor.ID)
+		}
+		return db.Order(table + ".created_at DESC").Order(table + ".id DESC").Limit(p.Limit + 1)
+	}
+}
+
+/*
+Description:
+
+	Scope paging a query oldest first by keyset on the creation time and the id of the table.
+
+Parameters:
+
+	table (string): The table of the listed items.
+
+Returns:
+
+	func(*gorm.DB) *gorm.DB: The scope paging the query.
+*/
+func (p Params) Oldest(table string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if p.Cursor != nil {
+			db = db.Where("("+table+".created_at, "+table+".id) > (?, ?)", p.Cursor.CreatedAt, p.Cursor.ID)
+		}
+		return db.Order(table + ".created_at ASC").Order(table + ".id ASC").Limit(p.Limit + 1)
+	}
+}
+
+/*
+Description:
+
+	Scope paging an ordered query by offset. The query must have a stable order.
+
+Parameters:
+
+	db (*gorm.DB): The query to page.
+
+Returns:
+
+	*gorm.DB: The paged query.
+*/
+func (p Params) Offset(db *gorm.DB) *gorm.DB {
+	return db.Offset(p.offset()).Limit(p.Limit + 1)
+}
+
+/*
+Description:
+
+	Build the page of a query paged with Newest or Oldest. The query fetches one item more than the limit
+	to tell whether there is a next page.
+
+Parameters:
+
+	items ([]T): The items fetched by the query.
+	p (Params): The pagination of the request.
+	key (func(T) models.Model): Returns the model of an item, holding its creation time and id.
+
+Returns:
+
+	Page[T]: The page.
+*/
+func NewKeysetPage[T any](items []T, p Params, key func(T) models.Model) Page[T] {
+	page := newPage(items, p)
+	if page.HasMore {
+		last := key(page.Data[len(page.Data)-1])
+		cursor := Cursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
+		page.NextCursor = &cursor
+	}
+
+	return page
+}
+
+/*
+Description:
+
+	Build the page of a query paged with Offset.
+
+Parameters:
+
+	items ([]T): The items fetched by the query.
+	p (Params): The pagination of the request.
+
+Returns:
+
+	Page[T]: The page.
+*/
+func NewOffsetPage[T any](items []T, p Params) Page[T] {
+	page := newPage(items, p)
+	if page.HasMore {
+		cursor := Cursor{Offset: p.offset() + p.Limit}.Encode()
+		page.NextCursor = &cursor
+	}
+
+	return page
+}
+
+// parse reads the limit and the cursor from the query parameters.
+func parse(c echo.Context) (Params, error) {
+	p := Params{Limit: DefaultLimit}
+
+	if s := c.QueryParam("limit"); s != "" {
+		limit, err := strconv.Atoi(s)
+		if err != nil || limit < 1 || limit > MaxLimit {
+			return p, ErrInvalidLimit
+		}
+		p.Limit = limit
+	}
+
+	if s := c.QueryParam("cursor"); s != "" {
+		cursor, err := Decode(s)
+		if err != nil {
+			return p, err
+		}
+		p.Cursor = cursor
+	}
+
+	return p, nil
+}
+
+// offset is the number of items before the page.
+func (p Params) offset() int {
+	if p.Cursor == nil {
+		return 0
+	}
+
+	return p.Cursor.Offset
+}
+
+// newPage trims the extra item fetched to detect the next page.
+func newPage[T any](items []T, p Params) Page[T] {
+	if items == nil {
+		items = []T{}
+	}
+
+	hasMore := len(items) > p.Limit
+	if hasMore {
+		items = items[:p.Limit]
+	}
+
+	return Page[T]{Data: items, HasMore: hasMore}
+}
@@ -0,0 +1,84 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Number of takes between two sweeps of the idle buckets
+const sweepInterval = 10000
+
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+	policy    Policy
+}
+
+/*
+Description:
+
+	MemoryStore keeps the token buckets in memory. It is only suitable for single instance deployments.
+*/
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	takes   int
+}
+
+/*
+Description:
+
+	Instantiates a new MemoryStore.
+
+Returns:
+
+	*MemoryStore: A pointer to the newly created MemoryStore instance.
+*/
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: map[string]*bucket{},
+	}
+}
+
+/*
+Description:
+
+	Take a token from the bucket with the key.
+
+Parameters:
+
+	key (string): The key of the bucket.
+	policy (Policy): The policy of the bucket.
+	now (time.Time): The current time.
+
+Returns:
+
+	(Result, error): The outcome. The error is always nil.
+*/
+func (s *MemoryStore) Take(key string, policy Policy, now time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// New buckets start full
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(policy.Burst), updatedAt: now, policy: policy}
+		s.buckets[key] = b
+	}
+
+	var res Result
+	b.tokens, res = take(b.tokens, b.updatedAt, now, policy)
+	b.updatedAt = now
+
+	// Periodically drop the buckets which are full again, they are equivalent to new ones
+	s.takes++
+	if s.takes%sweepInterval == 0 {
+		for k, v := range s.buckets {
+			if now.Sub(v.updatedAt).Seconds()*v.policy.rate()+v.tokens >= float64(v.policy.Burst) {
+				delete(s.buckets, k)
+			}
+		}
+	}
+
+	return res, nil
+}
@@ -0,0 +1,148 @@
+package ratelimit
+
+import (
+	"errors"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/haseakito/ec_api/auth"
+)
+
+/*
+Description:
+
+	KeyFunc returns the key identifying the client of a request.
+*/
+type KeyFunc func(c echo.Context) string
+
+/*
+Description:
+
+	Identify the client by its API key, its authenticated user, or its IP address, in that order.
+	The middleware using it must be installed after the auth middleware of the route.
+	The IP address is the one given by the IP extractor of the Echo instance, see IPExtractorFromEnv.
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	string: The key of the client.
+*/
+func KeyByActor(c echo.Context) string {
+	if actorType, actorID := auth.CurrentActor(c); actorID != "" {
+		return actorType + ":" + actorID
+	}
+
+	return KeyByIP(c)
+}
+
+/*
+Description:
+
+	Identify the client by its IP address only, for the limiters installed before the auth middleware of the route,
+	so that requests are limited before their credentials are checked. The IP address is the one given by the IP extractor
+	of the Echo instance, see IPExtractorFromEnv.
+
+Parameters:
+
+	c (echo.Context): Context object containing the HTTP request information.
+
+Returns:
+
+	string: The key of the client.
+*/
+func KeyByIP(c echo.Context) string {
+	return "ip:" + c.RealIP()
+}
+
+/*
+Description:
+
+	Instantiates the IP extractor of the Echo instance based on the TRUSTED_PROXIES environment variable, a comma-separated list
+	of the IP ranges of the reverse proxies in front of the API, e.g. "172.28.0.10/32". The client IP is read from the X-Forwarded-For
+	header only when the request comes from a trusted proxy. Without trusted proxies, the IP of the peer is used and the headers
+	are ignored, so that clients cannot pick their IP to escape the rate limits.
+
+Returns:
+
+	(echo.IPExtractor, error): The IP extractor. Otherwise, an error if a range is not in CIDR notation.
+*/
+func IPExtractorFromEnv() (echo.IPExtractor, error) {
+	value := strings.TrimSpace(os.Getenv("TRUSTED_PROXIES"))
+	if value == "" {
+		return echo.ExtractIPDirect(), nil
+	}
+
+	options := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+	for _, cidr := range strings.Split(value, ",") {
+		_, ipNet, err := net.ParseCIDR(strings.TrimSpace(cidr))
+		if err != nil {
+			return nil, errors.New("ratelimit: invalid TRUSTED_PROXIES range " + cidr)
+		}
+		options = append(options, echo.TrustIPRange(ipNet))
+	}
+
+	return echo.ExtractIPFromXFFHeader(options...), nil
+}
+
+/*
+Description:
+
+	Middleware is used to rate limit incoming requests with a token bucket per client and policy.
+	It sets the X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset headers on every response,
+	and rejects the request with 429 Too Many Requests and a Retry-After header when the bucket is empty.
+	Requests are allowed if the store fails, so that an outage of the store does not take the API down.
+
+Parameters:
+
+	store (Store): The store keeping the token buckets.
+	policy (Policy): The policy of the buckets.
+	key (KeyFunc): The function used to identify the client.
+
+Returns:
+
+	echo.MiddlewareFunc: An Echo middleware function that performs rate limiting for incoming requests.
+*/
+func Middleware(store Store, policy Policy, key KeyFunc) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			// Take a token from the bucket of the client
+			// If the store failed, then allow the request
+			res, err := store.Take(policy.Name+":"+key(c), policy, time.Now())
+			if err != nil {
+				log.Println("ratelimit: failed to take a token:", err)
+				return next(c)
+			}
+
+			// Set the rate limit headers
+			header := c.Response().Header()
+			header.Set("X-RateLimit-Limit", strconv.Itoa(policy.Burst))
+			header.Set("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			header.Set("X-RateLimit-Reset", strconv.Itoa(seconds(res.ResetAfter)))
+
+			// If the bucket is empty, then throw a too many requests error
+			if !res.Allowed {
+				header.Set("Retry-After", strconv.Itoa(seconds(res.RetryAfter)))
+				return c.JSON(http.StatusTooManyRequests, "Too many requests")
+			}
+
+			// Call the next handler in the middleware chain
+			return next(c)
+		}
+	}
+}
+
+// Round the duration up to whole seconds
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,98 @@
+package ratelimit
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/haseakito/ec_api/models"
+)
+
+/*
+Description:
+
+	PostgresStore keeps the token buckets in the rate_limit_buckets table so that they are shared by every instance.
+*/
+type PostgresStore struct {
+	db *gorm.DB
+}
+
+/*
+Description:
+
+	Instantiates a new PostgresStore with the provided database connection.
+
+Parameters:
+
+	db (*gorm.DB): A pointer to the GORM database connection.
+
+Returns:
+
+	*PostgresStore: A pointer to the newly created PostgresStore instance.
+*/
+func NewPostgresStore(db *gorm.DB) *PostgresStore {
+	return &PostgresStore{
+		db: db,
+	}
+}
+
+/*
+Description:
+
+	Take a token from the bucket with the key. The bucket row is locked for the duration of the transaction.
+
+Parameters:
+
+	key (string): The key of the bucket.
+	policy (Policy): The policy of the bucket.
+	now (time.Time): The current time.
+
+Returns:
+
+	(Result, error): The outcome. Otherwise, any error encountered during the transaction.
+*/
+func (s *PostgresStore) Take(key string, policy Policy, now time.Time) (Result, error) {
+	var res Result
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// New buckets start full
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.RateLimitBucket{
+			Key:       key,
+			Tokens:    float64(policy.Burst),
+			UpdatedAt: now,
+		}).Error; err != nil {
+			return err
+		}
+
+		// Lock the bucket
+		var b models.RateLimitBucket
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Take(&b, "key = ?", key).Error; err != nil {
+			return err
+		}
+
+		// Take a token and save the bucket
+		b.Tokens, res = take(b.Tokens, b.UpdatedAt, now, policy)
+		b.UpdatedAt = now
+
+		return tx.Save(&b).Error
+	})
+
+	return res, err
+}
+
+/*
+Description:
+
+	Delete the buckets which have not been used for the given duration. They are equivalent to new ones once full.
+
+Parameters:
+
+	idle (time.Duration): The duration after which a bucket is considered idle.
+
+Returns:
+
+	error: Any error encountered during the deletion.
+*/
+func (s *PostgresStore) Purge(idle time.Duration) error {
+	return s.db.Where("updated_at < ?", time.Now().Add(-idle)).Delete(&models.RateLimitBucket{}).Error
+}
@@ -0,0 +1,221 @@
+package ratelimit
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+/*
+Description:
+
+	Policy describes a token bucket. The bucket holds at most Burst tokens and is refilled with Requests tokens every Period.
+	Each request takes one token, and requests are rejected while the bucket is empty.
+
+Fields:
+
+	Name (string): The name of the policy. Buckets of different policies are independent.
+	Requests (int): The number of tokens added every Period.
+	Period (time.Duration): The refill period.
+	Burst (int): The capacity of the bucket.
+*/
+type Policy struct {
+	Name     string
+	Requests int
+	Period   time.Duration
+	Burst    int
+}
+
+/*
+Description:
+
+	Result is the outcome of taking a token from a bucket.
+
+Fields:
+
+	Allowed (bool): Indicates whether the request is allowed.
+	Remaining (int): The number of whole tokens left in the bucket.
+	RetryAfter (time.Duration): The time until the next token is available. Zero when allowed.
+	ResetAfter (time.Duration): The time until the bucket is full again.
+*/
+type Result struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration
+	ResetAfter time.Duration
+}
+
+/*
+Description:
+
+	Store keeps the token buckets of the rate limiter.
+*/
+type Store interface {
+	Take(key string, policy Policy, now time.Time) (Result, error)
+}
+
+// Refill rate of the policy in tokens per second
+func (p Policy) rate() float64 {
+	return float64(p.Requests) / p.Period.Seconds()
+}
+
+/*
+Description:
+
+	Refill the bucket for the time elapsed since it was last updated and take one token if available.
+
+Parameters:
+
+	tokens (float64): The number of tokens in the bucket at updatedAt.
+	updatedAt (time.Time): The time the bucket was last updated.
+	now (time.Time): The current time.
+	policy (Policy): The policy of the bucket.
+
+Returns:
+
+	(float64, Result): The number of tokens left in the bucket at now, and the outcome.
+*/
+func take(tokens float64, updatedAt, now time.Time, policy Policy) (float64, Result) {
+	rate := policy.rate()
+	burst := float64(policy.Burst)
+
+	// Refill the bucket
+	if elapsed := now.Sub(updatedAt).Seconds(); elapsed > 0 {
+		tokens = math.Min(burst, tokens+elapsed*rate)
+	}
+
+	var res Result
+	if tokens >= 1 {
+		tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = time.Duration((1 - tokens) / rate * float64(time.Second))
+	}
+
+	res.Remaining = int(math.Floor(tokens))
+	res.ResetAfter = time.Duration((burst - tokens) / rate * float64(time.Second))
+
+	return tokens, res
+}
+
+/*
+Description:
+
+	Build a policy from the environment variable RATE_LIMIT_<NAME>, falling back to the default policy.
+	The variable has the form "<requests>/<period>[,<burst>]" where period is s, m or h, e.g. "10/m,5".
+
+Parameters:
+
+	def (Policy): The default policy. Its name is used to build the variable name.
+
+Returns:
+
+	(Policy, error): The configured policy. Otherwise, an error if the variable is malformed.
+*/
+func PolicyFromEnv(def Policy) (Policy, error) {
+	value := os.Getenv("RATE_LIMIT_" + strings.ToUpper(def.Name))
+	if value == "" {
+		return def, nil
+	}
+
+	return ParsePolicy(def.Name, value)
+}
+
+/*
+Description:
+
+	Parse a policy of the form "<requests>/<period>[,<burst>]" where period is s, m or h.
+	The burst defaults to the number of requests.
+
+Parameters:
+
+	name (string): The name of the policy.
+	value (string): The policy definition.
+
+Returns:
+
+	(Policy, error): The parsed policy. Otherwise, an error if the definition is malformed.
+*/
+func ParsePolicy(name, value string) (Policy, error) {
+	policy := Policy{Name: name}
+
+	rate, burst, hasBurst := strings.Cut(value, ",")
+	requests, period, ok := strings.Cut(rate, "/")
+	if !ok {
+		return policy, fmt.Errorf("ratelimit: invalid policy %q", value)
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(requests))
+	if err != nil || n <= 0 {
+		return policy, fmt.Errorf("ratelimit: invalid request count in policy %q", value)
+	}
+	policy.Requests = n
+	policy.Burst = n
+
+	switch strings.TrimSpace(period) {
+	case "s":
+		policy.Period = time.Second
+	case "m":
+		policy.Period = time.Minute
+	case "h":
+		policy.Period = time.Hour
+	default:
+		return policy, fmt.Errorf("ratelimit: invalid period in policy %q", value)
+	}
+
+	if hasBurst {
+		b, err := strconv.Atoi(strings.TrimSpace(burst))
+		if err != nil || b <= 0 {
+			return policy, fmt.Errorf("ratelimit: invalid burst in policy %q", value)
+		}
+		policy.Burst = b
+	}
+
+	return policy, nil
+}
+
+/*
+Description:
+
+	Instantiates a new Store based on the RATE_LIMIT_STORE environment variable.
+	"memory" (default) keeps the buckets in memory, "postgres" shares them between instances through the database
+	and purges the idle buckets every hour.
+
+Parameters:
+
+	db (*gorm.DB): A pointer to the GORM database connection.
+
+Returns:
+
+	(Store, error): The configured Store. Otherwise, an error if the store is unknown.
+*/
+func NewStoreFromEnv(db *gorm.DB) (Store, error) {
+	switch os.Getenv("RATE_LIMIT_STORE") {
+	case "", "memory":
+		return NewMemoryStore(), nil
+
+	case "postgres":
+		store := NewPostgresStore(db)
+
+		// Purge the idle buckets in the background
+		go func() {
+			for range time.Tick(time.Hour) {
+				if err := store.Purge(24 * time.Hour); err != nil {
+					log.Println("ratelimit: failed to purge buckets:", err)
+				}
+			}
+		}()
+
+		return store, nil
+
+	default:
+		return nil, errors.New("ratelimit: unknown RATE_LIMIT_STORE " + os.Getenv("RATE_LIMIT_STORE"))
+	}
+}
@@ -0,0 +1,36 @@
+package requests
+
+import (
+	validation "github.com/go-ozzo/ozzo-validation"
+
+	"github.com/haseakito/ec_api/models"
+)
+
+type APIKeyCreateRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+/*
+Description:
+
+	Perform validation on the APIKeyCreateRequest struct fields.
+
+Returns:
+
+	error: An error if any validation fails, otherwise nil.
+*/
+func (r APIKeyCreateRequest) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(
+			&r.Name,
+			validation.Required.Error("Name is required"),
+			validation.Length(0, 100),
+		),
+		validation.Field(
+			&r.Scopes,
+			validation.Required.Error("Scopes are required"),
+			validation.Each(validation.In(models.ScopeCatalogRead, models.ScopeCatalogWrite, models.ScopeOrdersRead, models.ScopeOrdersWrite)),
+		),
+	)
+}
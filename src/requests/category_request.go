@@ -0,0 +1,115 @@
+package requests
+
+import (
+	"regexp"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+// slugPattern matches lowercase slugs made of words separated by single hyphens
+var slugPattern = regexp.MustCompile(`^[\p{Ll}\p{Lo}\p{N}]+(-[\p{Ll}\p{Lo}\p{N}]+)*$`)
+
+type CategoryCreateRequest struct {
+	Name     string  `json:"name"`
+	Slug     string  `json:"slug"`
+	ParentID *string `json:"parent_id"`
+}
+
+/*
+Description:
+
+	Perform validation on the CategoryCreateRequest struct fields.
+
+Returns:
+
+	error: An error if any validation fails, otherwise nil.
+*/
+func (r CategoryCreateRequest) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(
+			&r.Name,
+			validation.Required.Error("Category name is required"),
+			validation.Length(0, 100),
+		),
+		validation.Field(
+			&r.Slug,
+			validation.Length(0, 100),
+			validation.Match(slugPattern).Error("Slug must be lowercase words separated by hyphens"),
+		),
+	)
+}
+
+// CategoryUpdateRequest moves the category to the root of the tree when ParentID is an empty string.
+type CategoryUpdateRequest struct {
+	Name     string  `json:"name"`
+	Slug     string  `json:"slug"`
+	ParentID *string `json:"parent_id"`
+}
+
+/*
+Description:
+
+	Perform validation on the CategoryUpdateRequest struct fields.
+
+Returns:
+
+	error: An error if any validation fails, otherwise nil.
+*/
+func (r CategoryUpdateRequest) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(
+			&r.Name,
+			validation.Length(0, 100),
+		),
+		validation.Field(
+			&r.Slug,
+			validation.Length(0, 100),
+			validation.Match(slugPattern).Error("Slug must be lowercase words separated by hyphens"),
+		),
+	)
+}
+
+type CategoryReorderRequest struct {
+	ParentID    *string  `json:"parent_id"`
+	CategoryIDs []string `json:"category_ids"`
+}
+
+/*
+Description:
+
+	Perform validation on the CategoryReorderRequest struct fields.
+
+Returns:
+
+	error: An error if any validation fails, otherwise nil.
+*/
+func (r CategoryReorderRequest) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(
+			&r.CategoryIDs,
+			validation.Required.Error("Category Ids are required"),
+		),
+	)
+}
+
+type ProductCategoriesRequest struct {
+	CategoryIDs []string `json:"category_ids"`
+}
+
+/*
+Description:
+
+	Perform validation on the ProductCategoriesRequest struct fields.
+
+Returns:
+
+	error: An error if any validation fails, otherwise nil.
+*/
+func (r ProductCategoriesRequest) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(
+			&r.CategoryIDs,
+			validation.Each(validation.Required),
+		),
+	)
+}
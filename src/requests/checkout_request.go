@@ -2,9 +2,38 @@ package requests
 
 import validation "github.com/go-ozzo/ozzo-validation"
 
+type CheckoutItem struct {
+	ProductID string  `json:"product_id"`
+	VariantID *string `json:"variant_id"`
+	Quantity  int     `json:"quantity"`
+}
+
+/*
+Description:
+
+	Perform validation on the CheckoutItem struct fields.
+
+Returns:
+
+	error: An error if any validation fails, otherwise nil.
+*/
+func (i CheckoutItem) Validate() error {
+	return validation.ValidateStruct(&i,
+		validation.Field(
+			&i.ProductID,
+			validation.Required.Error("Product Id is required"),
+		),
+		validation.Field(
+			&i.Quantity,
+			validation.Required.Error("Quantity is required"),
+			validation.Min(1),
+			validation.Max(1000),
+		),
+	)
+}
+
 type CheckoutCreateRequest struct {
-	UserID    string   `json:"user_id"`
-	ProductIDs []string `json:"product_ids"`
+	Items []CheckoutItem `json:"items"`
 }
 
 /*
@@ -19,12 +48,39 @@ Returns:
 func (r CheckoutCreateRequest) Validate() error {
 	return validation.ValidateStruct(&r,
 		validation.Field(
-			&r.UserID,
-			validation.Required.Error("User Id is required"),
-		),
-		validation.Field(
-			&r.ProductIDs,
-			validation.Required.Error("Product Ids is required"),
+			&r.Items,
+			validation.Required.Error("Items are required"),
 		),
 	)
 }
+
+/*
+Description:
+
+	Get the items of the checkout with the quantities of duplicate products and variants added up,
+	in the order they first appear in the request.
+
+Returns:
+
+	[]CheckoutItem: The aggregated items.
+*/
+func (r CheckoutCreateRequest) Lines() []CheckoutItem {
+	var lines []CheckoutItem
+	index := make(map[string]int)
+	for _, item := range r.Items {
+		key := item.ProductID
+		if item.VariantID != nil {
+			key += "/" + *item.VariantID
+		}
+
+		if i, ok := index[key]; ok {
+			lines[i].Quantity += item.Quantity
+			continue
+		}
+
+		index[key] = len(lines)
+		lines = append(lines, item)
+	}
+
+	return lines
+}
@@ -0,0 +1,124 @@
+package requests
+
+import (
+	validation "github.com/go-ozzo/ozzo-validation"
+
+	"github.com/haseakito/ec_api/catalog"
+	"github.com/haseakito/ec_api/models"
+)
+
+// collectionSorts are the orders of the products of collections
+var collectionSorts = []interface{}{
+	models.CollectionSortManual, catalog.SortNewest, catalog.SortPriceAsc, catalog.SortPriceDesc, catalog.SortBestSelling, catalog.SortTopRated,
+}
+
+type CollectionCreateRequest struct {
+	Name        string  `json:"name"`
+	Slug        string  `json:"slug"`
+	Description *string `json:"description"`
+	Rule        *string `json:"rule"`
+	Sort        string  `json:"sort"`
+}
+
+/*
+Description:
+
+	Perform validation on the CollectionCreateRequest struct fields.
+
+Returns:
+
+	error: An error if any validation fails, otherwise nil.
+*/
+func (r CollectionCreateRequest) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(
+			&r.Name,
+			validation.Required.Error("Collection name is required"),
+			validation.Length(0, 100),
+		),
+		validation.Field(
+			&r.Slug,
+			validation.Length(0, 100),
+			validation.Match(slugPattern).Error("Slug must be lowercase words separated by hyphens"),
+		),
+		validation.Field(
+			&r.Description,
+			validation.Length(0, 1000),
+		),
+		validation.Field(
+			&r.Rule,
+			validation.Length(0, 500),
+		),
+		validation.Field(
+			&r.Sort,
+			validation.In(collectionSorts...),
+		),
+	)
+}
+
+// CollectionUpdateRequest turns the collection into a manual collection when Rule is an empty string.
+type CollectionUpdateRequest struct {
+	Name        string  `json:"name"`
+	Slug        string  `json:"slug"`
+	Description *string `json:"description"`
+	Rule        *string `json:"rule"`
+	Sort        string  `json:"sort"`
+}
+
+/*
+Description:
+
+	Perform validation on the CollectionUpdateRequest struct fields.
+
+Returns:
+
+	error: An error if any validation fails, otherwise nil.
+*/
+func (r CollectionUpdateRequest) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(
+			&r.Name,
+			validation.Length(0, 100),
+		),
+		validation.Field(
+			&r.Slug,
+			validation.Length(0, 100),
+			validation.Match(slugPattern).Error("Slug must be lowercase words separated by hyphens"),
+		),
+		validation.Field(
+			&r.Description,
+			validation.Length(0, 1000),
+		),
+		validation.Field(
+			&r.Rule,
+			validation.Length(0, 500),
+		),
+		validation.Field(
+			&r.Sort,
+			validation.In(collectionSorts...),
+		),
+	)
+}
+
+type CollectionProductsRequest struct {
+	ProductIDs []string `json:"product_ids"`
+}
+
+/*
+Description:
+
+	Perform validation on the CollectionProductsRequest struct fields.
+
+Returns:
+
+	error: An error if any validation fails, otherwise nil.
+*/
+func (r CollectionProductsRequest) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(
+			&r.ProductIDs,
+			validation.Length(0, 500),
+			validation.Each(validation.Required),
+		),
+	)
+}
@@ -0,0 +1,82 @@
+package requests
+
+import (
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/go-ozzo/ozzo-validation/is"
+
+	"github.com/haseakito/ec_api/models"
+)
+
+type MemberInviteRequest struct {
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+/*
+Description:
+
+	Perform validation on the MemberInviteRequest struct fields.
+
+Returns:
+
+	error: An error if any validation fails, otherwise nil.
+*/
+func (r MemberInviteRequest) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(
+			&r.Email,
+			validation.Required.Error("Email is required"),
+			is.Email,
+		),
+		validation.Field(
+			&r.Role,
+			validation.Required.Error("Role is required"),
+			validation.In(models.RoleOwner, models.RoleManager, models.RoleFulfillment, models.RoleAnalyst),
+		),
+	)
+}
+
+type MemberUpdateRequest struct {
+	Role string `json:"role"`
+}
+
+/*
+Description:
+
+	Perform validation on the MemberUpdateRequest struct fields.
+
+Returns:
+
+	error: An error if any validation fails, otherwise nil.
+*/
+func (r MemberUpdateRequest) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(
+			&r.Role,
+			validation.Required.Error("Role is required"),
+			validation.In(models.RoleOwner, models.RoleManager, models.RoleFulfillment, models.RoleAnalyst),
+		),
+	)
+}
+
+type MemberAcceptRequest struct {
+	Token string `json:"token"`
+}
+
+/*
+Description:
+
+	Perform validation on the MemberAcceptRequest struct fields.
+
+Returns:
+
+	error: An error if any validation fails, otherwise nil.
+*/
+func (r MemberAcceptRequest) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(
+			&r.Token,
+			validation.Required.Error("Invitation token is required"),
+		),
+	)
+}
@@ -0,0 +1,25 @@
+package requests
+
+import validation "github.com/go-ozzo/ozzo-validation"
+
+type ModerationRequest struct {
+	Reason string `json:"reason"`
+}
+
+/*
+Description:
+
+	Perform validation on the ModerationRequest struct fields.
+
+Returns:
+
+	error: An error if any validation fails, otherwise nil.
+*/
+func (r ModerationRequest) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(
+			&r.Reason,
+			validation.Length(0, 1000),
+		),
+	)
+}
@@ -0,0 +1,74 @@
+package requests
+
+import (
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+
+	"github.com/haseakito/ec_api/catalog"
+	"github.com/haseakito/ec_api/utils"
+)
+
+type ProductListRequest struct {
+	Q         string   `query:"q"`
+	Category  string   `query:"category"`
+	Tags      string   `query:"tags"`
+	MinPrice  *int64   `query:"min_price"`
+	MaxPrice  *int64   `query:"max_price"`
+	InStock   bool     `query:"in_stock"`
+	MinRating *float64 `query:"min_rating"`
+	Sort      string   `query:"sort"`
+}
+
+/*
+Description:
+
+	Perform validation on the ProductListRequest struct fields.
+
+Returns:
+
+	error: An error if any validation fails, otherwise nil.
+*/
+func (r ProductListRequest) Validate() error {
+	var minPrice int64
+	if r.MinPrice != nil {
+		minPrice = *r.MinPrice
+	}
+
+	return validation.ValidateStruct(&r,
+		validation.Field(
+			&r.MinPrice,
+			validation.Min(int64(0)),
+		),
+		validation.Field(
+			&r.MaxPrice,
+			validation.Min(minPrice).Error("Maximum price must be greater than the minimum price"),
+		),
+		validation.Field(
+			&r.MinRating,
+			validation.Min(float64(1)),
+			validation.Max(float64(5)),
+		),
+		validation.Field(
+			&r.Sort,
+			validation.In(catalog.SortNewest, catalog.SortPriceAsc, catalog.SortPriceDesc, catalog.SortBestSelling, catalog.SortTopRated, catalog.SortRelevance),
+		),
+	)
+}
+
+/*
+Description:
+
+	Get the tags of the comma-separated `tags` query parameter.
+
+Returns:
+
+	[]string: The normalized tags.
+*/
+func (r ProductListRequest) TagList() []string {
+	if r.Tags == "" {
+		return nil
+	}
+
+	return utils.NormalizeTags(strings.Split(r.Tags, ","))
+}
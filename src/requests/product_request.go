@@ -1,11 +1,18 @@
 package requests
 
-import validation "github.com/go-ozzo/ozzo-validation"
+import (
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
 
 type ProductCreateRequest struct {
+	SKU         *string  `json:"sku"`
 	Name        string   `json:"name"`
 	Description *string  `json:"description"`
-	Price       *float32 `json:"price"`
+	Price       *int64   `json:"price"`
+	Stock       *int     `json:"stock"`
+	Tags        []string `json:"tags"`
 }
 
 /*
@@ -19,6 +26,11 @@ Returns:
 */
 func (r ProductCreateRequest) Validate() error {
 	return validation.ValidateStruct(&r,
+		validation.Field(
+			&r.SKU,
+			validation.NilOrNotEmpty.Error("SKU cannot be blank"),
+			validation.Length(0, 100),
+		),
 		validation.Field(
 			&r.Name,
 			validation.Required.Error("Product name is requried"),
@@ -30,15 +42,28 @@ func (r ProductCreateRequest) Validate() error {
 		),
 		validation.Field(
 			&r.Price,
+			validation.Min(int64(0)),
+		),
+		validation.Field(
+			&r.Stock,
+			validation.Min(0),
+		),
+		validation.Field(
+			&r.Tags,
+			validation.Length(0, 20),
+			validation.Each(validation.Required, validation.Length(0, 50)),
 		),
 	)
 }
 
 type ProductUpdateRequest struct {
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Price       float32 `json:"price"`
-	Published   bool    `json:"is_published"`
+	SKU         string   `json:"sku"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Price       int64    `json:"price"`
+	Published   *bool    `json:"is_published"`
+	Stock       *int     `json:"stock"`
+	Tags        []string `json:"tags"`
 }
 
 /*
@@ -52,6 +77,10 @@ Returns:
 */
 func (r ProductUpdateRequest) Validate() error {
 	return validation.ValidateStruct(&r,
+		validation.Field(
+			&r.SKU,
+			validation.Length(0, 100),
+		),
 		validation.Field(
 			&r.Name,
 			validation.Length(0, 255),
@@ -62,9 +91,56 @@ func (r ProductUpdateRequest) Validate() error {
 		),
 		validation.Field(
 			&r.Price,
+			validation.Min(int64(0)),
 		),
 		validation.Field(
 			&r.Published,
 		),
+		validation.Field(
+			&r.Stock,
+			validation.Min(0),
+		),
+		validation.Field(
+			&r.Tags,
+			validation.Length(0, 20),
+			validation.Each(validation.Required, validation.Length(0, 50)),
+		),
+	)
+}
+
+// ProductScheduleRequest replaces the schedule of a product. A null time cancels the scheduled change.
+type ProductScheduleRequest struct {
+	PublishAt   *time.Time `json:"publish_at"`
+	UnpublishAt *time.Time `json:"unpublish_at"`
+}
+
+/*
+Description:
+
+	Perform validation on the ProductScheduleRequest struct fields.
+
+Returns:
+
+	error: An error if any validation fails, otherwise nil.
+*/
+func (r ProductScheduleRequest) Validate() error {
+	now := time.Now()
+
+	unpublishAtRules := []validation.Rule{
+		validation.Min(now).Error("Unpublish time must be in the future"),
+	}
+	if r.PublishAt != nil {
+		unpublishAtRules = append(unpublishAtRules, validation.Min(*r.PublishAt).Exclusive().Error("Unpublish time must be after the publish time"))
+	}
+
+	return validation.ValidateStruct(&r,
+		validation.Field(
+			&r.PublishAt,
+			validation.Min(now).Error("Publish time must be in the future"),
+		),
+		validation.Field(
+			&r.UnpublishAt,
+			unpublishAtRules...,
+		),
 	)
 }
@@ -3,8 +3,8 @@ package requests
 import validation "github.com/go-ozzo/ozzo-validation"
 
 type ReviewCreateRequest struct {
-	UserID  string `json:"user_id"`
 	Content string `json:"content"`
+	Rating  int    `json:"rating"`
 }
 
 /*
@@ -18,14 +18,16 @@ Returns:
 */
 func (r ReviewCreateRequest) Validate() error {
 	return validation.ValidateStruct(&r,
-		validation.Field(
-			&r.UserID,
-			validation.Required.Error("User Id is required"),
-		),
 		validation.Field(
 			&r.Content,
 			validation.Required.Error("Review content is required"),
 			validation.Length(0, 255),
 		),
+		validation.Field(
+			&r.Rating,
+			validation.Required.Error("Rating is required"),
+			validation.Min(1),
+			validation.Max(5),
+		),
 	)
 }
@@ -1,11 +1,15 @@
 package requests
 
-import validation "github.com/go-ozzo/ozzo-validation"
+import (
+	validation "github.com/go-ozzo/ozzo-validation"
+
+	"github.com/haseakito/ec_api/money"
+)
 
 type StoreCreateRequest struct {
-	UserID      string `json:"user_id"`
 	Name        string `json:"name"`
 	Description string `json:"description"`
+	Currency    string `json:"currency"`
 }
 
 /*
@@ -19,10 +23,6 @@ Returns:
 */
 func (r StoreCreateRequest) Validate() error {
 	return validation.ValidateStruct(&r,
-		validation.Field(
-			&r.UserID,
-			validation.Required.Error("User Id is required"),
-		),
 		validation.Field(
 			&r.Name,
 			validation.Length(0, 30),
@@ -32,12 +32,17 @@ func (r StoreCreateRequest) Validate() error {
 			&r.Description,
 			validation.Length(0, 1000),
 		),
+		validation.Field(
+			&r.Currency,
+			validation.In(money.Currencies()...).Error("Currency is not supported"),
+		),
 	)
 }
 
 type StoreUpdateRequest struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
+	Currency    string `json:"currency"`
 }
 
 /*
@@ -59,5 +64,9 @@ func (r StoreUpdateRequest) Validate() error {
 			&r.Description,
 			validation.Length(0, 1000),
 		),
+		validation.Field(
+			&r.Currency,
+			validation.In(money.Currencies()...).Error("Currency is not supported"),
+		),
 	)
 }
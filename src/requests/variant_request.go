@@ -0,0 +1,101 @@
+package requests
+
+import validation "github.com/go-ozzo/ozzo-validation"
+
+type OptionCreateRequest struct {
+	Name   string   `json:"name"`
+	Values []string `json:"values"`
+}
+
+/*
+Description:
+
+	Perform validation on the OptionCreateRequest struct fields.
+
+Returns:
+
+	error: An error if any validation fails, otherwise nil.
+*/
+func (r OptionCreateRequest) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(
+			&r.Name,
+			validation.Required.Error("Option name is required"),
+			validation.Length(0, 100),
+		),
+		validation.Field(
+			&r.Values,
+			validation.Required.Error("Option values are required"),
+			validation.Each(validation.Required, validation.Length(0, 100)),
+		),
+	)
+}
+
+type VariantCreateRequest struct {
+	SKU            *string  `json:"sku"`
+	Price          *int64   `json:"price"`
+	Stock          *int     `json:"stock"`
+	OptionValueIDs []string `json:"option_value_ids"`
+}
+
+/*
+Description:
+
+	Perform validation on the VariantCreateRequest struct fields.
+
+Returns:
+
+	error: An error if any validation fails, otherwise nil.
+*/
+func (r VariantCreateRequest) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(
+			&r.SKU,
+			validation.Length(0, 100),
+		),
+		validation.Field(
+			&r.Price,
+			validation.Min(int64(0)),
+		),
+		validation.Field(
+			&r.Stock,
+			validation.Min(0),
+		),
+		validation.Field(
+			&r.OptionValueIDs,
+			validation.Required.Error("Option value Ids are required"),
+		),
+	)
+}
+
+type VariantUpdateRequest struct {
+	SKU   string `json:"sku"`
+	Price int64  `json:"price"`
+	Stock *int   `json:"stock"`
+}
+
+/*
+Description:
+
+	Perform validation on the VariantUpdateRequest struct fields.
+
+Returns:
+
+	error: An error if any validation fails, otherwise nil.
+*/
+func (r VariantUpdateRequest) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(
+			&r.SKU,
+			validation.Length(0, 100),
+		),
+		validation.Field(
+			&r.Price,
+			validation.Min(int64(0)),
+		),
+		validation.Field(
+			&r.Stock,
+			validation.Min(0),
+		),
+	)
+}
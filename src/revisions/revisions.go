@@ -0,0 +1,174 @@
+package revisions
+
+import (
+	"encoding/json"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+
+	"github.com/haseakito/ec_api/audit"
+	"github.com/haseakito/ec_api/auth"
+	"github.com/haseakito/ec_api/models"
+)
+
+/*
+Description:
+
+	Snapshot holds the versioned fields of a product, which are saved with each revision and restored by a revert.
+	The stock is left out, since it also changes with orders and reverting it would corrupt the inventory.
+*/
+type Snapshot struct {
+	SKU         *string            `json:"sku"`
+	Name        string             `json:"name"`
+	Description *string            `json:"description"`
+	Price       *int64             `json:"price"`
+	Published   bool               `json:"is_published"`
+	Tags        models.StringArray `json:"tags"`
+}
+
+/*
+Description:
+
+	Take the snapshot of the versioned fields of a product.
+
+Parameters:
+
+	product (models.Product): The product.
+
+Returns:
+
+	Snapshot: The snapshot of the product.
+*/
+func NewSnapshot(product models.Product) Snapshot {
+	return Snapshot{
+		SKU:         product.SKU,
+		Name:        product.Name,
+		Description: product.Description,
+		Price:       product.Price,
+		Published:   product.Published,
+		Tags:        product.Tags,
+	}
+}
+
+/*
+Description:
+
+	Set the versioned fields of a product to the values of the snapshot.
+
+Parameters:
+
+	product (*models.Product): The product to update.
+*/
+func (s Snapshot) Apply(product *models.Product) {
+	product.SKU = s.SKU
+	product.Name = s.Name
+	product.Description = s.Description
+	product.Price = s.Price
+	product.Published = s.Published
+	product.Tags = s.Tags
+}
+
+/*
+Description:
+
+	Record a revision for an edit of a product made by the actor of the request.
+	It should be called with the transaction performing the edit, after the product is saved, so that the edits of a product are numbered in order.
+
+Parameters:
+
+	tx (*gorm.DB): The transaction performing the edit.
+	c (echo.Context): Context object containing the HTTP request information.
+	before (models.Product): The product before the edit.
+	after (models.Product): The product after the edit.
+	revertedFrom (*int): The version the product was reverted to, if the edit is a revert. Nullable.
+
+Returns:
+
+	error: Any error encountered while computing the diff or creating the revisions.
+*/
+func Record(tx *gorm.DB, c echo.Context, before, after models.Product, revertedFrom *int) error {
+	// Get the actor of the request
+	actorType, actorID := auth.CurrentActor(c)
+
+	return RecordActor(tx, actorType, actorID, c.Response().Header().Get(echo.HeaderXRequestID), before, after, revertedFrom)
+}
+
+/*
+Description:
+
+	Record a revision for an edit of a product made on behalf of an actor. Edits which leave the versioned fields unchanged are not recorded.
+	The first time a product is edited, the product as it was before the edit is recorded first, so that the edit can be reverted.
+	It should be called with the transaction performing the edit, after the product is saved: the update locks the product until the
+	transaction is committed, so that concurrent edits are numbered in order.
+
+Parameters:
+
+	tx (*gorm.DB): The transaction performing the edit.
+	actorType (string): The type of the actor, either user or api_key.
+	actorID (string): The ID of the user or the API key.
+	requestID (string): The ID of the request which made the edit.
+	before (models.Product): The product before the edit.
+	after (models.Product): The product after the edit.
+	revertedFrom (*int): The version the product was reverted to, if the edit is a revert. Nullable.
+
+Returns:
+
+	error: Any error encountered while computing the diff or creating the revisions.
+*/
+func RecordActor(tx *gorm.DB, actorType, actorID, requestID string, before, after models.Product, revertedFrom *int) error {
+	// Compute the changed fields
+	// If no versioned field changed, then there is nothing to record
+	previous, current := NewSnapshot(before), NewSnapshot(after)
+	changes, err := audit.Diff(previous, current)
+	if err != nil || len(changes) == 0 {
+		return err
+	}
+
+	// Get the latest revision of the product
+	var latest []models.ProductRevision
+	if err := tx.Where("product_id = ?", after.ID).Order("version DESC").Limit(1).Find(&latest).Error; err != nil {
+		return err
+	}
+
+	version := 1
+	if len(latest) > 0 {
+		version = latest[0].Version + 1
+	} else {
+		// Record the product as it was before its first recorded edit
+		baseline, err := json.Marshal(previous)
+		if err != nil {
+			return err
+		}
+		if err := tx.Create(&models.ProductRevision{
+			Model:     models.Model{CreatedAt: before.UpdatedAt},
+			ProductID: after.ID,
+			Version:   version,
+			Snapshot:  models.JSON(baseline),
+			Changes:   models.JSON("{}"),
+		}).Error; err != nil {
+			return err
+		}
+		version++
+	}
+
+	snapshot, err := json.Marshal(current)
+	if err != nil {
+		return err
+	}
+
+	raw, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+
+	return tx.Create(&models.ProductRevision{
+		ProductID:    after.ID,
+		Version:      version,
+		ActorType:    actorType,
+		ActorID:      actorID,
+		RequestID:    requestID,
+		RevertedFrom: revertedFrom,
+		Snapshot:     models.JSON(snapshot),
+		Changes:      models.JSON(raw),
+	}).Error
+}
@@ -1,17 +1,24 @@
 package routes
 
 import (
+	"log"
 	"os"
+	"time"
 
 	"github.com/stripe/stripe-go/v76"
 	"gorm.io/gorm"
 
-	"github.com/clerkinc/clerk-sdk-go/clerk"
-
 	"github.com/haseakito/ec_api/auth"
+	"github.com/haseakito/ec_api/bulk"
+	"github.com/haseakito/ec_api/catalog"
 	"github.com/haseakito/ec_api/database"
 	"github.com/haseakito/ec_api/handlers"
 	"github.com/haseakito/ec_api/handlers/admin"
+	"github.com/haseakito/ec_api/handlers/platform"
+	"github.com/haseakito/ec_api/inventory"
+	"github.com/haseakito/ec_api/jobs"
+	"github.com/haseakito/ec_api/ratelimit"
+	"github.com/haseakito/ec_api/trash"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 )
@@ -34,15 +41,44 @@ func Init() *echo.Echo {
 	// Initialize sentry client
 	// TODO: Add Sentry SDK
 
-	// Initialize clerk client
-	client, _ := clerk.NewClient(os.Getenv("CLERK_SECRET_KEY"))
+	// Initialize the authenticator (Clerk by default)
+	authenticator, err := auth.NewAuthenticatorFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	// Initialize the Stripe client
 	stripe.Key = os.Getenv("STRIPE_SECRET_KEY")
 
+	// Initialize the rate limiter store (in memory by default)
+	limiter, err := ratelimit.NewStoreFromEnv(db)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Release the stock reservations of abandoned checkouts in the background
+	inventory.StartSweeper(db, time.Minute, 5*time.Minute)
+
+	// Apply the scheduled publications and unpublications of products in the background
+	catalog.StartScheduler(db, 30*time.Second)
+
+	// Purge the trashed stores, products and images after the retention period in the background
+	trash.StartPurger(db, time.Hour, trash.RetentionFromEnv())
+
+	// Run the background jobs, resuming the jobs interrupted by a restart
+	jobs.Register(bulk.JobTypeProductImport, bulk.ImportProducts)
+	jobs.Register(trash.JobTypeStoreDeletion, trash.DeleteStore)
+	jobs.StartWorker(db, 5*time.Second)
+
 	// Initialize new Echo application
 	e := echo.New()
 
+	// Read the client IP from the forwarded headers only when they are set by a trusted proxy
+	e.IPExtractor, err = ratelimit.IPExtractorFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	/* Configure middlewares */
 
 	// CORS middleware
@@ -55,24 +91,51 @@ func Init() *echo.Echo {
 	// Recover from panics middleware
 	e.Use(middleware.Recover())
 
-	// Auth middleware
-	e.Use(auth.AuthMiddleware(client))
-
 	/* Configure routers */
 
 	// Set the default API route
 	r := e.Group("/api/v1")
 
 	// Set up public APIs
-	publicAPIs(r, db)
+	publicAPIs(r, db, authenticator, limiter)
+
+	// Set up customer APIs
+	customerAPIs(r, db, authenticator, limiter)
+
+	// Set up webhook APIs
+	webhookAPIs(r, db)
 
 	// Set up admin APIs
-	adminAPIs(r, db)
+	adminAPIs(r, db, authenticator, limiter)
+
+	// Set up platform APIs
+	platformAPIs(r, db, authenticator, limiter)
 
 	return e
 }
 
-func publicAPIs(r *echo.Group, db *gorm.DB) {
+/*
+Description:
+
+	Set up the catalog APIs which are accessible anonymously.
+	The user is resolved when the request carries a valid bearer token.
+
+Parameters:
+
+	r (*echo.Group): The API route group.
+	db (*gorm.DB): A pointer to the GORM database connection.
+	authenticator (auth.Authenticator): The Authenticator used to authenticate requests.
+	limiter (ratelimit.Store): The store keeping the rate limiter buckets.
+*/
+func publicAPIs(r *echo.Group, db *gorm.DB, authenticator auth.Authenticator, limiter ratelimit.Store) {
+	// Rate limit the catalog per IP address before resolving the user, so that bearer tokens are not checked without limit,
+	// then per user or IP address once the user is resolved from the bearer token of the request, if any
+	public := []echo.MiddlewareFunc{
+		ipRateLimit(limiter, ratelimit.Policy{Name: "public_ip", Requests: 300, Period: time.Minute, Burst: 120}),
+		auth.OptionalAuthMiddleware(authenticator),
+		rateLimit(limiter, ratelimit.Policy{Name: "public", Requests: 120, Period: time.Minute, Burst: 60}),
+	}
+
 	// Stores APIs Group
 	s := r.Group("/stores")
 	{
@@ -80,17 +143,25 @@ func publicAPIs(r *echo.Group, db *gorm.DB) {
 		storeCtrl := handlers.NewStoreHandler(db)
 
 		// Store APIs
-		s.GET("", storeCtrl.GetStores)
-		s.GET("/:id", storeCtrl.GetStore)
+		s.GET("", storeCtrl.GetStores, public...)
+		s.GET("/:id", storeCtrl.GetStore, public...)
 
 		// Product APIs for Stores
-		s.GET("/:id/products", storeCtrl.GetProducts)
+		s.GET("/:id/products", storeCtrl.GetProducts, public...)
 
-		// Order APIs for Stores
-		s.POST("/:id/checkout", storeCtrl.CreateOrder)
-		s.GET("/:id/orders", storeCtrl.GetOrders)
+		// Category APIs for Stores
+		s.GET("/:id/categories", storeCtrl.GetCategories, public...)
+
+		// Collection APIs for Stores
+		s.GET("/:id/collections/:slug/products", storeCtrl.GetCollectionProducts, public...)
 	}
 
+	// Initialize the new SearchHandler
+	searchCtrl := handlers.NewSearchHandler(db)
+
+	// Search APIs
+	r.GET("/search", searchCtrl.SearchProducts, public...)
+
 	// Products APIs Group
 	p := r.Group("/products")
 	{
@@ -98,14 +169,72 @@ func publicAPIs(r *echo.Group, db *gorm.DB) {
 		productCtrl := handlers.NewProductHandler(db)
 
 		// Product APIs
-		p.GET("/:id", productCtrl.GetProduct)
+		p.GET("/:id", productCtrl.GetProduct, public...)
+
+		// Review APIs
+		p.GET("/:id/reviews", productCtrl.GetReviews, public...)
+	}
+}
+
+/*
+Description:
+
+	Set up the customer APIs which require an authenticated user.
+
+Parameters:
+
+	r (*echo.Group): The API route group.
+	db (*gorm.DB): A pointer to the GORM database connection.
+	authenticator (auth.Authenticator): The Authenticator used to authenticate requests.
+	limiter (ratelimit.Store): The store keeping the rate limiter buckets.
+*/
+func customerAPIs(r *echo.Group, db *gorm.DB, authenticator auth.Authenticator, limiter ratelimit.Store) {
+	// Rate limit per IP address before the authentication, so that failed authentications are limited too
+	ipLimit := ipRateLimit(limiter, ratelimit.Policy{Name: "customer_ip", Requests: 120, Period: time.Minute, Burst: 60})
+
+	// Require an authenticated user
+	requireAuth := auth.AuthMiddleware(authenticator)
+
+	// Rate limit per user, with stricter policies for the routes creating Stripe sessions and reviews
+	customerLimit := rateLimit(limiter, ratelimit.Policy{Name: "customer", Requests: 60, Period: time.Minute, Burst: 30})
+	checkoutLimit := rateLimit(limiter, ratelimit.Policy{Name: "checkout", Requests: 10, Period: time.Minute, Burst: 5})
+	reviewLimit := rateLimit(limiter, ratelimit.Policy{Name: "reviews", Requests: 5, Period: time.Minute, Burst: 3})
+
+	// Stores APIs Group
+	s := r.Group("/stores")
+	{
+		// Initialize the new StoreController
+		storeCtrl := handlers.NewStoreHandler(db)
+
+		// Order APIs for Stores
+		s.POST("/:id/checkout", storeCtrl.CreateOrder, ipLimit, requireAuth, checkoutLimit)
+		s.GET("/:id/orders", storeCtrl.GetOrders, ipLimit, requireAuth, customerLimit)
+		s.POST("/:id/orders/:order_id/cancel", storeCtrl.CancelOrder, ipLimit, requireAuth, customerLimit)
+	}
+
+	// Products APIs Group
+	p := r.Group("/products")
+	{
+		// Initialize the new ProductHandler
+		productCtrl := handlers.NewProductHandler(db)
 
 		// Review APIs
-		p.GET("/:id/reviews", productCtrl.GetReviews)
-		p.POST("/:id/reviews", productCtrl.CreateReview)
-		p.DELETE("/:id/reviews/:review_id", productCtrl.DeleteReview)
+		p.POST("/:id/reviews", productCtrl.CreateReview, ipLimit, requireAuth, reviewLimit)
+		p.DELETE("/:id/reviews/:review_id", productCtrl.DeleteReview, ipLimit, requireAuth, customerLimit)
 	}
+}
+
+/*
+Description:
 
+	Set up the webhook APIs. Webhooks are authenticated by their signatures instead of a user session.
+
+Parameters:
+
+	r (*echo.Group): The API route group.
+	db (*gorm.DB): A pointer to the GORM database connection.
+*/
+func webhookAPIs(r *echo.Group, db *gorm.DB) {
 	// Webhooks Group
 	w := r.Group("/webhooks")
 	{
@@ -116,9 +245,26 @@ func publicAPIs(r *echo.Group, db *gorm.DB) {
 	}
 }
 
-func adminAPIs(r *echo.Group, db *gorm.DB) {
+/*
+Description:
+
+	Set up the admin APIs which require an authenticated user holding a role with the required permission in the targeted store,
+	or an API key of the targeted store granted the scope of the required permission.
+
+Parameters:
+
+	r (*echo.Group): The API route group.
+	db (*gorm.DB): A pointer to the GORM database connection.
+	authenticator (auth.Authenticator): The Authenticator used to authenticate requests.
+	limiter (ratelimit.Store): The store keeping the rate limiter buckets.
+*/
+func adminAPIs(r *echo.Group, db *gorm.DB, authenticator auth.Authenticator, limiter ratelimit.Store) {
+	// Rate limit per IP address before the authentication, so that failed authentications are limited too, then per user or API key
+	ipLimit := ipRateLimit(limiter, ratelimit.Policy{Name: "admin_ip", Requests: 600, Period: time.Minute, Burst: 100})
+	adminLimit := rateLimit(limiter, ratelimit.Policy{Name: "admin", Requests: 600, Period: time.Minute, Burst: 100})
+
 	// Set the admin API route
-	a := r.Group("/admin")
+	a := r.Group("/admin", ipLimit, auth.APIKeyAuthMiddleware(authenticator, db), adminLimit)
 	{
 		/* Stores Group APIs */
 
@@ -127,29 +273,216 @@ func adminAPIs(r *echo.Group, db *gorm.DB) {
 
 		// Stores APIs
 		a.POST("/stores", storeCtrl.CreateStore)
-		a.PATCH("/stores/:id", storeCtrl.UpdateStore)
-		a.DELETE("/stores/:id", storeCtrl.DeleteStore)
 
-		// Assets APIs for Stores
-		a.POST("/stores/:id/upload", storeCtrl.UploadImage)
-		a.DELETE("/stores/:id/assets", storeCtrl.DeleteImage)
-
-		// Product APIs for Stores
-		a.POST("/stores/:id/products", storeCtrl.CreateProduct)
-		a.GET("/stores/:id/products", storeCtrl.GetProducts)
-
-		// Order APIs for Stores
-		a.GET("/stores/:id/orders", storeCtrl.GetRevenues)
+		// Categories are managed from both the store and the product groups
+		categoryCtrl := admin.NewAdminCategoryHandler(db)
+
+		// Invitations are accepted by users who are not members of the store yet
+		memberCtrl := admin.NewAdminMemberHandler(db)
+		a.POST("/stores/:id/members/accept", memberCtrl.AcceptInvitation)
+
+		// Trashed stores and products are restored by the members of the store
+		trashCtrl := admin.NewAdminTrashHandler(db)
+		a.POST("/stores/:id/restore", trashCtrl.RestoreStore, auth.StoreMemberMiddleware(db, auth.TrashedStoreFromParam), auth.RequirePermission(auth.PermStoreDelete))
+		a.POST("/stores/:id/purge", trashCtrl.PurgeStore, auth.StoreMemberMiddleware(db, auth.TrashedStoreFromParam), auth.RequirePermission(auth.PermStoreDelete))
+		a.GET("/stores/:id/purge", trashCtrl.GetStorePurge, auth.StoreMemberMiddleware(db, auth.TrashedStoreFromParam), auth.RequirePermission(auth.PermStoreDelete))
+		a.POST("/products/:id/restore", trashCtrl.RestoreProduct, auth.StoreMemberMiddleware(db, auth.StoreFromTrashedProductParam), auth.RequirePermission(auth.PermProductsDelete))
+
+		// Store APIs restricted to the members of the store
+		s := a.Group("/stores/:id", auth.StoreMemberMiddleware(db, auth.StoreFromParam))
+		{
+			s.PATCH("", storeCtrl.UpdateStore, auth.RequirePermission(auth.PermStoreUpdate))
+			s.DELETE("", storeCtrl.DeleteStore, auth.RequirePermission(auth.PermStoreDelete))
+
+			// Assets APIs for Stores
+			s.POST("/upload", storeCtrl.UploadImage, auth.RequirePermission(auth.PermStoreUpdate))
+			s.DELETE("/assets", storeCtrl.DeleteImage, auth.RequirePermission(auth.PermStoreUpdate))
+
+			// Product APIs for Stores
+			s.POST("/products", storeCtrl.CreateProduct, auth.RequirePermission(auth.PermProductsWrite))
+			s.GET("/products", storeCtrl.GetProducts, auth.RequirePermission(auth.PermProductsRead))
+
+			// Trash APIs for Stores
+			s.GET("/trash/products", trashCtrl.GetTrashedProducts, auth.RequirePermission(auth.PermProductsRead))
+			s.GET("/trash/images", trashCtrl.GetTrashedImages, auth.RequirePermission(auth.PermProductsRead))
+
+			// Import and export APIs for Stores
+			importCtrl := admin.NewAdminImportHandler(db)
+			s.POST("/products/import", importCtrl.ImportProducts, auth.RequirePermission(auth.PermProductsWrite))
+			s.GET("/products/export", importCtrl.ExportProducts, auth.RequirePermission(auth.PermProductsRead))
+
+			// Job APIs for Stores
+			jobCtrl := admin.NewAdminJobHandler(db)
+			s.GET("/jobs", jobCtrl.GetJobs, auth.RequirePermission(auth.PermProductsRead))
+			s.GET("/jobs/:job_id", jobCtrl.GetJob, auth.RequirePermission(auth.PermProductsRead))
+
+			// Order APIs for Stores
+			orderCtrl := admin.NewAdminOrderHandler(db)
+			s.GET("/orders", storeCtrl.GetRevenues, auth.RequirePermission(auth.PermRevenueRead))
+			s.POST("/orders/:order_id/fulfill", orderCtrl.FulfillOrder, auth.RequirePermission(auth.PermOrdersWrite))
+
+			// Member APIs for Stores
+			s.GET("/members", memberCtrl.GetMembers, auth.RequirePermission(auth.PermMembersManage))
+			s.POST("/members", memberCtrl.InviteMember, auth.RequirePermission(auth.PermMembersManage))
+			s.PATCH("/members/:member_id", memberCtrl.UpdateMember, auth.RequirePermission(auth.PermMembersManage))
+			s.DELETE("/members/:member_id", memberCtrl.RevokeMember, auth.RequirePermission(auth.PermMembersManage))
+
+			// API key APIs for Stores
+			apiKeyCtrl := admin.NewAdminAPIKeyHandler(db)
+			s.GET("/api-keys", apiKeyCtrl.GetAPIKeys, auth.RequirePermission(auth.PermAPIKeysManage))
+			s.POST("/api-keys", apiKeyCtrl.CreateAPIKey, auth.RequirePermission(auth.PermAPIKeysManage))
+			s.DELETE("/api-keys/:key_id", apiKeyCtrl.RevokeAPIKey, auth.RequirePermission(auth.PermAPIKeysManage))
+
+			// Audit log APIs for Stores
+			auditCtrl := admin.NewAdminAuditHandler(db)
+			s.GET("/audit-log", auditCtrl.GetAuditLog, auth.RequirePermission(auth.PermAuditRead))
+
+			// Category APIs for Stores
+			s.GET("/categories", categoryCtrl.GetCategories, auth.RequirePermission(auth.PermProductsRead))
+			s.POST("/categories", categoryCtrl.CreateCategory, auth.RequirePermission(auth.PermProductsWrite))
+			s.POST("/categories/reorder", categoryCtrl.ReorderCategories, auth.RequirePermission(auth.PermProductsWrite))
+			s.PATCH("/categories/:category_id", categoryCtrl.UpdateCategory, auth.RequirePermission(auth.PermProductsWrite))
+			s.DELETE("/categories/:category_id", categoryCtrl.DeleteCategory, auth.RequirePermission(auth.PermProductsWrite))
+
+			// Collection APIs for Stores
+			collectionCtrl := admin.NewAdminCollectionHandler(db)
+			s.GET("/collections", collectionCtrl.GetCollections, auth.RequirePermission(auth.PermProductsRead))
+			s.POST("/collections", collectionCtrl.CreateCollection, auth.RequirePermission(auth.PermProductsWrite))
+			s.PATCH("/collections/:collection_id", collectionCtrl.UpdateCollection, auth.RequirePermission(auth.PermProductsWrite))
+			s.PUT("/collections/:collection_id/products", collectionCtrl.SetCollectionProducts, auth.RequirePermission(auth.PermProductsWrite))
+			s.DELETE("/collections/:collection_id", collectionCtrl.DeleteCollection, auth.RequirePermission(auth.PermProductsWrite))
+		}
 
 		/* Product Group APIs */
 
 		// Initialize the new AdminProductHandler
 		productCtrl := admin.NewAdminProductHandler(db)
 
+		// Product APIs restricted to the members of the store the product belongs to
+		p := a.Group("/products/:id", auth.StoreMemberMiddleware(db, auth.StoreFromProductParam))
+		{
+			p.PATCH("", productCtrl.UpdateProduct, auth.RequirePermission(auth.PermProductsWrite))
+			p.PUT("/schedule", productCtrl.ScheduleProduct, auth.RequirePermission(auth.PermProductsWrite))
+			p.POST("/upload", productCtrl.UploadImages, auth.RequirePermission(auth.PermProductsWrite))
+			p.DELETE("", productCtrl.DeleteProduct, auth.RequirePermission(auth.PermProductsDelete))
+			p.DELETE("/assets/:image_id", productCtrl.DeleteProductImage, auth.RequirePermission(auth.PermProductsWrite))
+			p.POST("/assets/:image_id/restore", trashCtrl.RestoreProductImage, auth.RequirePermission(auth.PermProductsWrite))
+
+			// Option and variant APIs for Products
+			variantCtrl := admin.NewAdminVariantHandler(db)
+			p.POST("/options", variantCtrl.CreateOption, auth.RequirePermission(auth.PermProductsWrite))
+			p.DELETE("/options/:option_id", variantCtrl.DeleteOption, auth.RequirePermission(auth.PermProductsWrite))
+			p.GET("/variants", variantCtrl.GetVariants, auth.RequirePermission(auth.PermProductsRead))
+			p.POST("/variants", variantCtrl.CreateVariant, auth.RequirePermission(auth.PermProductsWrite))
+			p.PATCH("/variants/:variant_id", variantCtrl.UpdateVariant, auth.RequirePermission(auth.PermProductsWrite))
+			p.POST("/variants/:variant_id/upload", variantCtrl.UploadVariantImages, auth.RequirePermission(auth.PermProductsWrite))
+			p.DELETE("/variants/:variant_id", variantCtrl.DeleteVariant, auth.RequirePermission(auth.PermProductsDelete))
+
+			// Category APIs for Products
+			p.PUT("/categories", categoryCtrl.SetProductCategories, auth.RequirePermission(auth.PermProductsWrite))
+
+			// Revision APIs for Products
+			revisionCtrl := admin.NewAdminRevisionHandler(db)
+			p.GET("/revisions", revisionCtrl.GetRevisions, auth.RequirePermission(auth.PermProductsRead))
+			p.POST("/revisions/:version/revert", revisionCtrl.RevertRevision, auth.RequirePermission(auth.PermProductsWrite))
+
+			// Draft APIs for Products
+			draftCtrl := admin.NewAdminDraftHandler(db)
+			p.GET("/draft", draftCtrl.GetDraft, auth.RequirePermission(auth.PermProductsRead))
+			p.DELETE("/draft", draftCtrl.DiscardDraft, auth.RequirePermission(auth.PermProductsWrite))
+			p.POST("/draft/publish", draftCtrl.PublishDraft, auth.RequirePermission(auth.PermProductsWrite))
+			p.POST("/draft/preview", draftCtrl.CreatePreview, auth.RequirePermission(auth.PermProductsRead))
+		}
+	}
+}
+
+/*
+Description:
+
+	Set up the platform APIs which are restricted to platform operators.
+
+Parameters:
+
+	r (*echo.Group): The API route group.
+	db (*gorm.DB): A pointer to the GORM database connection.
+	authenticator (auth.Authenticator): The Authenticator used to authenticate requests.
+	limiter (ratelimit.Store): The store keeping the rate limiter buckets.
+*/
+func platformAPIs(r *echo.Group, db *gorm.DB, authenticator auth.Authenticator, limiter ratelimit.Store) {
+	// Rate limit per IP address before the authentication, so that failed authentications are limited too, then per user
+	ipLimit := ipRateLimit(limiter, ratelimit.Policy{Name: "platform_ip", Requests: 600, Period: time.Minute, Burst: 100})
+	platformLimit := rateLimit(limiter, ratelimit.Policy{Name: "platform", Requests: 600, Period: time.Minute, Burst: 100})
+
+	// Set the platform API route
+	g := r.Group("/platform", ipLimit, auth.AuthMiddleware(authenticator), auth.PlatformAdminMiddleware(), platformLimit)
+	{
+		// Look up store owners when the authenticator is backed by a user directory
+		users, _ := authenticator.(auth.UserDirectory)
+
+		// Initialize the new PlatformHandler
+		platformCtrl := platform.NewPlatformHandler(db, users)
+
+		// Store APIs
+		g.GET("/stores", platformCtrl.GetStores)
+		g.POST("/stores/:id/suspend", platformCtrl.SuspendStore)
+		g.POST("/stores/:id/unsuspend", platformCtrl.UnsuspendStore)
+
 		// Product APIs
-		a.PATCH("/products/:id", productCtrl.UpdateProduct)
-		a.POST("/products/:id/upload", productCtrl.UploadImages)
-		a.DELETE("/products/:id", productCtrl.DeleteProduct)
-		a.DELETE("/products/:id/assets/:image_id", productCtrl.DeleteProductImage)
+		g.POST("/products/:id/unpublish", platformCtrl.UnpublishProduct)
+		g.POST("/products/:id/unlock", platformCtrl.UnlockProduct)
+
+		// Review APIs
+		g.DELETE("/reviews/:review_id", platformCtrl.DeleteReview)
+
+		// Moderation log APIs
+		g.GET("/actions", platformCtrl.GetActions)
+	}
+}
+
+/*
+Description:
+
+	Build a rate limiting middleware identifying clients by API key, user or IP address.
+	The policy can be overridden with the RATE_LIMIT_<NAME> environment variable.
+
+Parameters:
+
+	store (ratelimit.Store): The store keeping the rate limiter buckets.
+	def (ratelimit.Policy): The default policy.
+
+Returns:
+
+	echo.MiddlewareFunc: An Echo middleware function that performs rate limiting for incoming requests.
+*/
+func rateLimit(store ratelimit.Store, def ratelimit.Policy) echo.MiddlewareFunc {
+	policy, err := ratelimit.PolicyFromEnv(def)
+	if err != nil {
+		log.Fatal(err)
 	}
+
+	return ratelimit.Middleware(store, policy, ratelimit.KeyByActor)
+}
+
+/*
+Description:
+
+	Build a rate limiting middleware identifying clients by IP address, to be installed before the auth middleware of the routes.
+	The policy can be overridden with the RATE_LIMIT_<NAME> environment variable.
+
+Parameters:
+
+	store (ratelimit.Store): The store keeping the rate limiter buckets.
+	def (ratelimit.Policy): The default policy.
+
+Returns:
+
+	echo.MiddlewareFunc: An Echo middleware function that performs rate limiting for incoming requests.
+*/
+func ipRateLimit(store ratelimit.Store, def ratelimit.Policy) echo.MiddlewareFunc {
+	policy, err := ratelimit.PolicyFromEnv(def)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return ratelimit.Middleware(store, policy, ratelimit.KeyByIP)
 }
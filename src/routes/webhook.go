@@ -5,12 +5,14 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/stripe/stripe-go/v76"
 	"github.com/stripe/stripe-go/v76/webhook"
 	"gorm.io/gorm"
 
+	"github.com/haseakito/ec_api/inventory"
 	"github.com/haseakito/ec_api/models"
 )
 
@@ -41,7 +43,8 @@ func NewWebhookHandler(db *gorm.DB) *WebhookHandler {
 Description:
 
 	StripeWebhook handles incoming webhook events from Stripe.
-	It processes the checkout.session.completed event to update the order status.
+	It processes the checkout.session.completed event to mark the order as paid and take its reserved stock,
+	and the checkout.session.expired event to cancel the order and release its reserved stock.
 
 HTTP Method:
 
@@ -76,34 +79,54 @@ func (h WebhookHandler) StripeWebhook(c echo.Context) error {
 		return c.String(http.StatusBadRequest, "Failed to construct stripe event")
 	}
 
-	// Check if the event type is checkout.session.completed
-	if event.Type == "checkout.session.completed" {
-		// Unmarshal the event data into a PaymentIntent object
-		var paymentIntent stripe.PaymentIntent
-		if err := json.Unmarshal(event.Data.Raw, &paymentIntent); err != nil {
-			return c.JSON(http.StatusBadRequest, err.Error())
-		}
+	// Ignore the events not related to checkout sessions
+	if event.Type != "checkout.session.completed" && event.Type != "checkout.session.expired" {
+		return c.NoContent(http.StatusOK)
+	}
 
-		// Extract the order ID from the PaymentIntent's metadata
-		orderID := paymentIntent.Metadata["order_id"]
+	// Unmarshal the event data into a CheckoutSession object
+	var checkoutSession stripe.CheckoutSession
+	if err := json.Unmarshal(event.Data.Raw, &checkoutSession); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
 
-		// Get an order with order id
-		// If there is no record, then throw a NotFound error
-		var order models.Order
-		if err := h.db.Find(&order, "id = ?", orderID).Error; err != nil {
-			return c.JSON(http.StatusNotFound, err)
-		}
+	// Extract the order ID from the CheckoutSession's metadata
+	orderID := checkoutSession.Metadata["order_id"]
 
-		// Update order field
-		order.Paid = true
+	// Get an order with order id
+	// If there is no record, then throw a NotFound error
+	var order models.Order
+	if err := h.db.Take(&order, "id = ?", orderID).Error; err != nil {
+		return c.JSON(http.StatusNotFound, err)
+	}
 
-		// Update order with data
-		// If the update is unsuccessful, then throw an error
-		if err := h.db.Save(&order).Error; err != nil {
-			return c.JSON(http.StatusInternalServerError, err)
+	// Update the order and its reserved stock in a transaction
+	// If the update is unsuccessful, then throw an error
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		switch event.Type {
+		case "checkout.session.completed":
+			// Mark the order as paid with the amount charged, including discounts and taxes applied by Stripe, and take the reserved stock
+			// A payment completed after the sweeper cancelled the expired order reopens the order, so that it can be fulfilled
+			if err := tx.Model(&order).Updates(map[string]interface{}{"paid": true, "total": checkoutSession.AmountTotal, "canceled_at": nil}).Error; err != nil {
+				return err
+			}
+			return inventory.Commit(tx, order.ID)
+
+		default:
+			// Paid orders are never cancelled
+			if order.Paid {
+				return nil
+			}
+
+			// Mark the order as cancelled and release the reserved stock
+			if err := tx.Model(&order).Update("canceled_at", time.Now()).Error; err != nil {
+				return err
+			}
+			return inventory.Release(tx, order.ID)
 		}
-
-		return c.JSON(http.StatusOK, "Successfully updated the order")
+	}); err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
 	}
-	return nil
+
+	return c.JSON(http.StatusOK, "Successfully updated the order")
 }
@@ -0,0 +1,94 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+// Config is the Postgres text search configuration used to index and query products.
+const Config = "english"
+
+// headlineOptions configures the highlighted snippets of the matching products.
+const headlineOptions = "StartSel=<mark>, StopSel=</mark>, MaxWords=30, MinWords=10, MaxFragments=2"
+
+// snippetSource is the name and description of a product, HTML-escaped so that the snippets only contain the markup of the highlights
+const snippetSource = `replace(replace(replace(replace(replace(products.name || ' ' || coalesce(products.description, ''), '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&quot;'), '''', '&#39;')`
+
+/*
+Description:
+
+	Convert the search terms typed by a user into a Postgres tsquery matching every term as a prefix,
+	e.g. "red t-sh" into "red:* & t:* & sh:*". Only letters and digits are kept, so the query never contains tsquery operators.
+
+Parameters:
+
+	q (string): The search terms.
+
+Returns:
+
+	string: The tsquery, or an empty string if there are no terms.
+*/
+func PrefixQuery(q string) string {
+	terms := strings.FieldsFunc(strings.ToLower(q), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	for i, term := range terms {
+		terms[i] = term + ":*"
+	}
+
+	return strings.Join(terms, " & ")
+}
+
+/*
+Description:
+
+	Scope restricting a query on products to the products matching the search terms.
+	The caller must check that PrefixQuery(q) is not empty.
+
+Parameters:
+
+	q (string): The search terms.
+
+Returns:
+
+	func(*gorm.DB) *gorm.DB: The scope restricting the query.
+*/
+func Matching(q string) func(db *gorm.DB) *gorm.DB {
+	tsquery := PrefixQuery(q)
+
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("products.search_vector @@ to_tsquery(?, ?)", Config, tsquery)
+	}
+}
+
+/*
+Description:
+
+	Scope restricting a query on products to the products matching the search terms, ordered by relevance,
+	with a highlighted snippet of the name and description selected into Product.Snippet.
+	The snippet is HTML-escaped, so that it only contains the <mark> tags of the highlights and can be rendered as HTML.
+	The caller must check that PrefixQuery(q) is not empty.
+
+Parameters:
+
+	q (string): The search terms.
+
+Returns:
+
+	func(*gorm.DB) *gorm.DB: The scope restricting the query.
+*/
+func Match(q string) func(db *gorm.DB) *gorm.DB {
+	tsquery := PrefixQuery(q)
+
+	return func(db *gorm.DB) *gorm.DB {
+		return db.
+			Select("products.*, ts_headline(?, "+snippetSource+", to_tsquery(?, ?), ?) AS snippet, ts_rank(products.search_vector, to_tsquery(?, ?)) AS search_rank",
+				Config, Config, tsquery, headlineOptions, Config, tsquery).
+			Scopes(Matching(q)).
+			Order("search_rank DESC").
+			Order("products.id")
+	}
+}
@@ -0,0 +1,45 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/haseakito/ec_api/auth"
+	"github.com/haseakito/ec_api/models"
+)
+
+func TestAPIKeyScopes(t *testing.T) {
+	key := &models.APIKey{Scopes: models.StringArray{models.ScopeCatalogWrite}}
+
+	// catalog:write grants reading and writing the catalog
+	assert.True(t, auth.APIKeyHasPermission(key, auth.PermProductsRead))
+	assert.True(t, auth.APIKeyHasPermission(key, auth.PermProductsDelete))
+
+	// but nothing else
+	assert.False(t, auth.APIKeyHasPermission(key, auth.PermRevenueRead))
+	assert.False(t, auth.APIKeyHasPermission(key, auth.PermStoreDelete))
+	assert.False(t, auth.APIKeyHasPermission(key, auth.PermAPIKeysManage))
+}
+
+func TestStringArray(t *testing.T) {
+	arr := models.StringArray{"catalog:read", `quote "and" \slash`, "comma,separated", ""}
+
+	// Round trip through the array literal
+	value, err := arr.Value()
+	assert.NoError(t, err)
+
+	var scanned models.StringArray
+	assert.NoError(t, scanned.Scan(value))
+	assert.Equal(t, arr, scanned)
+
+	// Unquoted literals as returned by PostgreSQL
+	assert.NoError(t, scanned.Scan([]byte("{a,b}")))
+	assert.Equal(t, models.StringArray{"a", "b"}, scanned)
+
+	assert.NoError(t, scanned.Scan("{}"))
+	assert.Empty(t, scanned)
+
+	assert.Error(t, scanned.Scan("{a,NULL}"))
+	assert.Error(t, scanned.Scan("not an array"))
+}
@@ -0,0 +1,32 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/haseakito/ec_api/audit"
+	"github.com/haseakito/ec_api/models"
+)
+
+func TestAuditDiff(t *testing.T) {
+	description := "old description"
+	before := models.Product{Name: "T-shirt", Description: &description}
+	after := before
+	after.Name = "Shirt"
+	after.Published = true
+
+	// Only the changed fields are recorded
+	changes, err := audit.Diff(before, after)
+	assert.NoError(t, err)
+	assert.Len(t, changes, 2)
+	assert.Equal(t, audit.Change{Before: "T-shirt", After: "Shirt"}, changes["name"])
+	assert.Equal(t, audit.Change{Before: false, After: true}, changes["is_published"])
+
+	// Deletions record every non-timestamp field as removed
+	changes, err = audit.Diff(before, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "old description", changes["description"].Before)
+	assert.Nil(t, changes["description"].After)
+	assert.NotContains(t, changes, "created_at")
+}
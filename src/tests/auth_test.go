@@ -0,0 +1,150 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/clerkinc/clerk-sdk-go/clerk"
+	"github.com/golang-jwt/jwt"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/haseakito/ec_api/auth"
+)
+
+const testSecret = "test-secret"
+
+// Sign a HS256 token with the test secret
+func signToken(t *testing.T, claims jwt.MapClaims) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testSecret))
+	assert.NoError(t, err)
+
+	return token
+}
+
+func TestJWTAuthenticatorValidToken(t *testing.T) {
+	authenticator, err := auth.NewHS256Authenticator([]byte(testSecret), auth.JWTOptions{Issuer: "ec_api"})
+	assert.NoError(t, err)
+
+	token := signToken(t, jwt.MapClaims{
+		"sub":   "user_123",
+		"iss":   "ec_api",
+		"email": "owner@example.com",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	})
+
+	user, err := authenticator.Authenticate(token)
+	assert.NoError(t, err)
+	assert.Equal(t, "user_123", user.ID)
+	assert.Equal(t, "owner@example.com", user.EmailAddresses[0].EmailAddress)
+
+	// The email address is only verified when the token says so
+	assert.False(t, auth.HasVerifiedEmail(user, "owner@example.com"))
+
+	user, err = authenticator.Authenticate(signToken(t, jwt.MapClaims{
+		"sub":            "user_123",
+		"iss":            "ec_api",
+		"email":          "owner@example.com",
+		"email_verified": true,
+	}))
+	assert.NoError(t, err)
+	assert.True(t, auth.HasVerifiedEmail(user, "Owner@Example.com"))
+}
+
+func TestHasVerifiedEmail(t *testing.T) {
+	user := &clerk.User{EmailAddresses: []clerk.EmailAddress{
+		{EmailAddress: "owner@example.com", Verification: &clerk.Verification{Status: auth.EmailVerified}},
+		{EmailAddress: "pending@example.com", Verification: &clerk.Verification{Status: "unverified"}},
+		{EmailAddress: "unknown@example.com"},
+	}}
+
+	assert.True(t, auth.HasVerifiedEmail(user, "OWNER@example.com"))
+	assert.False(t, auth.HasVerifiedEmail(user, "pending@example.com"))
+	assert.False(t, auth.HasVerifiedEmail(user, "unknown@example.com"))
+	assert.False(t, auth.HasVerifiedEmail(user, "other@example.com"))
+}
+
+func TestJWTAuthenticatorRejectsInvalidTokens(t *testing.T) {
+	authenticator, err := auth.NewHS256Authenticator([]byte(testSecret), auth.JWTOptions{Issuer: "ec_api"})
+	assert.NoError(t, err)
+
+	// Expired token
+	_, err = authenticator.Authenticate(signToken(t, jwt.MapClaims{
+		"sub": "user_123",
+		"iss": "ec_api",
+		"exp": time.Now().Add(-time.Hour).Unix(),
+	}))
+	assert.ErrorIs(t, err, auth.ErrInvalidToken)
+
+	// Wrong issuer
+	_, err = authenticator.Authenticate(signToken(t, jwt.MapClaims{
+		"sub": "user_123",
+		"iss": "someone-else",
+	}))
+	assert.ErrorIs(t, err, auth.ErrInvalidToken)
+
+	// Missing subject
+	_, err = authenticator.Authenticate(signToken(t, jwt.MapClaims{
+		"iss": "ec_api",
+	}))
+	assert.ErrorIs(t, err, auth.ErrInvalidToken)
+
+	// Signed with another secret
+	other, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "user_123", "iss": "ec_api"}).SignedString([]byte("other"))
+	_, err = authenticator.Authenticate(other)
+	assert.ErrorIs(t, err, auth.ErrInvalidToken)
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	authenticator, _ := auth.NewHS256Authenticator([]byte(testSecret), auth.JWTOptions{})
+
+	// Initialize new Echo application with a handler echoing the user id
+	e := echo.New()
+	handler := func(c echo.Context) error {
+		user, err := auth.CurrentUser(c)
+		if err != nil {
+			return c.String(http.StatusOK, "anonymous")
+		}
+		return c.String(http.StatusOK, user.ID)
+	}
+	e.GET("/private", handler, auth.AuthMiddleware(authenticator))
+	e.GET("/public", handler, auth.OptionalAuthMiddleware(authenticator))
+
+	token := signToken(t, jwt.MapClaims{"sub": "user_123"})
+
+	cases := []struct {
+		path   string
+		token  string
+		status int
+		body   string
+	}{
+		{"/private", token, http.StatusOK, "user_123"},
+		{"/private", "", http.StatusUnauthorized, ""},
+		{"/public", token, http.StatusOK, "user_123"},
+		{"/public", "", http.StatusOK, "anonymous"},
+		{"/public", "invalid", http.StatusOK, "anonymous"},
+	}
+
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		if tc.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+		}
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		assert.Equal(t, tc.status, rec.Code, tc.path)
+		if tc.body != "" {
+			assert.Equal(t, tc.body, rec.Body.String(), tc.path)
+		}
+	}
+}
+
+// Ensure both implementations satisfy the Authenticator interface
+var (
+	_ auth.Authenticator = (*auth.JWTAuthenticator)(nil)
+	_ auth.Authenticator = auth.NewClerkAuthenticator((clerk.Client)(nil))
+)
@@ -0,0 +1,174 @@
+package tests
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/haseakito/ec_api/bulk"
+	"github.com/haseakito/ec_api/models"
+	"github.com/haseakito/ec_api/requests"
+	"github.com/haseakito/ec_api/utils"
+)
+
+func TestReadRowsCSV(t *testing.T) {
+	input := "\ufeffSKU,name,price,published,image_urls\n" +
+		"tee-1,T-shirt,19.99,true,https://example.com/a.png | https://example.com/b.png\n" +
+		"tee-2,\"Multi\nline\",5,maybe,\n" +
+		"tee-3,Short\n" +
+		",Mug,,,\n"
+
+	lines, err := bulk.ReadRows([]byte(input), bulk.FormatCSV)
+	if !assert.NoError(t, err) || !assert.Len(t, lines, 4) {
+		return
+	}
+
+	published := true
+
+	assert.NoError(t, lines[0].Err)
+	assert.Equal(t, 2, lines[0].Number)
+	assert.Equal(t, bulk.Row{
+		SKU:       "tee-1",
+		Name:      "T-shirt",
+		Price:     json.Number("19.99"),
+		Published: &published,
+		ImageURLs: []string{"https://example.com/a.png", "https://example.com/b.png"},
+	}, lines[0].Row)
+
+	// Line numbers are the first line of the rows, and invalid cells are reported with their row
+	assert.Equal(t, 3, lines[1].Number)
+	assert.EqualError(t, lines[1].Err, `published: expected true or false but found "maybe"`)
+	assert.Equal(t, 5, lines[2].Number)
+	assert.EqualError(t, lines[2].Err, "expected 5 fields but found 2")
+
+	// Empty publications are left unset, so that the publication of existing products is kept
+	assert.NoError(t, lines[3].Err)
+	assert.Equal(t, bulk.Row{Name: "Mug"}, lines[3].Row)
+}
+
+func TestReadRowsCSVHeader(t *testing.T) {
+	for input, message := range map[string]string{
+		"":                 "bulk: the file is empty",
+		"sku,price\n":      `bulk: missing column "name"`,
+		"name,color\n":     `bulk: unknown column "color"`,
+		"name,sku,Name\n":  `bulk: duplicate column "name"`,
+		"name,\"sku\n":     "bulk: invalid header",
+		"name,price,sku\n": "",
+	} {
+		lines, err := bulk.ReadRows([]byte(input), bulk.FormatCSV)
+		if message == "" {
+			assert.NoError(t, err, input)
+			assert.Empty(t, lines, input)
+			continue
+		}
+		if assert.Error(t, err, input) {
+			assert.Contains(t, err.Error(), message, input)
+		}
+	}
+
+	_, err := bulk.ReadRows([]byte("name\n"), "xlsx")
+	assert.ErrorIs(t, err, bulk.ErrUnsupportedFormat)
+}
+
+func TestReadRowsNDJSON(t *testing.T) {
+	input := `{"sku": "tee-1", "name": "T-shirt", "price": "19.99", "published": true}
+
+{"name": "Mug", "price": 5, "category": "kitchen", "image_urls": ["https://example.com/a.png"]}
+{"name": "Hat", "color": "red"}
+{"name": "Cap"} {"name": "Bag"}
+not json
+`
+
+	lines, err := bulk.ReadRows([]byte(input), bulk.FormatNDJSON)
+	if !assert.NoError(t, err) || !assert.Len(t, lines, 5) {
+		return
+	}
+
+	// Blank lines are skipped but counted in the line numbers, and prices may be strings or numbers
+	published := true
+	assert.NoError(t, lines[0].Err)
+	assert.Equal(t, 1, lines[0].Number)
+	assert.Equal(t, bulk.Row{SKU: "tee-1", Name: "T-shirt", Price: json.Number("19.99"), Published: &published}, lines[0].Row)
+	assert.Nil(t, lines[1].Row.Published)
+
+	assert.NoError(t, lines[1].Err)
+	assert.Equal(t, 3, lines[1].Number)
+	assert.Equal(t, json.Number("5"), lines[1].Row.Price)
+	assert.Equal(t, "kitchen", lines[1].Row.Category)
+	assert.Equal(t, []string{"https://example.com/a.png"}, lines[1].Row.ImageURLs)
+
+	assert.Equal(t, 4, lines[2].Number)
+	assert.ErrorContains(t, lines[2].Err, `unknown field "color"`)
+	assert.EqualError(t, lines[3].Err, "expected a single JSON object")
+	assert.Equal(t, 6, lines[4].Number)
+	assert.Error(t, lines[4].Err)
+}
+
+func TestNewRow(t *testing.T) {
+	sku := "tee-1"
+	description := "Soft, \"organic\" cotton"
+	price := int64(1999)
+	product := models.Product{
+		SKU:         &sku,
+		Name:        "T-shirt",
+		Description: &description,
+		Price:       &price,
+		Published:   true,
+		Categories:  []models.Category{{Slug: "apparel"}, {Slug: "tops"}},
+		ProductImages: []models.ProductImage{
+			{Url: "https://bucket.s3.amazonaws.com/products/a.png"},
+			{Url: "https://bucket.s3.amazonaws.com/products/b.png"},
+		},
+	}
+
+	row := bulk.NewRow(product, "usd")
+	assert.Equal(t, bulk.Row{
+		SKU:         "tee-1",
+		Name:        "T-shirt",
+		Description: &description,
+		Price:       json.Number("19.99"),
+		Published:   &product.Published,
+		Category:    "apparel",
+		ImageURLs:   []string{"https://bucket.s3.amazonaws.com/products/a.png", "https://bucket.s3.amazonaws.com/products/b.png"},
+	}, row)
+
+	// Exported rows are read back as they were
+	encoded, err := json.Marshal(row)
+	assert.NoError(t, err)
+	lines, err := bulk.ReadRows(encoded, bulk.FormatNDJSON)
+	if assert.NoError(t, err) && assert.Len(t, lines, 1) {
+		assert.NoError(t, lines[0].Err)
+		assert.Equal(t, row, lines[0].Row)
+	}
+
+	// Exported rows always give the publication, so that they are imported back as they were
+	unpublished := false
+	assert.Equal(t, bulk.Row{Name: "Mug", Published: &unpublished}, bulk.NewRow(models.Product{Name: "Mug"}, "usd"))
+}
+
+func TestProductCreateRequestSKU(t *testing.T) {
+	sku := "tee-1"
+	assert.NoError(t, requests.ProductCreateRequest{Name: "T-shirt", SKU: &sku}.Validate())
+
+	empty := ""
+	assert.Error(t, requests.ProductCreateRequest{Name: "T-shirt", SKU: &empty}.Validate())
+}
+
+func TestUploadFromURLRejectsInternalAddresses(t *testing.T) {
+	for _, ip := range []string{"127.0.0.1", "10.0.0.8", "172.16.4.2", "192.168.1.1", "169.254.169.254", "100.64.0.1", "0.0.0.0", "::1", "fe80::1", "fd00::1", "::ffff:127.0.0.1"} {
+		assert.False(t, utils.IsPublicIP(net.ParseIP(ip)), ip)
+	}
+	for _, ip := range []string{"93.184.216.34", "2606:2800:220:1:248:1893:25c8:1946"} {
+		assert.True(t, utils.IsPublicIP(net.ParseIP(ip)), ip)
+	}
+
+	// The download is refused without details, so that the internal network cannot be probed
+	_, err := utils.UploadFromURL("http://169.254.169.254/latest/meta-data/image.png", "products/")
+	assert.ErrorIs(t, err, utils.ErrImageDownload)
+	_, err = utils.UploadFromURL("http://127.0.0.1:5432/a.png", "products/")
+	assert.ErrorIs(t, err, utils.ErrImageDownload)
+	_, err = utils.UploadFromURL("file:///etc/passwd", "products/")
+	assert.ErrorIs(t, err, utils.ErrImageURL)
+}
@@ -0,0 +1,95 @@
+package tests
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+
+	"github.com/haseakito/ec_api/catalog"
+	"github.com/haseakito/ec_api/models"
+	"github.com/haseakito/ec_api/requests"
+)
+
+// dryRunDB builds the SQL of queries without a database connection
+func dryRunDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	require.NoError(t, err)
+	return db
+}
+
+func TestCatalogFilterScope(t *testing.T) {
+	db := dryRunDB(t)
+	minPrice, maxPrice, rating := int64(1000), int64(5000), 4.0
+
+	filter := catalog.Filter{
+		CategoryID: "shirts",
+		Tags:       []string{"sale"},
+		MinPrice:   &minPrice,
+		MaxPrice:   &maxPrice,
+		InStock:    true,
+		MinRating:  &rating,
+	}
+
+	var products []models.Product
+	sql := db.Scopes(filter.Scope).Find(&products).Statement.SQL.String()
+	assert.Contains(t, sql, "WITH RECURSIVE tree")
+	assert.Contains(t, sql, "products.tags @>")
+	assert.Contains(t, sql, "products.price >=")
+	assert.Contains(t, sql, "products.price <=")
+	assert.Contains(t, sql, "products.stock > products.reserved")
+	assert.Contains(t, sql, "AVG(reviews.rating)")
+
+	// Without filters the query is not restricted beyond hiding the trashed products
+	sql = db.Scopes(catalog.Filter{}.Scope).Find(&products).Statement.SQL.String()
+	assert.Equal(t, `SELECT * FROM "products" WHERE "products"."deleted_at" IS NULL`, sql)
+}
+
+func TestCatalogSort(t *testing.T) {
+	db := dryRunDB(t)
+	var products []models.Product
+
+	cases := map[string]string{
+		catalog.SortPriceAsc:    "ORDER BY products.price ASC NULLS LAST,products.id",
+		catalog.SortPriceDesc:   "ORDER BY products.price DESC NULLS LAST,products.id",
+		catalog.SortBestSelling: "SUM(order_items.quantity)",
+		catalog.SortTopRated:    "DESC NULLS LAST,products.id",
+		"":                      "ORDER BY products.created_at DESC,products.id",
+	}
+	for sort, expected := range cases {
+		sql := db.Scopes(catalog.Sort(sort)).Find(&products).Statement.SQL.String()
+		assert.Contains(t, sql, expected, sort)
+	}
+}
+
+func TestProductListRequest(t *testing.T) {
+	minPrice, maxPrice := int64(5000), int64(1000)
+	assert.Error(t, requests.ProductListRequest{MinPrice: &minPrice, MaxPrice: &maxPrice}.Validate())
+	assert.Error(t, requests.ProductListRequest{Sort: "cheapest"}.Validate())
+
+	rating := 6.0
+	assert.Error(t, requests.ProductListRequest{MinRating: &rating}.Validate())
+
+	assert.NoError(t, requests.ProductListRequest{MinPrice: &maxPrice, MaxPrice: &minPrice, Sort: catalog.SortTopRated}.Validate())
+
+	assert.Equal(t, []string{"sale", "summer"}, requests.ProductListRequest{Tags: " Sale,summer,,sale"}.TagList())
+	assert.Nil(t, requests.ProductListRequest{}.TagList())
+}
+
+func TestVariantSKUIndexPerStore(t *testing.T) {
+	s, err := schema.Parse(&models.ProductVariant{}, &sync.Map{}, schema.NamingStrategy{})
+	require.NoError(t, err)
+
+	// The SKUs of variants are unique per store, like the SKUs of products
+	index := s.LookIndex("idx_product_variants_store_sku")
+	require.NotNil(t, index)
+	assert.Equal(t, "UNIQUE", index.Class)
+	require.Len(t, index.Fields, 2)
+	assert.Equal(t, "store_id", index.Fields[0].DBName)
+	assert.Equal(t, "sku", index.Fields[1].DBName)
+	assert.Nil(t, s.LookIndex("idx_product_variants_sku"))
+}
@@ -0,0 +1,44 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/haseakito/ec_api/models"
+	"github.com/haseakito/ec_api/utils"
+)
+
+func TestBuildCategoryTree(t *testing.T) {
+	clothing, shirts, missing := "clothing", "shirts", "missing"
+	categories := []models.Category{
+		{Model: models.Model{ID: "tees"}, ParentID: &shirts, Position: 1},
+		{Model: models.Model{ID: "polos"}, ParentID: &shirts, Position: 0},
+		{Model: models.Model{ID: "shirts"}, ParentID: &clothing},
+		{Model: models.Model{ID: "mugs"}, Position: 1},
+		{Model: models.Model{ID: "clothing"}, Position: 0},
+		{Model: models.Model{ID: "orphan"}, ParentID: &missing, Position: 2},
+	}
+
+	tree := models.BuildCategoryTree(categories)
+
+	// Roots are ordered by position, and categories with an unknown parent are roots
+	assert.Len(t, tree, 3)
+	assert.Equal(t, "clothing", tree[0].ID)
+	assert.Equal(t, "mugs", tree[1].ID)
+	assert.Equal(t, "orphan", tree[2].ID)
+
+	// Children are nested and ordered by position
+	assert.Len(t, tree[0].Children, 1)
+	assert.Equal(t, "shirts", tree[0].Children[0].ID)
+	assert.Len(t, tree[0].Children[0].Children, 2)
+	assert.Equal(t, "polos", tree[0].Children[0].Children[0].ID)
+	assert.Equal(t, "tees", tree[0].Children[0].Children[1].ID)
+}
+
+func TestSlugify(t *testing.T) {
+	assert.Equal(t, "summer-t-shirts", utils.Slugify("Summer T-Shirts!"))
+	assert.Equal(t, "mugs-cups", utils.Slugify("  Mugs & Cups  "))
+	assert.Equal(t, "café", utils.Slugify("Café"))
+	assert.Equal(t, "", utils.Slugify("!!!"))
+}
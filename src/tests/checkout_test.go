@@ -0,0 +1,48 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/haseakito/ec_api/requests"
+)
+
+func TestCheckoutLinesAggregateDuplicates(t *testing.T) {
+	small, large := "small", "large"
+	req := requests.CheckoutCreateRequest{
+		Items: []requests.CheckoutItem{
+			{ProductID: "tee", VariantID: &small, Quantity: 1},
+			{ProductID: "mug", Quantity: 2},
+			{ProductID: "tee", VariantID: &large, Quantity: 1},
+			{ProductID: "tee", VariantID: &small, Quantity: 2},
+			{ProductID: "mug", Quantity: 1},
+		},
+	}
+
+	lines := req.Lines()
+	assert.Len(t, lines, 3)
+
+	// Lines keep the order of their first appearance
+	assert.Equal(t, "tee", lines[0].ProductID)
+	assert.Equal(t, small, *lines[0].VariantID)
+	assert.Equal(t, 3, lines[0].Quantity)
+
+	assert.Equal(t, "mug", lines[1].ProductID)
+	assert.Nil(t, lines[1].VariantID)
+	assert.Equal(t, 3, lines[1].Quantity)
+
+	assert.Equal(t, large, *lines[2].VariantID)
+	assert.Equal(t, 1, lines[2].Quantity)
+}
+
+func TestCheckoutValidation(t *testing.T) {
+	assert.Error(t, requests.CheckoutCreateRequest{}.Validate())
+
+	// Every item requires a product and a positive quantity
+	assert.Error(t, requests.CheckoutCreateRequest{Items: []requests.CheckoutItem{{ProductID: "tee"}}}.Validate())
+	assert.Error(t, requests.CheckoutCreateRequest{Items: []requests.CheckoutItem{{Quantity: 1}}}.Validate())
+	assert.Error(t, requests.CheckoutCreateRequest{Items: []requests.CheckoutItem{{ProductID: "tee", Quantity: -1}}}.Validate())
+
+	assert.NoError(t, requests.CheckoutCreateRequest{Items: []requests.CheckoutItem{{ProductID: "tee", Quantity: 2}}}.Validate())
+}
@@ -0,0 +1,87 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/haseakito/ec_api/catalog"
+	"github.com/haseakito/ec_api/models"
+	"github.com/haseakito/ec_api/requests"
+)
+
+// ruleSQL parses the rule and renders the query it scopes
+func ruleSQL(t *testing.T, rule string, currency string) (string, []interface{}) {
+	t.Helper()
+
+	parsed, err := catalog.ParseRule(rule, currency)
+	if !assert.NoError(t, err, rule) {
+		return "", nil
+	}
+
+	var products []models.Product
+	stmt := dryRunDB(t).Scopes(parsed.Scope).Find(&products).Statement
+	return stmt.SQL.String(), stmt.Vars
+}
+
+func TestParseRule(t *testing.T) {
+	// Prices are converted into minor units of the currency of the store
+	sql, vars := ruleSQL(t, "tag = sale AND price < 20", "usd")
+	assert.Contains(t, sql, "WHERE (((products.tags @> $1::text[]) AND (products.price < $2)))")
+	assert.Equal(t, models.StringArray{"sale"}, vars[0])
+	assert.Equal(t, int64(2000), vars[1])
+
+	_, vars = ruleSQL(t, "price <= 1500", "jpy")
+	assert.Equal(t, int64(1500), vars[0])
+
+	// AND binds tighter than OR, and parentheses override it
+	sql, _ = ruleSQL(t, "tag = a OR tag = b AND tag = c", "usd")
+	assert.Contains(t, sql, "((products.tags @> $1::text[]) OR ((products.tags @> $2::text[]) AND (products.tags @> $3::text[])))")
+	sql, _ = ruleSQL(t, "(tag = a OR tag = b) AND tag = c", "usd")
+	assert.Contains(t, sql, "(((products.tags @> $1::text[]) OR (products.tags @> $2::text[])) AND (products.tags @> $3::text[]))")
+
+	// Keywords are case-insensitive, values may be quoted, and tags are normalized
+	sql, vars = ruleSQL(t, `not TAG != "Staff Picks" and in_stock = true`, "usd")
+	assert.Contains(t, sql, "(NOT NOT (products.tags @> $1::text[]) AND (")
+	assert.Equal(t, models.StringArray{"staff picks"}, vars[0])
+
+	sql, vars = ruleSQL(t, "rating >= 4.5 OR category != 'shoes'", "usd")
+	assert.Contains(t, sql, "AVG(reviews.rating)")
+	assert.Contains(t, sql, "OR NOT (products.id IN (")
+	assert.Equal(t, []interface{}{4.5, "shoes"}, vars)
+
+	sql, _ = ruleSQL(t, "in_stock = false", "usd")
+	assert.Contains(t, sql, "WHERE (NOT (")
+}
+
+func TestParseRuleErrors(t *testing.T) {
+	for rule, message := range map[string]string{
+		"":                      `expected a field but found "end of rule" at position 1`,
+		"tag = sale AND":        `expected a field but found "end of rule" at position 15`,
+		"color = red":           `unknown field "color" at position 1`,
+		"tag < sale":            `tag only supports = and != at position 5`,
+		"price < 19.999":        `invalid price "19.999" at position 9`,
+		"rating > 6":            `invalid rating "6" at position 10`,
+		"in_stock = maybe":      `expected true or false but found "maybe" at position 12`,
+		"(tag = a":              `expected ")" but found "end of rule" at position 9`,
+		"tag = a tag = b":       `unexpected "tag" at position 9`,
+		"tag = 'sale":           `unterminated string at position 7`,
+		"tag ! sale":            `unexpected "!" at position 5`,
+		"price = = 10":          `expected a value but found "=" at position 9`,
+		"tag = sale OR (price)": `expected an operator but found ")" at position 21`,
+	} {
+		_, err := catalog.ParseRule(rule, "usd")
+		if assert.ErrorIs(t, err, catalog.ErrInvalidRule, rule) {
+			assert.Equal(t, "catalog: invalid rule: "+message, err.Error(), rule)
+		}
+	}
+}
+
+func TestCollectionCreateRequest(t *testing.T) {
+	assert.NoError(t, requests.CollectionCreateRequest{Name: "Summer Sale", Sort: catalog.SortPriceAsc}.Validate())
+	assert.NoError(t, requests.CollectionCreateRequest{Name: "Staff Picks", Sort: models.CollectionSortManual}.Validate())
+
+	assert.Error(t, requests.CollectionCreateRequest{}.Validate())
+	assert.Error(t, requests.CollectionCreateRequest{Name: "Sale", Slug: "Summer Sale"}.Validate())
+	assert.Error(t, requests.CollectionCreateRequest{Name: "Sale", Sort: catalog.SortRelevance}.Validate())
+}
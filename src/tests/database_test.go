@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/haseakito/ec_api/database"
+	"github.com/haseakito/ec_api/models"
+)
+
+var (
+	testDBOnce sync.Once
+	testDBConn *gorm.DB
+)
+
+// testDB connects to the test database given by DB_URL and migrates it once, skipping the test when no database is configured
+func testDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	if os.Getenv("DB_URL") == "" {
+		t.Skip("DB_URL is not set")
+	}
+
+	testDBOnce.Do(func() {
+		testDBConn = database.Init()
+	})
+
+	return testDBConn
+}
+
+// createTestStore creates a store, deleted with all its rows at the end of the test
+func createTestStore(t *testing.T, db *gorm.DB) models.Store {
+	t.Helper()
+
+	store := models.Store{UserID: "user_test", Name: "Test store", Currency: "usd"}
+	require.NoError(t, db.Create(&store).Error)
+
+	t.Cleanup(func() {
+		for _, sql := range []string{
+			"DELETE FROM stock_reservations WHERE order_id IN (SELECT id FROM orders WHERE store_id = ?)",
+			"DELETE FROM order_items WHERE order_id IN (SELECT id FROM orders WHERE store_id = ?)",
+			"DELETE FROM orders WHERE store_id = ?",
+			"DELETE FROM product_variants WHERE store_id = ?",
+			"DELETE FROM products WHERE store_id = ?",
+			"DELETE FROM audit_events WHERE store_id = ?",
+			"DELETE FROM stores WHERE id = ?",
+		} {
+			if err := db.Exec(sql, store.ID).Error; err != nil {
+				t.Log("failed to clean up the test store:", err)
+			}
+		}
+	})
+
+	return store
+}
+
+// createTestProduct creates a published product of the store, with the stock given
+func createTestProduct(t *testing.T, db *gorm.DB, storeID string, name string, stock *int) models.Product {
+	t.Helper()
+
+	price := int64(1000)
+	product := models.Product{StoreID: storeID, Name: name, Price: &price, Published: true, Stock: stock, Tags: models.StringArray{}}
+	require.NoError(t, db.Create(&product).Error)
+
+	return product
+}
+
+// createTestOrder creates an order of the store
+func createTestOrder(t *testing.T, db *gorm.DB, storeID string, paid bool) models.Order {
+	t.Helper()
+
+	order := models.Order{StoreID: storeID, UserID: "user_test", Paid: paid, Currency: "usd"}
+	require.NoError(t, db.Create(&order).Error)
+
+	return order
+}
@@ -0,0 +1,74 @@
+package tests
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/haseakito/ec_api/drafts"
+	"github.com/haseakito/ec_api/models"
+	"github.com/haseakito/ec_api/revisions"
+)
+
+func TestPreviewToken(t *testing.T) {
+	now := time.Now()
+
+	// Previews cannot be signed nor verified without a secret
+	t.Setenv("PREVIEW_SIGNING_SECRET", "")
+	_, err := drafts.SignPreview("p1", now.Add(time.Hour))
+	assert.ErrorIs(t, err, drafts.ErrNoPreviewSecret)
+
+	t.Setenv("PREVIEW_SIGNING_SECRET", "secret")
+	token, err := drafts.SignPreview("p1", now.Add(time.Hour))
+	require.NoError(t, err)
+	assert.NoError(t, drafts.VerifyPreview(token, "p1", now))
+
+	// The token is only valid for its product and until it expires
+	assert.ErrorIs(t, drafts.VerifyPreview(token, "p2", now), drafts.ErrInvalidPreview)
+	assert.ErrorIs(t, drafts.VerifyPreview(token, "p1", now.Add(2*time.Hour)), drafts.ErrInvalidPreview)
+
+	// Tampered and malformed tokens are rejected
+	expired, err := drafts.SignPreview("p1", now.Add(-time.Minute))
+	require.NoError(t, err)
+	assert.ErrorIs(t, drafts.VerifyPreview(expired, "p1", now), drafts.ErrInvalidPreview)
+	assert.ErrorIs(t, drafts.VerifyPreview(token+"x", "p1", now), drafts.ErrInvalidPreview)
+	assert.ErrorIs(t, drafts.VerifyPreview("garbage", "p1", now), drafts.ErrInvalidPreview)
+
+	// Changing the secret revokes the tokens
+	t.Setenv("PREVIEW_SIGNING_SECRET", "rotated")
+	assert.ErrorIs(t, drafts.VerifyPreview(token, "p1", now), drafts.ErrInvalidPreview)
+}
+
+func TestPreviewURL(t *testing.T) {
+	// Preview URLs are built from the public URL of the API only
+	t.Setenv("PUBLIC_API_URL", "https://api.example.com/")
+	previewURL, err := drafts.PreviewURL("p1", "123.sig")
+	require.NoError(t, err)
+	assert.Equal(t, "https://api.example.com/api/v1/products/p1?preview=123.sig", previewURL)
+
+	for _, invalid := range []string{"", "api.example.com", "ftp://api.example.com"} {
+		t.Setenv("PUBLIC_API_URL", invalid)
+		_, err := drafts.PreviewURL("p1", "123.sig")
+		assert.ErrorIs(t, err, drafts.ErrNoPublicURL, invalid)
+	}
+}
+
+func TestApplyDraft(t *testing.T) {
+	price := int64(3000)
+	snapshot, err := json.Marshal(revisions.Snapshot{Name: "New Tee", Price: &price, Published: true, Tags: models.StringArray{"new"}})
+	require.NoError(t, err)
+	draft := models.ProductDraft{ProductID: "p1", Snapshot: models.JSON(snapshot)}
+
+	// The staged edits are applied, and the publication of the product is kept as is
+	stock := 4
+	product := models.Product{Name: "Tee", Published: false, Stock: &stock}
+	require.NoError(t, drafts.Apply(draft, &product))
+	assert.Equal(t, "New Tee", product.Name)
+	assert.Equal(t, &price, product.Price)
+	assert.Equal(t, models.StringArray{"new"}, product.Tags)
+	assert.False(t, product.Published)
+	assert.Equal(t, 4, *product.Stock)
+}
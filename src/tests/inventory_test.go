@@ -0,0 +1,202 @@
+package tests
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/haseakito/ec_api/inventory"
+	"github.com/haseakito/ec_api/models"
+)
+
+func TestReservationTTLFromEnv(t *testing.T) {
+	// Defaults to just above the minimum expiry of Stripe checkout sessions
+	t.Setenv("CHECKOUT_RESERVATION_TTL", "")
+	assert.Equal(t, inventory.MinReservationTTL, inventory.ReservationTTLFromEnv())
+	assert.Greater(t, inventory.MinReservationTTL, 30*time.Minute)
+
+	t.Setenv("CHECKOUT_RESERVATION_TTL", "45m")
+	assert.Equal(t, 45*time.Minute, inventory.ReservationTTLFromEnv())
+
+	// Clamped to the bounds of Stripe checkout sessions
+	t.Setenv("CHECKOUT_RESERVATION_TTL", "5m")
+	assert.Equal(t, inventory.MinReservationTTL, inventory.ReservationTTLFromEnv())
+
+	t.Setenv("CHECKOUT_RESERVATION_TTL", "48h")
+	assert.Equal(t, inventory.MaxReservationTTL, inventory.ReservationTTLFromEnv())
+
+	// Invalid durations fall back to the default
+	t.Setenv("CHECKOUT_RESERVATION_TTL", "soon")
+	assert.Equal(t, inventory.MinReservationTTL, inventory.ReservationTTLFromEnv())
+}
+
+// stockOf reads the stock and the reserved units of a product
+func stockOf(t *testing.T, db *gorm.DB, productID string) (int, int) {
+	t.Helper()
+
+	var product models.Product
+	require.NoError(t, db.Unscoped().Take(&product, "id = ?", productID).Error)
+	require.NotNil(t, product.Stock)
+	return *product.Stock, product.Reserved
+}
+
+// reservationStatuses reads the statuses of the reservations of an order
+func reservationStatuses(t *testing.T, db *gorm.DB, orderID string) []string {
+	t.Helper()
+
+	var statuses []string
+	require.NoError(t, db.Model(&models.StockReservation{}).Where("order_id = ?", orderID).Pluck("status", &statuses).Error)
+	return statuses
+}
+
+func TestReserveReleaseCommit(t *testing.T) {
+	db := testDB(t)
+	store := createTestStore(t, db)
+	stock := 5
+	product := createTestProduct(t, db, store.ID, "Tee", &stock)
+	expiresAt := time.Now().Add(time.Hour)
+
+	// Reserved units are no longer available to other orders
+	first := createTestOrder(t, db, store.ID, false)
+	require.NoError(t, inventory.Reserve(db, first.ID, product.ID, nil, 3, expiresAt))
+	second := createTestOrder(t, db, store.ID, false)
+	assert.ErrorIs(t, inventory.Reserve(db, second.ID, product.ID, nil, 3, expiresAt), inventory.ErrOutOfStock)
+
+	onHand, reserved := stockOf(t, db, product.ID)
+	assert.Equal(t, 5, onHand)
+	assert.Equal(t, 3, reserved)
+
+	// Releasing makes the units available again, once
+	require.NoError(t, inventory.Release(db, first.ID))
+	require.NoError(t, inventory.Release(db, first.ID))
+	_, reserved = stockOf(t, db, product.ID)
+	assert.Equal(t, 0, reserved)
+	assert.Equal(t, []string{models.ReservationReleased}, reservationStatuses(t, db, first.ID))
+
+	// Committing takes the units from stock, once
+	require.NoError(t, inventory.Reserve(db, second.ID, product.ID, nil, 3, expiresAt))
+	require.NoError(t, inventory.Commit(db, second.ID))
+	require.NoError(t, inventory.Commit(db, second.ID))
+	onHand, reserved = stockOf(t, db, product.ID)
+	assert.Equal(t, 2, onHand)
+	assert.Equal(t, 0, reserved)
+	assert.Equal(t, []string{models.ReservationCommitted}, reservationStatuses(t, db, second.ID))
+}
+
+func TestReleaseExpired(t *testing.T) {
+	db := testDB(t)
+	store := createTestStore(t, db)
+	stock := 5
+	product := createTestProduct(t, db, store.ID, "Tee", &stock)
+
+	expired := createTestOrder(t, db, store.ID, false)
+	require.NoError(t, inventory.Reserve(db, expired.ID, product.ID, nil, 2, time.Now().Add(-time.Hour)))
+	pending := createTestOrder(t, db, store.ID, false)
+	require.NoError(t, inventory.Reserve(db, pending.ID, product.ID, nil, 1, time.Now().Add(time.Hour)))
+
+	// Only the expired reservations are released, and their unpaid orders are cancelled
+	require.NoError(t, inventory.ReleaseExpired(db, time.Now()))
+	_, reserved := stockOf(t, db, product.ID)
+	assert.Equal(t, 1, reserved)
+	assert.Equal(t, []string{models.ReservationReleased}, reservationStatuses(t, db, expired.ID))
+	assert.Equal(t, []string{models.ReservationActive}, reservationStatuses(t, db, pending.ID))
+
+	require.NoError(t, db.Take(&expired, "id = ?", expired.ID).Error)
+	assert.NotNil(t, expired.CanceledAt)
+	require.NoError(t, db.Take(&pending, "id = ?", pending.ID).Error)
+	assert.Nil(t, pending.CanceledAt)
+
+	// A cancelled order no longer prevents the deletion of its store
+	var open int64
+	require.NoError(t, db.Model(&models.Order{}).Scopes(models.OpenOrders).Where("id = ?", expired.ID).Count(&open).Error)
+	assert.Zero(t, open)
+}
+
+func TestCommitAfterReleaseOversold(t *testing.T) {
+	db := testDB(t)
+	store := createTestStore(t, db)
+	stock := 2
+	product := createTestProduct(t, db, store.ID, "Tee", &stock)
+
+	// The reservation of the late order expires, and its units are sold to another order
+	late := createTestOrder(t, db, store.ID, false)
+	require.NoError(t, inventory.Reserve(db, late.ID, product.ID, nil, 2, time.Now().Add(-time.Hour)))
+	require.NoError(t, inventory.ReleaseExpired(db, time.Now()))
+
+	other := createTestOrder(t, db, store.ID, false)
+	require.NoError(t, inventory.Reserve(db, other.ID, product.ID, nil, 2, time.Now().Add(time.Hour)))
+	require.NoError(t, inventory.Commit(db, other.ID))
+
+	// The late payment never drives the stock negative, and flags the order as oversold instead
+	require.NoError(t, inventory.Commit(db, late.ID))
+	onHand, reserved := stockOf(t, db, product.ID)
+	assert.Equal(t, 0, onHand)
+	assert.Equal(t, 0, reserved)
+	assert.Equal(t, []string{models.ReservationOversold}, reservationStatuses(t, db, late.ID))
+
+	require.NoError(t, db.Take(&late, "id = ?", late.ID).Error)
+	assert.True(t, late.Oversold)
+	require.NoError(t, db.Take(&other, "id = ?", other.ID).Error)
+	assert.False(t, other.Oversold)
+}
+
+func TestCommitAfterReleaseInStock(t *testing.T) {
+	db := testDB(t)
+	store := createTestStore(t, db)
+	stock := 3
+	product := createTestProduct(t, db, store.ID, "Tee", &stock)
+
+	// A late payment takes the units from stock directly while they are still available
+	late := createTestOrder(t, db, store.ID, false)
+	require.NoError(t, inventory.Reserve(db, late.ID, product.ID, nil, 2, time.Now().Add(-time.Hour)))
+	require.NoError(t, inventory.ReleaseExpired(db, time.Now()))
+	require.NoError(t, inventory.Commit(db, late.ID))
+
+	onHand, reserved := stockOf(t, db, product.ID)
+	assert.Equal(t, 1, onHand)
+	assert.Equal(t, 0, reserved)
+	assert.Equal(t, []string{models.ReservationCommitted}, reservationStatuses(t, db, late.ID))
+}
+
+func TestConcurrentReserve(t *testing.T) {
+	db := testDB(t)
+	store := createTestStore(t, db)
+	stock := 5
+	product := createTestProduct(t, db, store.ID, "Tee", &stock)
+
+	// Concurrent checkouts never reserve more units than on hand
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	reservedOrders, outOfStock := 0, 0
+	for i := 0; i < 12; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := db.Transaction(func(tx *gorm.DB) error {
+				order := models.Order{StoreID: store.ID, UserID: "user_test", Currency: "usd"}
+				if err := tx.Create(&order).Error; err != nil {
+					return err
+				}
+				return inventory.Reserve(tx, order.ID, product.ID, nil, 1, time.Now().Add(time.Hour))
+			})
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err == nil {
+				reservedOrders++
+			} else if assert.ErrorIs(t, err, inventory.ErrOutOfStock) {
+				outOfStock++
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 5, reservedOrders)
+	assert.Equal(t, 7, outOfStock)
+	_, reserved := stockOf(t, db, product.ID)
+	assert.Equal(t, 5, reserved)
+}
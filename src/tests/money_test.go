@@ -0,0 +1,63 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/haseakito/ec_api/money"
+)
+
+func TestMoneyParse(t *testing.T) {
+	cases := []struct {
+		input    string
+		currency string
+		amount   int64
+	}{
+		// 19.99 is not representable as a float, but parses exactly
+		{"19.99", "usd", 1999},
+		{"0.1", "usd", 10},
+		{"20", "usd", 2000},
+		{".5", "eur", 50},
+		{"19.990", "usd", 1999},
+		{"-3.25", "usd", -325},
+		{"1999", "jpy", 1999},
+		{"1999", "JPY", 1999},
+		{"1.234", "kwd", 1234},
+	}
+
+	for _, c := range cases {
+		amount, err := money.Parse(c.input, c.currency)
+		assert.NoError(t, err, c.input)
+		assert.Equal(t, c.amount, amount, c.input)
+	}
+
+	// Amounts with more decimals than the currency allows are rejected
+	for _, input := range []string{"19.999", "abc", "", "1.2.3", "1e3"} {
+		_, err := money.Parse(input, "usd")
+		assert.ErrorIs(t, err, money.ErrInvalidAmount, input)
+	}
+
+	_, err := money.Parse("1999.5", "jpy")
+	assert.ErrorIs(t, err, money.ErrInvalidAmount)
+}
+
+func TestMoneyFormat(t *testing.T) {
+	assert.Equal(t, "19.99", money.Format(1999, "usd"))
+	assert.Equal(t, "0.05", money.Format(5, "usd"))
+	assert.Equal(t, "-1.00", money.Format(-100, "eur"))
+	assert.Equal(t, "1999", money.Format(1999, "jpy"))
+	assert.Equal(t, "1.234", money.Format(1234, "kwd"))
+	assert.Equal(t, "19.99", money.Money{Amount: 1999, Currency: "usd"}.String())
+}
+
+func TestMoneyCurrencies(t *testing.T) {
+	assert.True(t, money.IsSupported("usd"))
+	assert.True(t, money.IsSupported("JPY"))
+	assert.False(t, money.IsSupported("xyz"))
+
+	assert.Equal(t, 0, money.Exponent("jpy"))
+	assert.Equal(t, 2, money.Exponent("usd"))
+	assert.Equal(t, 3, money.Exponent("bhd"))
+	assert.Contains(t, money.Currencies(), money.DefaultCurrency)
+}
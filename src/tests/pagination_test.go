@@ -0,0 +1,112 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/haseakito/ec_api/models"
+	"github.com/haseakito/ec_api/pagination"
+)
+
+func paginationContext(query string) echo.Context {
+	req := httptest.NewRequest(http.MethodGet, "/?"+query, nil)
+	return echo.New().NewContext(req, httptest.NewRecorder())
+}
+
+func TestCursorEncodeDecode(t *testing.T) {
+	cursor := pagination.Cursor{CreatedAt: time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC), ID: "abc"}
+
+	decoded, err := pagination.Decode(cursor.Encode())
+	assert.NoError(t, err)
+	assert.True(t, cursor.CreatedAt.Equal(decoded.CreatedAt))
+	assert.Equal(t, "abc", decoded.ID)
+
+	for _, invalid := range []string{"!!!", "bm90IGpzb24"} {
+		_, err := pagination.Decode(invalid)
+		assert.ErrorIs(t, err, pagination.ErrInvalidCursor, invalid)
+	}
+}
+
+func TestParsePagination(t *testing.T) {
+	p, err := pagination.ParseKeyset(paginationContext(""))
+	assert.NoError(t, err)
+	assert.Equal(t, pagination.DefaultLimit, p.Limit)
+	assert.Nil(t, p.Cursor)
+
+	for _, invalid := range []string{"limit=0", "limit=101", "limit=x"} {
+		_, err := pagination.ParseKeyset(paginationContext(invalid))
+		assert.ErrorIs(t, err, pagination.ErrInvalidLimit, invalid)
+	}
+
+	// Cursors are only accepted by the kind of listing which issued them
+	keyset := pagination.Cursor{CreatedAt: time.Now(), ID: "abc"}.Encode()
+	offset := pagination.Cursor{Offset: 20}.Encode()
+
+	_, err = pagination.ParseKeyset(paginationContext("cursor=" + keyset))
+	assert.NoError(t, err)
+	_, err = pagination.ParseKeyset(paginationContext("cursor=" + offset))
+	assert.ErrorIs(t, err, pagination.ErrInvalidCursor)
+
+	p, err = pagination.ParseOffset(paginationContext("limit=5&cursor=" + offset))
+	assert.NoError(t, err)
+	assert.Equal(t, 5, p.Limit)
+	assert.Equal(t, 20, p.Cursor.Offset)
+	_, err = pagination.ParseOffset(paginationContext("cursor=" + keyset))
+	assert.ErrorIs(t, err, pagination.ErrInvalidCursor)
+}
+
+func TestKeysetPage(t *testing.T) {
+	now := time.Now()
+	items := []models.Model{
+		{ID: "c", CreatedAt: now},
+		{ID: "b", CreatedAt: now.Add(-time.Minute)},
+		{ID: "a", CreatedAt: now.Add(-2 * time.Minute)},
+	}
+	key := func(m models.Model) models.Model { return m }
+
+	// The extra item is trimmed and the cursor points at the last item of the page
+	page := pagination.NewKeysetPage(items, pagination.Params{Limit: 2}, key)
+	assert.Len(t, page.Data, 2)
+	assert.True(t, page.HasMore)
+	cursor, err := pagination.Decode(*page.NextCursor)
+	assert.NoError(t, err)
+	assert.Equal(t, "b", cursor.ID)
+
+	// The last page has no cursor
+	page = pagination.NewKeysetPage(items, pagination.Params{Limit: 3}, key)
+	assert.Len(t, page.Data, 3)
+	assert.False(t, page.HasMore)
+	assert.Nil(t, page.NextCursor)
+
+	// An empty page is an empty list
+	page = pagination.NewKeysetPage(nil, pagination.Params{Limit: 3}, key)
+	assert.NotNil(t, page.Data)
+}
+
+func TestOffsetPage(t *testing.T) {
+	p := pagination.Params{Limit: 2, Cursor: &pagination.Cursor{Offset: 4}}
+
+	page := pagination.NewOffsetPage([]int{1, 2, 3}, p)
+	assert.Equal(t, []int{1, 2}, page.Data)
+	cursor, err := pagination.Decode(*page.NextCursor)
+	assert.NoError(t, err)
+	assert.Equal(t, 6, cursor.Offset)
+}
+
+func TestNewestScope(t *testing.T) {
+	db := dryRunDB(t)
+	p := pagination.Params{Limit: 2, Cursor: &pagination.Cursor{CreatedAt: time.Now(), ID: "abc"}}
+
+	var reviews []models.Review
+	stmt := db.Scopes(p.Newest("reviews")).Find(&reviews).Statement
+	assert.Contains(t, stmt.SQL.String(), "(reviews.created_at, reviews.id) < (")
+	assert.Contains(t, stmt.SQL.String(), "ORDER BY reviews.created_at DESC,reviews.id DESC LIMIT $3")
+
+	// One item more than the limit is fetched to tell whether there is a next page
+	assert.Equal(t, 3, stmt.Vars[2])
+}
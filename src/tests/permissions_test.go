@@ -0,0 +1,32 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/haseakito/ec_api/auth"
+	"github.com/haseakito/ec_api/models"
+)
+
+func TestRolePermissions(t *testing.T) {
+	// Owners can do everything
+	assert.True(t, auth.HasPermission(models.RoleOwner, auth.PermStoreDelete))
+	assert.True(t, auth.HasPermission(models.RoleOwner, auth.PermMembersManage))
+
+	// Managers cannot delete the store or manage members
+	assert.True(t, auth.HasPermission(models.RoleManager, auth.PermProductsDelete))
+	assert.False(t, auth.HasPermission(models.RoleManager, auth.PermStoreDelete))
+	assert.False(t, auth.HasPermission(models.RoleManager, auth.PermMembersManage))
+
+	// Fulfillment staff handle orders only
+	assert.True(t, auth.HasPermission(models.RoleFulfillment, auth.PermOrdersWrite))
+	assert.False(t, auth.HasPermission(models.RoleFulfillment, auth.PermRevenueRead))
+
+	// Analysts are read-only
+	assert.True(t, auth.HasPermission(models.RoleAnalyst, auth.PermRevenueRead))
+	assert.False(t, auth.HasPermission(models.RoleAnalyst, auth.PermProductsDelete))
+
+	// Unknown roles have no permission
+	assert.False(t, auth.HasPermission("", auth.PermProductsRead))
+}
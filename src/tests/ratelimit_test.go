@@ -0,0 +1,127 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/haseakito/ec_api/ratelimit"
+)
+
+func TestMemoryStoreTokenBucket(t *testing.T) {
+	store := ratelimit.NewMemoryStore()
+	policy := ratelimit.Policy{Name: "test", Requests: 1, Period: time.Second, Burst: 2}
+	now := time.Now()
+
+	// The bucket starts full
+	res, _ := store.Take("client", policy, now)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 1, res.Remaining)
+
+	res, _ = store.Take("client", policy, now)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 0, res.Remaining)
+
+	// The bucket is empty until a token is refilled
+	res, _ = store.Take("client", policy, now)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, time.Second, res.RetryAfter)
+
+	// Other clients have their own bucket
+	res, _ = store.Take("other", policy, now)
+	assert.True(t, res.Allowed)
+
+	// A token is refilled after a second
+	res, _ = store.Take("client", policy, now.Add(time.Second))
+	assert.True(t, res.Allowed)
+}
+
+func TestParsePolicy(t *testing.T) {
+	policy, err := ratelimit.ParsePolicy("checkout", "10/m,5")
+	assert.NoError(t, err)
+	assert.Equal(t, ratelimit.Policy{Name: "checkout", Requests: 10, Period: time.Minute, Burst: 5}, policy)
+
+	policy, err = ratelimit.ParsePolicy("public", "100/h")
+	assert.NoError(t, err)
+	assert.Equal(t, 100, policy.Burst)
+
+	for _, invalid := range []string{"10", "10/d", "0/m", "10/m,x"} {
+		_, err := ratelimit.ParsePolicy("invalid", invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	policy := ratelimit.Policy{Name: "test", Requests: 1, Period: time.Minute, Burst: 1}
+
+	e := echo.New()
+	e.GET("/", func(c echo.Context) error {
+		return c.String(http.StatusOK, "ok")
+	}, ratelimit.Middleware(ratelimit.NewMemoryStore(), policy, ratelimit.KeyByActor))
+
+	// The first request is allowed
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, "0", rec.Header().Get("X-RateLimit-Remaining"))
+
+	// The second one is rejected with the time to wait
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "60", rec.Header().Get("Retry-After"))
+}
+
+func TestRateLimitBeforeAuth(t *testing.T) {
+	policy := ratelimit.Policy{Name: "test_ip", Requests: 1, Period: time.Minute, Burst: 1}
+	rejectAuth := func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error { return echo.ErrUnauthorized }
+	}
+
+	e := echo.New()
+	e.GET("/", func(c echo.Context) error {
+		return c.String(http.StatusOK, "ok")
+	}, ratelimit.Middleware(ratelimit.NewMemoryStore(), policy, ratelimit.KeyByIP), rejectAuth)
+
+	// Failed authentications are limited per IP address, whatever the credentials
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer another-token")
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+}
+
+func TestIPExtractorFromEnv(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "172.28.0.10:51234"
+	req.Header.Set(echo.HeaderXForwardedFor, "203.0.113.7")
+	req.Header.Set(echo.HeaderXRealIP, "203.0.113.8")
+
+	// Without trusted proxies, the forwarded headers are ignored
+	t.Setenv("TRUSTED_PROXIES", "")
+	extract, err := ratelimit.IPExtractorFromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, "172.28.0.10", extract(req))
+
+	// The forwarded headers are only read from the trusted proxies
+	t.Setenv("TRUSTED_PROXIES", "172.28.0.10/32")
+	extract, err = ratelimit.IPExtractorFromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, "203.0.113.7", extract(req))
+
+	req.RemoteAddr = "172.28.0.11:51234"
+	assert.Equal(t, "172.28.0.11", extract(req))
+
+	t.Setenv("TRUSTED_PROXIES", "nginx")
+	_, err = ratelimit.IPExtractorFromEnv()
+	assert.Error(t, err)
+}
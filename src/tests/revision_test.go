@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/haseakito/ec_api/audit"
+	"github.com/haseakito/ec_api/models"
+	"github.com/haseakito/ec_api/revisions"
+)
+
+func TestRevisionSnapshot(t *testing.T) {
+	sku, price, stock := "TEE-1", int64(2500), 10
+	product := models.Product{SKU: &sku, Name: "Tee", Price: &price, Published: true, Stock: &stock, Tags: models.StringArray{"sale"}}
+
+	snapshot := revisions.NewSnapshot(product)
+
+	// Reverting restores the versioned fields and leaves the stock alone
+	restock := 3
+	edited := models.Product{Name: "Shirt", Stock: &restock}
+	snapshot.Apply(&edited)
+	assert.Equal(t, &sku, edited.SKU)
+	assert.Equal(t, "Tee", edited.Name)
+	assert.Equal(t, &price, edited.Price)
+	assert.True(t, edited.Published)
+	assert.Equal(t, models.StringArray{"sale"}, edited.Tags)
+	assert.Equal(t, 3, *edited.Stock)
+
+	// Stock changes are not versioned
+	changes, err := audit.Diff(revisions.NewSnapshot(product), revisions.NewSnapshot(models.Product{SKU: &sku, Name: "Tee", Price: &price, Published: true, Stock: &restock, Tags: models.StringArray{"sale"}}))
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+}
+
+func TestRecordRevision(t *testing.T) {
+	db := dryRunDB(t).Session(&gorm.Session{SkipDefaultTransaction: true})
+
+	// Collect the revisions created
+	var created []models.ProductRevision
+	require.NoError(t, db.Callback().Create().After("gorm:create").Register("test:revisions", func(tx *gorm.DB) {
+		if revision, ok := tx.Statement.Dest.(*models.ProductRevision); ok {
+			created = append(created, *revision)
+		}
+	}))
+
+	updatedAt := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	before := models.Product{Model: models.Model{ID: "p1", UpdatedAt: updatedAt}, Name: "Tee"}
+
+	// Edits which leave the versioned fields unchanged are not recorded
+	stock := 5
+	unchanged := before
+	unchanged.Stock = &stock
+	require.NoError(t, revisions.RecordActor(db, "user", "u1", "r1", before, unchanged, nil))
+	assert.Empty(t, created)
+
+	// The first edit of a product records the product before the edit, then the edit
+	after := before
+	after.Name = "Shirt"
+	require.NoError(t, revisions.RecordActor(db, "user", "u1", "r1", before, after, nil))
+	require.Len(t, created, 2)
+
+	assert.Equal(t, 1, created[0].Version)
+	assert.Empty(t, created[0].ActorID)
+	assert.Equal(t, updatedAt, created[0].CreatedAt)
+	var baseline revisions.Snapshot
+	require.NoError(t, json.Unmarshal(created[0].Snapshot, &baseline))
+	assert.Equal(t, "Tee", baseline.Name)
+
+	assert.Equal(t, 2, created[1].Version)
+	assert.Equal(t, "u1", created[1].ActorID)
+	var changes map[string]audit.Change
+	require.NoError(t, json.Unmarshal(created[1].Changes, &changes))
+	assert.Equal(t, map[string]audit.Change{"name": {Before: "Tee", After: "Shirt"}}, changes)
+}
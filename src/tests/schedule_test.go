@@ -0,0 +1,39 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/haseakito/ec_api/models"
+	"github.com/haseakito/ec_api/requests"
+)
+
+func TestPublishedProductsScope(t *testing.T) {
+	var products []models.Product
+	stmt := dryRunDB(t).Scopes(models.PublishedProducts).Find(&products).Statement
+	sql := stmt.SQL.String()
+
+	// Due schedules are respected by the database even before the scheduler applies them
+	assert.Contains(t, sql, "(products.published OR (products.publish_at <= CURRENT_TIMESTAMP AND products.locked_at IS NULL))")
+	assert.Contains(t, sql, "AND (products.unpublish_at IS NULL OR products.unpublish_at > CURRENT_TIMESTAMP)")
+	assert.Empty(t, stmt.Vars)
+}
+
+func TestProductScheduleRequest(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	soon := time.Now().Add(time.Hour)
+	later := time.Now().Add(2 * time.Hour)
+
+	// Both times are optional, and clearing them cancels the schedule
+	assert.NoError(t, requests.ProductScheduleRequest{}.Validate())
+	assert.NoError(t, requests.ProductScheduleRequest{PublishAt: &soon}.Validate())
+	assert.NoError(t, requests.ProductScheduleRequest{UnpublishAt: &soon}.Validate())
+	assert.NoError(t, requests.ProductScheduleRequest{PublishAt: &soon, UnpublishAt: &later}.Validate())
+
+	assert.ErrorContains(t, requests.ProductScheduleRequest{PublishAt: &past}.Validate(), "Publish time must be in the future")
+	assert.ErrorContains(t, requests.ProductScheduleRequest{UnpublishAt: &past}.Validate(), "Unpublish time must be in the future")
+	assert.ErrorContains(t, requests.ProductScheduleRequest{PublishAt: &later, UnpublishAt: &soon}.Validate(), "Unpublish time must be after the publish time")
+	assert.ErrorContains(t, requests.ProductScheduleRequest{PublishAt: &soon, UnpublishAt: &soon}.Validate(), "Unpublish time must be after the publish time")
+}
@@ -0,0 +1,54 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/haseakito/ec_api/models"
+	"github.com/haseakito/ec_api/search"
+)
+
+func TestPrefixQuery(t *testing.T) {
+	assert.Equal(t, "red:* & t:* & sh:*", search.PrefixQuery("Red t-sh"))
+	assert.Equal(t, "mug:*", search.PrefixQuery("  mug  "))
+
+	// Operators and quotes are dropped, so user input never alters the tsquery
+	assert.Equal(t, "a:* & b:* & c:*", search.PrefixQuery("a & !b | c:*'"))
+	assert.Equal(t, "", search.PrefixQuery("!!! &"))
+	assert.Equal(t, "", search.PrefixQuery(""))
+}
+
+func TestSearchMatch(t *testing.T) {
+	db := dryRunDB(t)
+
+	var products []models.Product
+	sql := db.Scopes(search.Match("red sh")).Find(&products).Statement.SQL.String()
+	assert.Contains(t, sql, "ts_headline(")
+	// The name and description are escaped before highlighting, so merchants cannot inject markup into snippets
+	assert.Contains(t, sql, "'<', '&lt;'")
+	assert.Contains(t, sql, "products.search_vector @@ to_tsquery(")
+	assert.Contains(t, sql, "ORDER BY search_rank DESC")
+}
+
+func TestSearchMatchProducts(t *testing.T) {
+	db := testDB(t)
+	store := createTestStore(t, db)
+	shirt := createTestProduct(t, db, store.ID, `Red shirt <script>alert("x")</script>`, nil)
+	createTestProduct(t, db, store.ID, "Blue mug", nil)
+
+	// Only the matching products are found, by prefix
+	var products []models.Product
+	require.NoError(t, db.Scopes(search.Match("re sh")).Where("products.store_id = ?", store.ID).Find(&products).Error)
+	require.Len(t, products, 1)
+	assert.Equal(t, shirt.ID, products[0].ID)
+
+	// The snippet highlights the matches and escapes the markup of the merchant
+	require.NotNil(t, products[0].Snippet)
+	snippet := *products[0].Snippet
+	assert.Contains(t, snippet, "<mark>Red</mark>")
+	assert.Contains(t, snippet, "&lt;script&gt;")
+	assert.NotContains(t, snippet, "<script>")
+	assert.NotContains(t, snippet, `"`)
+}
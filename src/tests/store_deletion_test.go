@@ -0,0 +1,108 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/haseakito/ec_api/handlers/admin"
+	"github.com/haseakito/ec_api/models"
+	"github.com/haseakito/ec_api/trash"
+)
+
+func TestOpenOrdersScope(t *testing.T) {
+	var orders []models.Order
+	sql := dryRunDB(t).Scopes(models.OpenOrders).Where("store_id = ?", "s1").Find(&orders).Statement.SQL.String()
+
+	// Unpaid orders and paid orders awaiting their fulfillment prevent the deletion of the store
+	assert.Contains(t, sql, "orders.canceled_at IS NULL AND orders.fulfilled_at IS NULL")
+}
+
+func TestNewStoreDeletionJob(t *testing.T) {
+	store := models.Store{Model: models.Model{ID: "s1"}}
+
+	job := trash.NewStoreDeletionJob(store, "user", "u1", "r1")
+	assert.Equal(t, "s1", job.StoreID)
+	assert.Equal(t, trash.JobTypeStoreDeletion, job.Type)
+	assert.Equal(t, "user", job.ActorType)
+	assert.Equal(t, "u1", job.ActorID)
+	assert.Equal(t, "r1", job.RequestID)
+	assert.True(t, json.Valid(job.Params))
+}
+
+func TestStoreDeletionResult(t *testing.T) {
+	raw, err := json.Marshal(trash.StoreDeletionResult{Products: 3, Deleted: 2, Archived: 1})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"products": 3, "deleted": 2, "archived": 1}`, string(raw))
+
+	raw, err = json.Marshal(trash.StoreDeletionResult{Products: 3, Deleted: 2, Archived: 1, Store: trash.StoreArchived})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"products": 3, "deleted": 2, "archived": 1, "store": "archived"}`, string(raw))
+}
+
+func TestHasOpenOrders(t *testing.T) {
+	db := testDB(t)
+	store := createTestStore(t, db)
+
+	open, err := trash.HasOpenOrders(db, store.ID)
+	require.NoError(t, err)
+	assert.False(t, open)
+
+	// Unpaid orders are open until they are cancelled
+	unpaid := createTestOrder(t, db, store.ID, false)
+	open, err = trash.HasOpenOrders(db, store.ID)
+	require.NoError(t, err)
+	assert.True(t, open)
+	require.NoError(t, db.Model(&unpaid).Update("canceled_at", time.Now()).Error)
+
+	// Paid orders are open until they are fulfilled
+	paid := createTestOrder(t, db, store.ID, true)
+	open, err = trash.HasOpenOrders(db, store.ID)
+	require.NoError(t, err)
+	assert.True(t, open)
+	require.NoError(t, db.Model(&paid).Update("fulfilled_at", time.Now()).Error)
+
+	open, err = trash.HasOpenOrders(db, store.ID)
+	require.NoError(t, err)
+	assert.False(t, open)
+}
+
+// deleteStore calls the admin handler trashing the store
+func deleteStore(t *testing.T, db *gorm.DB, storeID string) int {
+	t.Helper()
+
+	e := echo.New()
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodDelete, "/", nil), rec)
+	c.SetParamNames("id")
+	c.SetParamValues(storeID)
+
+	require.NoError(t, admin.NewAdminStoreHandler(db).DeleteStore(c))
+	return rec.Code
+}
+
+func TestDeleteStoreWithOpenOrders(t *testing.T) {
+	db := testDB(t)
+	store := createTestStore(t, db)
+	order := createTestOrder(t, db, store.ID, false)
+
+	// The store is kept while it has open orders
+	assert.Equal(t, http.StatusConflict, deleteStore(t, db, store.ID))
+	require.NoError(t, db.Take(&models.Store{}, "id = ?", store.ID).Error)
+
+	// Once the orders are closed, the store is moved to the trash
+	require.NoError(t, db.Model(&order).Update("canceled_at", time.Now()).Error)
+	assert.Equal(t, http.StatusOK, deleteStore(t, db, store.ID))
+
+	var trashed models.Store
+	require.NoError(t, db.Unscoped().Take(&trashed, "id = ?", store.ID).Error)
+	assert.True(t, trashed.DeletedAt.Valid)
+	assert.Equal(t, http.StatusNotFound, deleteStore(t, db, store.ID))
+}
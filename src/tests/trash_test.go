@@ -0,0 +1,61 @@
+package tests
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+
+	"github.com/haseakito/ec_api/models"
+	"github.com/haseakito/ec_api/trash"
+)
+
+func TestTrashRetentionFromEnv(t *testing.T) {
+	t.Setenv("TRASH_RETENTION_DAYS", "")
+	assert.Equal(t, trash.DefaultRetention, trash.RetentionFromEnv())
+
+	t.Setenv("TRASH_RETENTION_DAYS", "7")
+	assert.Equal(t, 7*24*time.Hour, trash.RetentionFromEnv())
+
+	for _, value := range []string{"0", "-3", "a week"} {
+		t.Setenv("TRASH_RETENTION_DAYS", value)
+		assert.Equal(t, trash.DefaultRetention, trash.RetentionFromEnv(), value)
+	}
+}
+
+func TestSoftDelete(t *testing.T) {
+	db := dryRunDB(t)
+
+	// Deleting a product moves it to the trash, and trashed products are hidden from queries
+	stmt := db.Session(&gorm.Session{SkipDefaultTransaction: true}).Delete(&models.Product{Model: models.Model{ID: "p1"}}).Statement
+	assert.Contains(t, stmt.SQL.String(), `UPDATE "products" SET "deleted_at"=$1 WHERE "products"."id" = $2 AND "products"."deleted_at" IS NULL`)
+
+	var products []models.Product
+	stmt = db.Scopes(models.ActiveStoreProducts).Find(&products).Statement
+	assert.Contains(t, stmt.SQL.String(), "SELECT id FROM stores WHERE suspended_at IS NULL AND deleted_at IS NULL")
+	assert.Contains(t, stmt.SQL.String(), `"products"."deleted_at" IS NULL`)
+
+	var images []models.ProductImage
+	stmt = db.Find(&images).Statement
+	assert.Contains(t, stmt.SQL.String(), `"product_images"."deleted_at" IS NULL`)
+
+	var stores []models.Store
+	stmt = db.Find(&stores).Statement
+	assert.Contains(t, stmt.SQL.String(), `"stores"."deleted_at" IS NULL`)
+}
+
+func TestProductSKUIndexIgnoresTrash(t *testing.T) {
+	s, err := schema.Parse(&models.Product{}, &sync.Map{}, schema.NamingStrategy{})
+	require.NoError(t, err)
+
+	// A trashed product does not hold its SKU, so that a new product can take it
+	index := s.LookIndex("idx_products_store_sku")
+	require.NotNil(t, index)
+	assert.Equal(t, "UNIQUE", index.Class)
+	assert.Equal(t, "deleted_at IS NULL", index.Where)
+	assert.Len(t, index.Fields, 2)
+}
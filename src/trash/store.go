@@ -0,0 +1,258 @@
+package trash
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/haseakito/ec_api/audit"
+	"github.com/haseakito/ec_api/jobs"
+	"github.com/haseakito/ec_api/models"
+	"github.com/haseakito/ec_api/utils"
+)
+
+// JobTypeStoreDeletion is the type of the jobs permanently deleting trashed stores
+const JobTypeStoreDeletion = "store_deletion"
+
+// Outcomes of store deletions
+const (
+	StoreDeleted  = "deleted"
+	StoreArchived = "archived"
+)
+
+// storeDeletionBatchSize is the number of products read at a time by a store deletion
+const storeDeletionBatchSize = 50
+
+var (
+	// ErrOpenOrders is returned when a store with unpaid or unfulfilled orders is deleted.
+	ErrOpenOrders = errors.New("trash: the store has unpaid or unfulfilled orders")
+
+	// ErrNotTrashed is returned when a store is restored before its deletion job runs.
+	ErrNotTrashed = errors.New("trash: the store is not in the trash")
+)
+
+/*
+Description:
+
+	StoreDeletionResult holds the result of a store deletion job, updated as the products are purged.
+
+Fields:
+
+	Products (int): The number of products to purge.
+	Deleted (int): The number of products deleted.
+	Archived (int): The number of products archived because orders reference them.
+	Store (string): The outcome for the store once the job is done, deleted or archived when the store has orders.
+*/
+type StoreDeletionResult struct {
+	Products int    `json:"products"`
+	Deleted  int    `json:"deleted"`
+	Archived int    `json:"archived"`
+	Store    string `json:"store,omitempty"`
+}
+
+/*
+Description:
+
+	Instantiate a job permanently deleting a trashed store, to be enqueued with jobs.Enqueue.
+
+Parameters:
+
+	store (models.Store): The trashed store.
+	actorType (string): The type of the actor deleting the store.
+	actorID (string): The ID of the actor deleting the store.
+	requestID (string): The ID of the request deleting the store, if any.
+
+Returns:
+
+	models.Job: The store deletion job.
+*/
+func NewStoreDeletionJob(store models.Store, actorType, actorID, requestID string) models.Job {
+	return models.Job{
+		StoreID:   store.ID,
+		Type:      JobTypeStoreDeletion,
+		ActorType: actorType,
+		ActorID:   actorID,
+		RequestID: requestID,
+		Params:    models.JSON("{}"),
+	}
+}
+
+/*
+Description:
+
+	Report whether a store has open orders, which prevent its deletion.
+
+Parameters:
+
+	db (*gorm.DB): A pointer to the GORM database connection.
+	storeID (string): The ID of the store.
+
+Returns:
+
+	(bool, error): Whether the store has unpaid or unfulfilled orders. Otherwise, any error encountered during the query.
+*/
+func HasOpenOrders(db *gorm.DB, storeID string) (bool, error) {
+	var count int64
+	err := db.Model(&models.Order{}).Scopes(models.OpenOrders).Where("store_id = ?", storeID).Count(&count).Error
+	return count > 0, err
+}
+
+/*
+Description:
+
+	Get the pending or running deletion job of a store, if any.
+
+Parameters:
+
+	db (*gorm.DB): A pointer to the GORM database connection.
+	storeID (string): The ID of the store.
+
+Returns:
+
+	(*models.Job, error): The deletion job, nil if the store is not being deleted. Otherwise, any error encountered during the query.
+*/
+func RunningStoreDeletion(db *gorm.DB, storeID string) (*models.Job, error) {
+	var jobs []models.Job
+	if err := db.Omit("input").
+		Where("store_id = ? AND type = ? AND status IN ?", storeID, JobTypeStoreDeletion, []string{models.JobPending, models.JobRunning}).
+		Limit(1).
+		Find(&jobs).Error; err != nil || len(jobs) == 0 {
+		return nil, err
+	}
+
+	return &jobs[0], nil
+}
+
+/*
+Description:
+
+	Run a store deletion job. The products of the trashed store are purged one at a time, saving the progress with each product,
+	then its image, categories, collections, members and API keys are deleted. A store with orders is archived with its orders
+	instead of deleted, so that the orders keep their store. The job fails while the store has open orders or if the store was restored.
+
+Parameters:
+
+	db (*gorm.DB): A pointer to the GORM database connection.
+	job (*models.Job): The job, with the trashed store as store.
+
+Returns:
+
+	error: ErrOpenOrders, ErrNotTrashed, or any error encountered during the deletion.
+*/
+func DeleteStore(db *gorm.DB, job *models.Job) error {
+	// Get the trashed store with the store id
+	// If the store was already deleted, then there is nothing left to do
+	var store models.Store
+	err := db.Unscoped().Take(&store, "id = ?", job.StoreID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !store.DeletedAt.Valid {
+		return ErrNotTrashed
+	}
+	if store.ArchivedAt != nil {
+		return nil
+	}
+
+	if open, err := HasOpenOrders(db, store.ID); err != nil || open {
+		if err == nil {
+			err = ErrOpenOrders
+		}
+		return err
+	}
+
+	// Resume from the result saved with the progress
+	var result StoreDeletionResult
+	if len(job.Result) > 0 {
+		if err := json.Unmarshal(job.Result, &result); err != nil {
+			return err
+		}
+	}
+
+	// The products left to purge are the ones neither deleted nor archived yet
+	remaining := func() *gorm.DB {
+		return db.Unscoped().Model(&models.Product{}).Where("store_id = ? AND archived_at IS NULL", store.ID)
+	}
+	if job.Progress == 0 {
+		var count int64
+		if err := remaining().Count(&count).Error; err != nil {
+			return err
+		}
+		result.Products = int(count)
+	}
+
+	for {
+		var products []models.Product
+		if err := remaining().Order("id").Limit(storeDeletionBatchSize).Find(&products).Error; err != nil {
+			return err
+		}
+		if len(products) == 0 {
+			break
+		}
+
+		for _, product := range products {
+			if _, err := purgeProduct(db, product, func(tx *gorm.DB, archived bool) error {
+				progress := result
+				if archived {
+					progress.Archived++
+				} else {
+					progress.Deleted++
+				}
+				if err := jobs.Checkpoint(tx, job, job.Progress+1, progress); err != nil {
+					return err
+				}
+				result = progress
+				return nil
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Delete the image of the store before the rows, so that an interrupted deletion is retried
+	if store.ImageUrl != nil {
+		if err := utils.Delete(*store.ImageUrl); err != nil {
+			return err
+		}
+	}
+
+	var orders int64
+	if err := db.Model(&models.Order{}).Where("store_id = ?", store.ID).Count(&orders).Error; err != nil {
+		return err
+	}
+
+	// Delete the rows of the store and record the audit event in a transaction
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("collection_id IN (?)", tx.Model(&models.Collection{}).Select("id").Where("store_id = ?", store.ID)).Delete(&models.CollectionProduct{}).Error; err != nil {
+			return err
+		}
+		for _, model := range []interface{}{&models.Collection{}, &models.Category{}, &models.StoreMember{}, &models.APIKey{}} {
+			if err := tx.Where("store_id = ?", store.ID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+
+		if orders > 0 {
+			// Keep the store for its orders, without its image
+			result.Store = StoreArchived
+			if err := tx.Unscoped().Model(&store).Updates(map[string]interface{}{"archived_at": time.Now(), "image_url": nil}).Error; err != nil {
+				return err
+			}
+		} else {
+			result.Store = StoreDeleted
+			if err := tx.Unscoped().Delete(&store).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := audit.RecordActor(tx, job.ActorType, job.ActorID, job.RequestID, store.ID, "store", store.ID, models.AuditDelete, store, nil); err != nil {
+			return err
+		}
+		return jobs.Checkpoint(tx, job, job.Progress, result)
+	})
+}
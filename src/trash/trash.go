@@ -0,0 +1,236 @@
+package trash
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/haseakito/ec_api/jobs"
+	"github.com/haseakito/ec_api/models"
+	"github.com/haseakito/ec_api/utils"
+)
+
+// DefaultRetention is how long trashed stores, products and images are kept before they are purged
+const DefaultRetention = 30 * 24 * time.Hour
+
+// purgeBatchSize is the number of stores, products and images purged per run
+const purgeBatchSize = 100
+
+// purgerActorType and purgerActorID identify the purge of the trash as the actor of the store deletions it starts
+const (
+	purgerActorType = "system"
+	purgerActorID   = "trash"
+)
+
+/*
+Description:
+
+	Permanently delete the stores, products and product images trashed before the cutoff, along with their objects in storage.
+	Trashed stores are deleted by a store deletion job, unless they have open orders. Products referenced by orders are archived
+	instead of deleted. The objects are deleted before the rows, so that an interrupted purge is retried on the next run.
+	Entities which cannot be purged are logged and skipped until the next run.
+
+Parameters:
+
+	db (*gorm.DB): A pointer to the GORM database connection.
+	cutoff (time.Time): Entities trashed before this time are purged.
+
+Returns:
+
+	error: Any error encountered while querying the trashed entities.
+*/
+func Purge(db *gorm.DB, cutoff time.Time) error {
+	var stores []models.Store
+	if err := db.Unscoped().Where("deleted_at < ? AND archived_at IS NULL", cutoff).Limit(purgeBatchSize).Find(&stores).Error; err != nil {
+		return err
+	}
+	for _, store := range stores {
+		if err := enqueuePurge(db, store); err != nil {
+			log.Println("trash: failed to purge store", store.ID+":", err)
+		}
+	}
+
+	// The products of trashed stores are purged with their store
+	var products []models.Product
+	if err := db.Unscoped().
+		Where("deleted_at < ? AND archived_at IS NULL", cutoff).
+		Where("store_id IN (SELECT id FROM stores WHERE deleted_at IS NULL)").
+		Limit(purgeBatchSize).
+		Find(&products).Error; err != nil {
+		return err
+	}
+	for _, product := range products {
+		if _, err := purgeProduct(db, product, nil); err != nil {
+			log.Println("trash: failed to purge product", product.ID+":", err)
+		}
+	}
+
+	var images []models.ProductImage
+	if err := db.Unscoped().Where("deleted_at < ?", cutoff).Limit(purgeBatchSize).Find(&images).Error; err != nil {
+		return err
+	}
+	for _, image := range images {
+		if err := purgeImages(db, []models.ProductImage{image}); err != nil {
+			log.Println("trash: failed to purge product image", image.ID+":", err)
+		}
+	}
+
+	return nil
+}
+
+/*
+Description:
+
+	Periodically purge the entities trashed for longer than the retention period.
+
+Parameters:
+
+	db (*gorm.DB): A pointer to the GORM database connection.
+	interval (time.Duration): The time between two purges.
+	retention (time.Duration): How long trashed entities are kept.
+*/
+func StartPurger(db *gorm.DB, interval time.Duration, retention time.Duration) {
+	go func() {
+		for range time.Tick(interval) {
+			if err := Purge(db, time.Now().Add(-retention)); err != nil {
+				log.Println("trash: failed to purge the trash:", err)
+			}
+		}
+	}()
+}
+
+/*
+Description:
+
+	Get how long trashed entities are kept from the TRASH_RETENTION_DAYS environment variable, e.g. "30".
+	Defaults to DefaultRetention when the variable is not a positive number of days.
+
+Returns:
+
+	time.Duration: The retention period.
+*/
+func RetentionFromEnv() time.Duration {
+	days, err := strconv.Atoi(os.Getenv("TRASH_RETENTION_DAYS"))
+	if err != nil || days <= 0 {
+		return DefaultRetention
+	}
+
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// enqueuePurge starts the deletion of a trashed store, unless it has open orders or is already being deleted
+func enqueuePurge(db *gorm.DB, store models.Store) error {
+	open, err := HasOpenOrders(db, store.ID)
+	if err != nil || open {
+		return err
+	}
+
+	running, err := RunningStoreDeletion(db, store.ID)
+	if err != nil || running != nil {
+		return err
+	}
+
+	job := NewStoreDeletionJob(store, purgerActorType, purgerActorID, "")
+	return jobs.Enqueue(db, &job)
+}
+
+/*
+Description:
+
+	Permanently delete a product with its images, and unassign it from its categories and collections.
+	If orders reference the product, then the product is archived: it keeps its variants for the orders, and stays in the trash for good.
+	The objects of the images are deleted first, then the rows are deleted in a transaction.
+
+Parameters:
+
+	db (*gorm.DB): A pointer to the GORM database connection.
+	product (models.Product): The product to purge.
+	done (func(tx *gorm.DB, archived bool) error): Called in the transaction of the deletion, e.g. to save the progress of a job. Nullable.
+
+Returns:
+
+	(bool, error): Whether the product was archived instead of deleted. Otherwise, any error encountered during the deletion.
+*/
+func purgeProduct(db *gorm.DB, product models.Product, done func(tx *gorm.DB, archived bool) error) (bool, error) {
+	var images []models.ProductImage
+	if err := db.Unscoped().Where("product_id = ?", product.ID).Find(&images).Error; err != nil {
+		return false, err
+	}
+	if err := purgeImages(db, images); err != nil {
+		return false, err
+	}
+
+	var ordered int64
+	if err := db.Model(&models.OrderItem{}).Where("product_id = ?", product.ID).Count(&ordered).Error; err != nil {
+		return false, err
+	}
+	archived := ordered > 0
+
+	return archived, db.Transaction(func(tx *gorm.DB) error {
+		// Unassign the product from its categories and collections, and delete its reviews, revisions and draft
+		if err := tx.Exec("DELETE FROM product_categories WHERE product_id = ?", product.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("product_id = ?", product.ID).Delete(&models.CollectionProduct{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("product_id = ?", product.ID).Delete(&models.Review{}).Error; err != nil {
+			return err
+		}
+		for _, model := range []interface{}{&models.ProductRevision{}, &models.ProductDraft{}} {
+			if err := tx.Where("product_id = ?", product.ID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+
+		if archived {
+			// Keep the product and its variants for the orders, out of the trash listings
+			now := time.Now()
+			if err := tx.Unscoped().Model(&product).Updates(map[string]interface{}{
+				"archived_at": now,
+				"deleted_at":  gorm.Expr("COALESCE(deleted_at, ?)", now),
+			}).Error; err != nil {
+				return err
+			}
+		} else {
+			// Delete the variants and options of the product, then the product
+			if err := tx.Exec("DELETE FROM variant_option_values WHERE product_variant_id IN (SELECT id FROM product_variants WHERE product_id = ?)", product.ID).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("product_id = ?", product.ID).Delete(&models.ProductVariant{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("option_id IN (?)", tx.Model(&models.ProductOption{}).Select("id").Where("product_id = ?", product.ID)).Delete(&models.ProductOptionValue{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("product_id = ?", product.ID).Delete(&models.ProductOption{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Unscoped().Delete(&product).Error; err != nil {
+				return err
+			}
+		}
+
+		if done == nil {
+			return nil
+		}
+		return done(tx, archived)
+	})
+}
+
+// purgeImages permanently deletes product images and their objects in storage
+func purgeImages(db *gorm.DB, images []models.ProductImage) error {
+	for _, image := range images {
+		if err := utils.Delete(image.Url); err != nil {
+			return err
+		}
+		if err := db.Unscoped().Delete(&image).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// maxRedirects is the number of redirects followed when downloading a file from a URL
+const maxRedirects = 3
+
+// ErrNonPublicAddress is returned when a download targets an address which is not on the public internet.
+var ErrNonPublicAddress = errors.New("the address is not public")
+
+// sharedAddressSpace is the carrier-grade NAT range, which is not routable on the public internet
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+/*
+Description:
+
+	Report whether an IP address is on the public internet, i.e. neither loopback, private, link-local, multicast nor unspecified.
+
+Parameters:
+
+	ip (net.IP): The IP address.
+
+Returns:
+
+	bool: Whether the address is public.
+*/
+func IsPublicIP(ip net.IP) bool {
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	if ip4 := ip.To4(); ip4 != nil && (ip4[0] == 0 || sharedAddressSpace.Contains(ip4)) {
+		return false
+	}
+
+	return true
+}
+
+/*
+Description:
+
+	Instantiate an HTTP client for downloading files from URLs given by users. The client only connects to public addresses,
+	which are checked once resolved so that DNS names and redirects cannot point it to the internal network, and ignores proxies.
+
+Returns:
+
+	*http.Client: The HTTP client.
+*/
+func newPublicClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if !IsPublicIP(net.ParseIP(host)) {
+				return ErrNonPublicAddress
+			}
+			return nil
+		},
+	}
+
+	return &http.Client{
+		Timeout: 30 * time.Second,
+		Transport: &http.Transport{
+			Proxy:               nil,
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 10 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return errors.New("too many redirects")
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return errors.New("redirect to a URL which is not http or https")
+			}
+			return nil
+		},
+	}
+}
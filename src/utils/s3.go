@@ -1,10 +1,17 @@
 package utils
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
 	"log"
+	"mime"
 	"mime/multipart"
+	"net/http"
 	"net/url"
 	"os"
+	"path"
 	"strings"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -63,10 +70,99 @@ func Upload(file *multipart.FileHeader, key string) (string, error) {
 	return res.Location, nil
 }
 
+// Errors returned by UploadFromURL, which are safe to report to the user who gave the URL
+var (
+	ErrImageURL      = errors.New("image URL must be an http or https URL")
+	ErrImageDownload = errors.New("failed to download image")
+	ErrImageType     = errors.New("file type must be JPEG or PNG")
+	ErrImageSize     = errors.New("File size exceeds the limit of 5MB")
+)
+
 /*
 Description:
 
-	Delete a file in AWS S3 bucket.
+	Download an image from a URL and upload it to AWS S3 bucket, so that the image is served from the bucket
+	and can be deleted with the product. Only JPEG and PNG images up to 5MB are accepted.
+	Only images on the public internet are downloaded, and download failures are reported without details,
+	so that the URL cannot be used to reach or probe the internal network.
+
+Parameters:
+
+	imageUrl (string): The http or https URL of the image.
+	key (string): The key prefix for the file in S3.
+
+Returns:
+
+	(string, error): The URL of the uploaded file. Otherwise, any error encountered while downloading or uploading the image.
+*/
+func UploadFromURL(imageUrl string, key string) (string, error) {
+	// Only download images from the web
+	u, err := url.Parse(imageUrl)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", ErrImageURL
+	}
+
+	// Download the image from a public address
+	// If the download is unsuccessful, then throw an error
+	res, err := newPublicClient().Get(imageUrl)
+	if err != nil {
+		return "", ErrImageDownload
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", ErrImageDownload
+	}
+
+	// Check file type (jpeg or png)
+	contentType, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	if contentType != "image/jpeg" && contentType != "image/png" {
+		return "", ErrImageType
+	}
+
+	// Check file size (Max size 5MB limit)
+	const maxFileSize = 5 * 1024 * 1024
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxFileSize+1))
+	if err != nil {
+		return "", ErrImageDownload
+	}
+	if len(body) > maxFileSize {
+		return "", ErrImageSize
+	}
+
+	// Prefix the file name with a random token so that images with the same name do not overwrite each other
+	prefix, err := GenerateToken("")
+	if err != nil {
+		return "", err
+	}
+
+	// Initialize AWS session
+	// If initialize failed, then throw an error
+	sess, err := session.NewSession()
+	if err != nil {
+		return "", err
+	}
+
+	// Upload file to AWS S3
+	// If there a problem with uploading, throw an error
+	uploaded, err := s3manager.NewUploader(sess).Upload(&s3manager.UploadInput{
+		Bucket:      aws.String(os.Getenv("AWS_BUCKET_NAME")),
+		Key:         aws.String(key + prefix[:16] + "-" + url.QueryEscape(path.Base(u.Path))),
+		Body:        bytes.NewReader(body),
+		ContentType: aws.String(contentType),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// Return the image url location
+	return uploaded.Location, nil
+}
+
+/*
+Description:
+
+	Delete a file in AWS S3 bucket. Deleting a file which does not exist succeeds, so that deletions can be retried.
 
 Parameters:
 
@@ -74,17 +170,21 @@ Parameters:
 
 Returns:
 
-	error: Any error encountered during the upload process.
+	error: Any error encountered during the deletion process, e.g. a URL which is not in the bucket.
 */
 func Delete(imageUrl string) error {
 	// Parse the S3 object key from the image URL
-	key := imageUrl[strings.Index(imageUrl, "amazonaws.com/")+len("amazonaws.com/"):]
+	// If the URL is not an S3 URL, then throw an error
+	index := strings.Index(imageUrl, "amazonaws.com/")
+	if index < 0 {
+		return fmt.Errorf("not an S3 URL: %s", imageUrl)
+	}
+	key := imageUrl[index+len("amazonaws.com/"):]
 
 	// Initialize AWS session
 	sess, err := session.NewSession()
 	if err != nil {
-		log.Fatal("failed to create AWS session: ", err)
-		return err
+		return fmt.Errorf("failed to create AWS session: %w", err)
 	}
 
 	// Initialize S3 client
@@ -97,8 +197,7 @@ func Delete(imageUrl string) error {
 		Key:    aws.String(key),
 	})
 	if err != nil {
-		log.Fatal("failed to delete object from S3: ", err)
-		return err
+		return fmt.Errorf("failed to delete object from S3: %w", err)
 	}
 
 	return nil
@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+/*
+Description:
+
+	Convert a name into a URL-friendly slug, e.g. "Summer T-Shirts!" into "summer-t-shirts".
+	Letters and digits are kept in lowercase, and runs of other characters are replaced by a single hyphen.
+
+Parameters:
+
+	name (string): The name to convert.
+
+Returns:
+
+	string: The slug.
+*/
+func Slugify(name string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if hyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			hyphen = false
+		} else {
+			hyphen = true
+		}
+	}
+
+	return b.String()
+}
+
+/*
+Description:
+
+	Normalize free-form tags by trimming and lowercasing them, and removing the empty and duplicate tags.
+
+Parameters:
+
+	tags ([]string): The tags to normalize.
+
+Returns:
+
+	[]string: The normalized tags in their original order.
+*/
+func NormalizeTags(tags []string) []string {
+	normalized := []string{}
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+
+	return normalized
+}
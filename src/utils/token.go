@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+/*
+Description:
+
+	Generate a random token with the given prefix. The token is only returned once and should be stored hashed.
+
+Parameters:
+
+	prefix (string): The prefix prepended to the random part of the token.
+
+Returns:
+
+	(string, error): The generated token. Otherwise, any error encountered while reading random bytes.
+*/
+func GenerateToken(prefix string) (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return prefix + hex.EncodeToString(b), nil
+}
+
+/*
+Description:
+
+	Hash a token with SHA-256 so that it can be stored and looked up without keeping the plain token.
+
+Parameters:
+
+	token (string): The plain token.
+
+Returns:
+
+	string: The hex encoded SHA-256 hash of the token.
+*/
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}